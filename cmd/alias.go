@@ -3,10 +3,18 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/msaglietto/mantrid/domain"
 	"github.com/msaglietto/mantrid/internal/logging"
+	"github.com/msaglietto/mantrid/internal/project"
 	"github.com/spf13/cobra"
 )
 
+var (
+	aliasDescription string
+	aliasCompletion  string
+	aliasLocal       bool
+)
+
 var aliasCmd = &cobra.Command{
 	Use:   "alias",
 	Short: "Manage aliases",
@@ -15,7 +23,20 @@ var aliasCmd = &cobra.Command{
 var addAliasCmd = &cobra.Command{
 	Use:   "add [name] [command]",
 	Short: "Add a new alias",
-	Args:  cobra.ExactArgs(2),
+	Long: `Store a command under a name.
+
+Use --complete to say how the alias parameters are tab-completed:
+
+  files              file names (the default)
+  files:yaml,yml     file names with one of the given extensions
+  dirs               directory names
+  words:dev,prod     a fixed list of words
+  command:<command>  the output lines of a command, e.g.
+                     command:kubectl get ns -o name
+
+Use --local to add the alias to the nearest project alias file,
+.mantrid.yaml or .mantrid/aliases.json, instead of the global store.`,
+	Args: cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		application, err := appFactory(cmd.Context(), GetConfigFile())
 		if err != nil {
@@ -27,12 +48,30 @@ var addAliasCmd = &cobra.Command{
 
 		application.Logger.Info("adding new alias", "name", name)
 
-		if err := application.AliasService.CreateAlias(ctx, name, command); err != nil {
+		var opts []domain.AliasOption
+		if aliasDescription != "" {
+			opts = append(opts, domain.WithDescription(aliasDescription))
+		}
+		if aliasCompletion != "" {
+			opts = append(opts, domain.WithCompletion(aliasCompletion))
+		}
+		if aliasLocal {
+			if len(application.Layers) == 0 {
+				return fmt.Errorf("failed to create alias: no project alias file in use; create %s in the project directory and run 'mantrid trust allow' on it first", project.YAMLFile)
+			}
+			opts = append(opts, domain.WithLayer(application.Layers[0]))
+		}
+
+		if err := application.AliasService.CreateAlias(ctx, name, command, opts...); err != nil {
 			application.Logger.Error("failed to create alias", "error", err)
 			return fmt.Errorf("failed to create alias: %w", err)
 		}
 
 		application.Logger.Info("alias created successfully", "name", name)
+		if aliasLocal {
+			fmt.Fprintf(cmd.OutOrStdout(), "Alias '%s' created successfully in %s\n", name, application.Layers[0].Name)
+			return nil
+		}
 		fmt.Fprintf(cmd.OutOrStdout(), "Alias '%s' created successfully\n", name)
 		return nil
 	},
@@ -41,4 +80,7 @@ var addAliasCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(aliasCmd)
 	aliasCmd.AddCommand(addAliasCmd)
+	addAliasCmd.Flags().StringVarP(&aliasDescription, "description", "d", "", "Short description shown in help output")
+	addAliasCmd.Flags().StringVar(&aliasCompletion, "complete", "", "How to complete the alias parameters: files[:ext,...], dirs, words:a,b or command:<command>")
+	addAliasCmd.Flags().BoolVar(&aliasLocal, "local", false, "Add the alias to the nearest project alias file")
 }
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/internal/app"
+	"github.com/msaglietto/mantrid/internal/logging"
+	"github.com/spf13/cobra"
+)
+
+// aliasGroupID is the help group that stored aliases are listed under.
+const aliasGroupID = "aliases"
+
+// aliasCommands holds the top-level commands generated from stored aliases.
+var aliasCommands []*cobra.Command
+
+// registerAliasCommands adds one top-level command per stored alias, so that
+// "mantrid k get pods" behaves like "mantrid do k -- get pods".
+// Built-in commands keep precedence: aliases they shadow are reported and
+// skipped. A store that cannot be loaded is ignored here so that built-in
+// commands keep working; they report the error themselves.
+func registerAliasCommands(ctx context.Context, configFilePath string) {
+	unregisterAliasCommands()
+
+	application, err := appFactory(ctx, configFilePath)
+	if err != nil {
+		return
+	}
+
+	// Listing an encrypted store would ask for its passphrase before every
+	// command; its aliases stay available through "mantrid do"
+	cfg := application.Config
+	if cfg.Encrypt && cfg.KeyFile == "" && os.Getenv(app.PassphraseEnv) == "" {
+		application.Logger.Debug("not loading aliases as commands without the passphrase", "env", app.PassphraseEnv)
+		return
+	}
+
+	ctx = logging.WithLogger(ctx, application.Logger)
+	aliases, err := application.AliasService.ListAliases(ctx)
+	if err != nil {
+		application.Logger.Warn("failed to load aliases as commands", "error", err)
+		return
+	}
+
+	sortAliases(aliases)
+
+	builtins := builtinCommandNames()
+	for _, alias := range aliases {
+		if builtins[alias.Name] {
+			application.Logger.Warn("alias shadowed by built-in command",
+				"name", alias.Name, "hint", fmt.Sprintf("use 'mantrid do %s'", alias.Name))
+			continue
+		}
+		if strings.HasPrefix(alias.Name, "-") {
+			application.Logger.Warn("alias name cannot be used as a command",
+				"name", alias.Name, "hint", fmt.Sprintf("use 'mantrid do %s'", alias.Name))
+			continue
+		}
+		aliasCommands = append(aliasCommands, newAliasCommand(alias))
+	}
+
+	if len(aliasCommands) == 0 {
+		return
+	}
+
+	if !rootCmd.ContainsGroup(aliasGroupID) {
+		rootCmd.AddGroup(&cobra.Group{ID: aliasGroupID, Title: "Aliases:"})
+	}
+	rootCmd.AddCommand(aliasCommands...)
+}
+
+// unregisterAliasCommands removes the commands added by registerAliasCommands.
+func unregisterAliasCommands() {
+	if len(aliasCommands) > 0 {
+		rootCmd.RemoveCommand(aliasCommands...)
+	}
+	aliasCommands = nil
+}
+
+// isAliasCommand reports whether name refers to a registered alias command.
+func isAliasCommand(name string) bool {
+	for _, c := range aliasCommands {
+		if c.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// builtinCommandNames returns the names and aliases of every top-level command
+// that is not generated from the alias store, including the ones cobra adds
+// lazily at execution time.
+func builtinCommandNames() map[string]bool {
+	names := map[string]bool{
+		"help":                          true,
+		"completion":                    true,
+		cobra.ShellCompRequestCmd:       true,
+		cobra.ShellCompNoDescRequestCmd: true,
+	}
+
+	for _, c := range rootCmd.Commands() {
+		if c.GroupID == aliasGroupID {
+			continue
+		}
+		names[c.Name()] = true
+		for _, a := range c.Aliases {
+			names[a] = true
+		}
+	}
+
+	return names
+}
+
+// newAliasCommand builds the top-level command that runs alias. Flag parsing
+// is disabled so that every argument after the alias name reaches the alias.
+func newAliasCommand(alias *domain.Alias) *cobra.Command {
+	short := alias.Description
+	if short == "" {
+		short = alias.Command
+	}
+
+	name := alias.Name
+	return &cobra.Command{
+		Use:                name + " [params...]",
+		Short:              short,
+		GroupID:            aliasGroupID,
+		DisableFlagParsing: true,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+			return completeAliasParams(cmd, name, toComplete)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Accept an optional leading "--" for parity with "mantrid do"
+			_, params := parseDoArgs(append([]string{name}, args...))
+			return runAlias(cmd, name, params)
+		},
+	}
+}
+
+// splitLeadingConfigFlag extracts a --config flag given before the first
+// positional argument and returns the remaining arguments. Alias commands do
+// not parse flags, so a root flag in front of them has to be consumed here.
+func splitLeadingConfigFlag(args []string) (configFile string, rest []string) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--config" && i+1 < len(args):
+			configFile = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--config="):
+			configFile = strings.TrimPrefix(arg, "--config=")
+		default:
+			return configFile, append(rest, args[i:]...)
+		}
+	}
+	return configFile, rest
+}
+
+// sortAliases orders aliases by name.
+func sortAliases(aliases []*domain.Alias) {
+	sort.Slice(aliases, func(i, j int) bool {
+		return aliases[i].Name < aliases[j].Name
+	})
+}
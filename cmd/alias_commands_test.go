@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/internal/app"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupAliasCommands registers the aliases of the current test app as
+// top-level commands and removes them again when the test ends.
+func setupAliasCommands(t *testing.T) {
+	t.Helper()
+
+	registerAliasCommands(context.Background(), "")
+	t.Cleanup(unregisterAliasCommands)
+}
+
+func TestAliasCommands(t *testing.T) {
+	t.Run("alias runs as top-level command", func(t *testing.T) {
+		application := setupTestApp(t)
+		ctx := context.Background()
+		application.AliasService.CreateAlias(ctx, "fail", "exit")
+		setupAliasCommands(t)
+
+		_, err := runCommand(t, "fail", "3")
+		var exitErr *CommandExitError
+		require.True(t, errors.As(err, &exitErr))
+		assert.Equal(t, 3, exitErr.ExitCode)
+	})
+
+	t.Run("flags pass through without separator", func(t *testing.T) {
+		application := setupTestApp(t)
+		ctx := context.Background()
+		application.AliasService.CreateAlias(ctx, "check", `test "$1" = "--verbose"`)
+		setupAliasCommands(t)
+
+		_, err := runCommand(t, "check", "--verbose")
+		assert.NoError(t, err)
+
+		_, err = runCommand(t, "check", "--", "--verbose")
+		assert.NoError(t, err)
+	})
+
+	t.Run("built-in commands keep precedence", func(t *testing.T) {
+		application := setupTestApp(t)
+		ctx := context.Background()
+		application.AliasService.CreateAlias(ctx, "alias", "echo shadowed")
+		setupAliasCommands(t)
+
+		assert.False(t, isAliasCommand("alias"))
+
+		output, err := runCommand(t, "alias", "list")
+		assert.NoError(t, err)
+		assert.Contains(t, output, "echo shadowed")
+	})
+
+	t.Run("help lists aliases with descriptions", func(t *testing.T) {
+		application := setupTestApp(t)
+		ctx := context.Background()
+		application.AliasService.CreateAlias(ctx, "k", "kubectl", domain.WithDescription("Run kubectl"))
+		application.AliasService.CreateAlias(ctx, "gs", "git status")
+		setupAliasCommands(t)
+
+		output, err := runCommand(t, "--help")
+		assert.NoError(t, err)
+		assert.Contains(t, output, "Aliases:")
+		assert.Regexp(t, `k\s+Run kubectl`, output)
+		assert.Regexp(t, `gs\s+git status`, output)
+	})
+}
+
+func TestSharedAppFactory(t *testing.T) {
+	application := setupTestApp(t)
+	calls := 0
+	factory, closeApps := sharedAppFactory(func(ctx context.Context, configFilePath string) (*app.App, error) {
+		calls++
+		return application, nil
+	})
+	defer closeApps()
+
+	ctx := context.Background()
+	first, _ := factory(ctx, "")
+	second, _ := factory(ctx, "")
+	assert.Same(t, first, second)
+	assert.Equal(t, 1, calls)
+
+	factory(ctx, "other.yaml")
+	assert.Equal(t, 2, calls)
+}
+
+func TestSplitLeadingConfigFlag(t *testing.T) {
+	tests := []struct {
+		name           string
+		args           []string
+		expectedConfig string
+		expectedRest   []string
+	}{
+		{
+			name:         "no flags",
+			args:         []string{"k", "get", "pods"},
+			expectedRest: []string{"k", "get", "pods"},
+		},
+		{
+			name:           "separate value",
+			args:           []string{"--config", "c.yaml", "k", "--config", "x"},
+			expectedConfig: "c.yaml",
+			expectedRest:   []string{"k", "--config", "x"},
+		},
+		{
+			name:           "inline value",
+			args:           []string{"--config=c.yaml", "k"},
+			expectedConfig: "c.yaml",
+			expectedRest:   []string{"k"},
+		},
+		{
+			name:         "other flags stop the scan",
+			args:         []string{"--help"},
+			expectedRest: []string{"--help"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configFile, rest := splitLeadingConfigFlag(tt.args)
+			assert.Equal(t, tt.expectedConfig, configFile)
+			assert.Equal(t, tt.expectedRest, rest)
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/internal/logging"
+	"github.com/spf13/cobra"
+)
+
+// completionCommandTimeout bounds how long a "command:" completion spec may
+// run, so that a slow command does not freeze the shell.
+const completionCommandTimeout = 2 * time.Second
+
+// completeAliasNames is a cobra ValidArgsFunction that completes the first
+// argument with the names of stored aliases, described by their description
+// or command where the shell shows descriptions.
+func completeAliasNames(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	application, err := appFactory(completionContext(cmd), GetConfigFile())
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	ctx := logging.WithLogger(completionContext(cmd), application.Logger)
+	aliases, err := application.AliasService.ListAliases(ctx)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	sortAliases(aliases)
+
+	var completions []cobra.Completion
+	for _, alias := range aliases {
+		if !strings.HasPrefix(alias.Name, toComplete) {
+			continue
+		}
+		description := alias.Description
+		if description == "" {
+			description = alias.Command
+		}
+		completions = append(completions, cobra.CompletionWithDesc(alias.Name, description))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeDoArgs completes "mantrid do": alias names first, then the
+// parameters of the chosen alias.
+func completeDoArgs(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return completeAliasNames(cmd, args, toComplete)
+	}
+	return completeAliasParams(cmd, args[0], toComplete)
+}
+
+// completeAliasParams completes a parameter of the alias called name
+// according to its completion spec. Aliases without a spec complete file
+// names, like any other command.
+func completeAliasParams(cmd *cobra.Command, name, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	application, err := appFactory(completionContext(cmd), GetConfigFile())
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	ctx := logging.WithLogger(completionContext(cmd), application.Logger)
+	alias, err := application.AliasService.ResolveAlias(ctx, name, application.Config.ResolvePrefix)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	if alias.Completion == "" {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+
+	spec, err := domain.ParseCompletion(alias.Completion)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	switch spec.Kind {
+	case domain.CompleteFiles:
+		if len(spec.Values) == 0 {
+			return nil, cobra.ShellCompDirectiveDefault
+		}
+		return spec.Values, cobra.ShellCompDirectiveFilterFileExt
+	case domain.CompleteDirs:
+		return nil, cobra.ShellCompDirectiveFilterDirs
+	case domain.CompleteWords:
+		return filterPrefix(spec.Values, toComplete), cobra.ShellCompDirectiveNoFileComp
+	case domain.CompleteCommand:
+		candidates, err := commandCandidates(ctx, spec.Command)
+		if err != nil {
+			application.Logger.Debug("completion command failed", "name", alias.Name, "error", err)
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return filterPrefix(candidates, toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+	return nil, cobra.ShellCompDirectiveDefault
+}
+
+// commandCandidates runs command in the system shell and returns the
+// non-empty lines of its output. A line may carry a description after a tab.
+func commandCandidates(ctx context.Context, command string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, completionCommandTimeout)
+	defer cancel()
+
+	output, err := shellCommand(ctx, command).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimRight(line, "\r"); strings.TrimSpace(line) != "" {
+			candidates = append(candidates, line)
+		}
+	}
+	return candidates, nil
+}
+
+// filterPrefix returns the candidates starting with prefix.
+func filterPrefix(candidates []string, prefix string) []cobra.Completion {
+	var result []cobra.Completion
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// completionContext returns the context of a command being completed.
+func completionContext(cmd *cobra.Command) context.Context {
+	if ctx := cmd.Context(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
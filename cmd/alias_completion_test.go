@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// directive formats the directive line that ends a completion response.
+func directive(d cobra.ShellCompDirective) string {
+	return fmt.Sprintf(":%d", d)
+}
+
+func TestCompleteAliasNames(t *testing.T) {
+	application := setupTestApp(t)
+	ctx := context.Background()
+	require.NoError(t, application.AliasService.CreateAlias(ctx, "deploy", "./deploy.sh $1", domain.WithDescription("Deploy an environment")))
+	require.NoError(t, application.AliasService.CreateAlias(ctx, "dk", "docker"))
+	require.NoError(t, application.AliasService.CreateAlias(ctx, "k", "kubectl"))
+
+	for _, args := range [][]string{
+		{"do", "d"},
+		{"alias", "edit", "d"},
+		{"alias", "remove", "d"},
+	} {
+		t.Run(args[0]+" "+args[1], func(t *testing.T) {
+			output, err := runCommand(t, append([]string{cobra.ShellCompRequestCmd}, args...)...)
+			assert.NoError(t, err)
+			assert.Contains(t, output, "deploy\tDeploy an environment\n")
+			assert.Contains(t, output, "dk\tdocker\n")
+			assert.NotContains(t, output, "kubectl")
+			assert.Contains(t, output, directive(cobra.ShellCompDirectiveNoFileComp))
+		})
+	}
+
+	t.Run("only the first argument", func(t *testing.T) {
+		output, err := runCommand(t, cobra.ShellCompRequestCmd, "alias", "remove", "k", "")
+		assert.NoError(t, err)
+		assert.NotContains(t, output, "deploy")
+	})
+}
+
+func TestCompleteAliasParams(t *testing.T) {
+	application := setupTestApp(t)
+	application.Config.ResolvePrefix = true
+	ctx := context.Background()
+	require.NoError(t, application.AliasService.CreateAlias(ctx, "deploy", "./deploy.sh $1", domain.WithCompletion("words:dev,staging,prod")))
+	require.NoError(t, application.AliasService.CreateAlias(ctx, "apply", "kubectl apply -f $1", domain.WithCompletion("files:yaml,yml")))
+	require.NoError(t, application.AliasService.CreateAlias(ctx, "into", "cd $1", domain.WithCompletion("dirs")))
+	require.NoError(t, application.AliasService.CreateAlias(ctx, "ns", "kubectl -n $1", domain.WithCompletion("command:printf 'default\\nkube-system\\n'")))
+	require.NoError(t, application.AliasService.CreateAlias(ctx, "cat", "cat"))
+
+	tests := []struct {
+		name      string
+		args      []string
+		expected  []string
+		directive cobra.ShellCompDirective
+	}{
+		{"words", []string{"do", "deploy", "s"}, []string{"staging"}, cobra.ShellCompDirectiveNoFileComp},
+		{"words after an argument", []string{"do", "deploy", "dev", "p"}, []string{"prod"}, cobra.ShellCompDirectiveNoFileComp},
+		{"abbreviated alias", []string{"do", "depl", ""}, []string{"dev", "staging", "prod"}, cobra.ShellCompDirectiveNoFileComp},
+		{"file extensions", []string{"do", "apply", ""}, []string{"yaml", "yml"}, cobra.ShellCompDirectiveFilterFileExt},
+		{"directories", []string{"do", "into", ""}, nil, cobra.ShellCompDirectiveFilterDirs},
+		{"command output", []string{"do", "ns", "k"}, []string{"kube-system"}, cobra.ShellCompDirectiveNoFileComp},
+		{"no spec", []string{"do", "cat", ""}, nil, cobra.ShellCompDirectiveDefault},
+		{"alias command", []string{"deploy", "d"}, []string{"dev"}, cobra.ShellCompDirectiveNoFileComp},
+		{"unknown alias", []string{"do", "nope", ""}, nil, cobra.ShellCompDirectiveError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.args[0] != "do" {
+				registerAliasCommands(ctx, "")
+				t.Cleanup(unregisterAliasCommands)
+			}
+
+			output, err := runCommand(t, append([]string{cobra.ShellCompRequestCmd}, tt.args...)...)
+			assert.NoError(t, err)
+			for _, candidate := range tt.expected {
+				assert.Contains(t, output, candidate+"\n")
+			}
+			assert.Contains(t, output, directive(tt.directive)+"\n")
+		})
+	}
+}
+
+func TestAddAliasWithCompletion(t *testing.T) {
+	application := setupTestApp(t)
+
+	output, err := runCommand(t, "alias", "add", "deploy", "./deploy.sh $1", "--complete", "words:dev,prod")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "Alias 'deploy' created successfully")
+
+	alias, err := application.AliasService.GetAlias(context.Background(), "deploy")
+	require.NoError(t, err)
+	assert.Equal(t, "words:dev,prod", alias.Completion)
+
+	output, err = runCommand(t, "alias", "show", "deploy")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "Completion:    words:dev,prod")
+
+	_, err = runCommand(t, "alias", "add", "other", "echo", "--complete", "hosts")
+	assert.ErrorIs(t, err, domain.ErrInvalidCompletion)
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/internal/logging"
+	"github.com/spf13/cobra"
+)
+
+var forceCopy bool
+
+var copyAliasCmd = &cobra.Command{
+	Use:   "copy [source] [destination]",
+	Short: "Copy an alias",
+	Long: `Create a new alias with the same command and description as an existing one.
+The copy of a project alias is added to the global store.
+Fails if the destination name is taken unless --force flag is used.`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeAliasNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		application, err := appFactory(cmd.Context(), GetConfigFile())
+		if err != nil {
+			return err
+		}
+
+		ctx := logging.WithLogger(cmd.Context(), application.Logger)
+		src, dst := args[0], args[1]
+
+		application.Logger.Info("copying alias", "from", src, "to", dst)
+
+		if err := application.AliasService.CopyAlias(ctx, src, dst, forceCopy); err != nil {
+			application.Logger.Error("failed to copy alias", "error", err)
+			switch {
+			case errors.Is(err, domain.ErrAliasExists):
+				return fmt.Errorf("failed to copy alias: alias '%s' already exists. Use --force to overwrite it: %w", dst, err)
+			case errors.Is(err, domain.ErrAliasNotFound):
+				return fmt.Errorf("failed to copy alias: %w.%s", err, didYouMean(ctx, application.AliasService, src))
+			}
+			return fmt.Errorf("failed to copy alias: %w", err)
+		}
+
+		application.Logger.Info("alias copied successfully", "from", src, "to", dst)
+		fmt.Fprintf(cmd.OutOrStdout(), "Alias '%s' copied to '%s'\n", src, dst)
+		return nil
+	},
+}
+
+func init() {
+	copyAliasCmd.Flags().BoolVarP(&forceCopy, "force", "f", false, "Overwrite an existing alias with the destination name")
+	aliasCmd.AddCommand(copyAliasCmd)
+}
@@ -1,17 +1,36 @@
 package cmd
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 
+	"github.com/msaglietto/mantrid/domain"
 	"github.com/msaglietto/mantrid/internal/logging"
 	"github.com/spf13/cobra"
 )
 
+var editAll bool
+
 var editAliasCmd = &cobra.Command{
 	Use:   "edit [name] [new-command]",
 	Short: "Edit an existing alias",
-	Long:  `Update the command of an existing alias by name.`,
-	Args:  cobra.ExactArgs(2),
+	Long: `Update the command of an existing alias by name.
+
+Without a new command, the alias is opened as YAML in $VISUAL or $EDITOR,
+where its command, description and completion can be changed. With --all, every alias
+is opened in a single buffer and the result is applied in one step.
+If the edited aliases are invalid, the editor is reopened with the problems
+marked as comments; save an empty file to give up. If they were changed
+from another terminal in the meantime, the changes are shown and you can
+edit again on top of them instead of overwriting them.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if editAll {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.RangeArgs(1, 2)(cmd, args)
+	},
+	ValidArgsFunction: completeAliasNames,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		application, err := appFactory(cmd.Context(), GetConfigFile())
 		if err != nil {
@@ -19,12 +38,53 @@ var editAliasCmd = &cobra.Command{
 		}
 
 		ctx := logging.WithLogger(cmd.Context(), application.Logger)
-		name, newCommand := args[0], args[1]
 
+		if editAll {
+			application.Logger.Info("editing all aliases")
+
+			created, updated, removed, changed, err := editAllAliases(ctx, application.AliasService, askEditAgain(cmd))
+			if err != nil {
+				application.Logger.Error("failed to update aliases", "error", err)
+				return fmt.Errorf("failed to update aliases: %w", err)
+			}
+			if !changed {
+				fmt.Fprintln(cmd.OutOrStdout(), "No changes made")
+				return nil
+			}
+
+			application.Logger.Info("aliases updated successfully", "created", created, "updated", updated, "removed", removed)
+			fmt.Fprintf(cmd.OutOrStdout(), "Aliases updated: %d created, %d updated, %d removed\n", created, updated, removed)
+			return nil
+		}
+
+		name := args[0]
 		application.Logger.Info("editing alias", "name", name)
 
+		if len(args) == 1 {
+			changed, err := editSingleAlias(ctx, application.AliasService, name, askEditAgain(cmd))
+			if err != nil {
+				application.Logger.Error("failed to update alias", "error", err)
+				if errors.Is(err, domain.ErrAliasNotFound) {
+					return fmt.Errorf("failed to update alias: %w.%s", err, didYouMean(ctx, application.AliasService, name))
+				}
+				return fmt.Errorf("failed to update alias: %w", err)
+			}
+			if !changed {
+				fmt.Fprintln(cmd.OutOrStdout(), "No changes made")
+				return nil
+			}
+
+			application.Logger.Info("alias updated successfully", "name", name)
+			fmt.Fprintf(cmd.OutOrStdout(), "Alias '%s' updated successfully\n", name)
+			return nil
+		}
+
+		newCommand := args[1]
 		if err := application.AliasService.UpdateAlias(ctx, name, newCommand); err != nil {
 			application.Logger.Error("failed to update alias", "error", err)
+			if errors.Is(err, domain.ErrAliasNotFound) {
+				return fmt.Errorf("failed to update alias: %w.%s", err, didYouMean(ctx, application.AliasService, name))
+			}
 			return fmt.Errorf("failed to update alias: %w", err)
 		}
 
@@ -34,6 +94,20 @@ var editAliasCmd = &cobra.Command{
 	},
 }
 
+// askEditAgain shows how aliases changed while they were being edited and
+// asks on cmd's input whether to edit again on top of the changes.
+func askEditAgain(cmd *cobra.Command) conflictFunc {
+	// One reader for all questions, so that buffered answers are not lost
+	in := bufio.NewReader(cmd.InOrStdin())
+	return func(before, after []*domain.Alias) bool {
+		out := cmd.OutOrStdout()
+		fmt.Fprintln(out, "The aliases were changed while you were editing them:")
+		writeAliasDiff(out, before, after)
+		return confirm(cmd, in, "Edit again on top of these changes? Your edits are kept")
+	}
+}
+
 func init() {
+	editAliasCmd.Flags().BoolVar(&editAll, "all", false, "Edit every alias in a single editor buffer")
 	aliasCmd.AddCommand(editAliasCmd)
 }
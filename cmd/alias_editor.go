@@ -0,0 +1,531 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/repository"
+	"github.com/msaglietto/mantrid/service"
+	"gopkg.in/yaml.v3"
+)
+
+// editableAlias is the YAML form of an alias in an editor buffer.
+type editableAlias struct {
+	Name        string `yaml:"name"`
+	Command     string `yaml:"command"`
+	Description string `yaml:"description,omitempty"`
+	Completion  string `yaml:"completion,omitempty"`
+	WorkDir     string `yaml:"workdir,omitempty"`
+}
+
+// editableFields lists the keys accepted in an editor buffer, so that typos
+// such as "comand" are reported instead of silently dropped.
+var editableFields = map[string]bool{
+	"name":        true,
+	"command":     true,
+	"description": true,
+	"completion":  true,
+	"workdir":     true,
+}
+
+// editErrorPrefix marks the comment lines mantrid adds to report problems.
+// They are stripped again before the buffer is parsed.
+const editErrorPrefix = "# error: "
+
+const editSingleHeader = `# Edit alias '%s' and save to apply, or delete everything to cancel.
+# Changing the name renames the alias.
+`
+
+const editAllHeader = `# Edit your aliases and save to apply, or delete everything to cancel.
+# Add entries to create aliases and remove entries to delete them.
+# An entry whose name changes is removed and created again.
+`
+
+// openEditor opens path in the user's editor and waits for it to exit.
+// It can be overridden in tests.
+var openEditor = runEditor
+
+// runEditor runs $VISUAL or $EDITOR, falling back to a platform default.
+func runEditor(ctx context.Context, path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		} else {
+			editor = "vi"
+		}
+	}
+
+	// Allow editors configured with arguments, such as "code --wait"
+	args := append(strings.Fields(editor), path)
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %q failed: %w", editor, err)
+	}
+	return nil
+}
+
+// editLoop opens doc in the editor and passes the result to apply. When
+// apply returns a non-nil retry buffer, typically doc annotated with error
+// comments, the editor is reopened on it. The loop ends when apply succeeds
+// or fails outright, or when the user saves an empty buffer or leaves doc
+// unchanged, in which case it reports that nothing was applied.
+func editLoop(ctx context.Context, doc []byte, apply func(edited []byte) (retry []byte, err error)) (bool, error) {
+	tmp, err := os.CreateTemp("", "mantrid-edit-*.yaml")
+	if err != nil {
+		return false, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	path := tmp.Name()
+	tmp.Close()
+	defer os.Remove(path)
+
+	content := doc
+	for {
+		if err := os.WriteFile(path, content, 0600); err != nil {
+			return false, fmt.Errorf("failed to write temp file: %w", err)
+		}
+
+		if err := openEditor(ctx, path); err != nil {
+			return false, err
+		}
+
+		edited, err := os.ReadFile(path)
+		if err != nil {
+			return false, fmt.Errorf("failed to read temp file: %w", err)
+		}
+
+		if isBlankYAML(edited) || bytes.Equal(edited, doc) {
+			return false, nil
+		}
+
+		retry, err := apply(edited)
+		if err != nil || retry == nil {
+			return err == nil, err
+		}
+		content = retry
+	}
+}
+
+// isBlankYAML reports whether data holds nothing but comments and whitespace.
+func isBlankYAML(data []byte) bool {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return false
+		}
+	}
+	return true
+}
+
+// stripEditErrors removes the error comments added by a previous pass.
+func stripEditErrors(data []byte) []byte {
+	lines := strings.Split(string(data), "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if !strings.HasPrefix(strings.TrimSpace(line), editErrorPrefix) {
+			kept = append(kept, line)
+		}
+	}
+	return []byte(strings.Join(kept, "\n"))
+}
+
+// withHeaderError prepends an error comment to a buffer that could not be
+// parsed at all.
+func withHeaderError(data []byte, problem error) []byte {
+	return append([]byte(errorComment(problem)+"\n"), data...)
+}
+
+// errorComment formats problem as one or more error comment lines.
+func errorComment(problem error) string {
+	lines := strings.Split(problem.Error(), "\n")
+	for i, line := range lines {
+		lines[i] = editErrorPrefix + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// annotate attaches problem as an error comment above node.
+func annotate(node *yaml.Node, problem error) {
+	comment := errorComment(problem)
+	if node.HeadComment != "" {
+		comment = node.HeadComment + "\n" + comment
+	}
+	node.HeadComment = comment
+}
+
+// encodeNode renders a parsed, possibly annotated, editor buffer.
+func encodeNode(root *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return nil, fmt.Errorf("failed to encode aliases: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode aliases: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeEditable decodes one alias mapping of an editor buffer.
+func decodeEditable(node *yaml.Node) (editableAlias, error) {
+	var entry editableAlias
+	if node.Kind != yaml.MappingNode {
+		return entry, fmt.Errorf("line %d: expected an alias with name and command", node.Line)
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		key := node.Content[i]
+		if !editableFields[key.Value] {
+			return entry, fmt.Errorf("line %d: unknown field %q", key.Line, key.Value)
+		}
+	}
+	if err := node.Decode(&entry); err != nil {
+		return entry, err
+	}
+	return entry, nil
+}
+
+// toEditable converts an alias to its editor form.
+func toEditable(alias *domain.Alias) editableAlias {
+	return editableAlias{
+		Name:        alias.Name,
+		Command:     alias.Command,
+		Description: alias.Description,
+		Completion:  alias.Completion,
+		WorkDir:     alias.WorkDir,
+	}
+}
+
+// fromEditable builds the alias described by entry. When entry edits an
+// existing alias, its creation time is kept and its update time is only
+// bumped if something changed.
+func fromEditable(entry editableAlias, existing *domain.Alias, now time.Time) *domain.Alias {
+	if existing == nil {
+		return &domain.Alias{
+			Name:        entry.Name,
+			Command:     entry.Command,
+			Description: entry.Description,
+			Completion:  entry.Completion,
+			WorkDir:     entry.WorkDir,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+	}
+
+	alias := *existing
+	if toEditable(existing) != entry {
+		alias.Name = entry.Name
+		alias.Command = entry.Command
+		alias.Description = entry.Description
+		alias.Completion = entry.Completion
+		alias.WorkDir = entry.WorkDir
+		alias.UpdatedAt = now
+	}
+	return &alias
+}
+
+// conflictFunc is asked, when the aliases being edited were changed from
+// before to after in the meantime, whether to edit them again on top of
+// after.
+type conflictFunc func(before, after []*domain.Alias) bool
+
+// changedMeanwhile reports whether err is a change that failed because the
+// store no longer holds the aliases that were opened in the editor.
+func changedMeanwhile(err error) bool {
+	return errors.Is(err, domain.ErrConflict) || errors.Is(err, domain.ErrAliasNotFound) ||
+		errors.Is(err, domain.ErrAliasExists)
+}
+
+// editSingleAlias edits the alias called name in the editor and applies the
+// result. A change made to the alias in the meantime is not overwritten:
+// resolve decides whether to edit it again. It reports whether anything
+// changed.
+func editSingleAlias(ctx context.Context, svc service.AliasService, name string, resolve conflictFunc) (bool, error) {
+	original, err := svc.GetAlias(ctx, name)
+	if err != nil {
+		return false, err
+	}
+
+	body, err := yaml.Marshal(toEditable(original))
+	if err != nil {
+		return false, fmt.Errorf("failed to encode alias: %w", err)
+	}
+	doc := append([]byte(fmt.Sprintf(editSingleHeader, name)), body...)
+
+	return editLoop(ctx, doc, func(edited []byte) ([]byte, error) {
+		edited = stripEditErrors(edited)
+
+		var root yaml.Node
+		if err := yaml.Unmarshal(edited, &root); err != nil {
+			return withHeaderError(edited, err), nil
+		}
+		node := root.Content[0]
+
+		entry, err := decodeEditable(node)
+		if err != nil {
+			return withHeaderError(edited, err), nil
+		}
+
+		// Change only the version that was opened; changes made to other
+		// aliases in the meantime are kept
+		alias := fromEditable(entry, original, time.Now())
+		ops := []repository.Op{repository.UpdateOp(alias).At(original.Revision)}
+		if entry.Name != name {
+			ops = []repository.Op{repository.DeleteOp(name).At(original.Revision), repository.CreateOp(alias)}
+		}
+
+		err = svc.BatchAliases(ctx, ops)
+		var invalid service.ValidationErrors
+		var failed *repository.BatchError
+		switch {
+		case errors.As(err, &invalid):
+			for _, problem := range invalid {
+				annotate(node, problem)
+			}
+			return encodeNode(&root)
+		case errors.As(err, &failed) && failed.Op.Kind == repository.OpCreate:
+			// Renamed onto another alias
+			annotate(node, failed.Err)
+			return encodeNode(&root)
+		case changedMeanwhile(err):
+			current, getErr := svc.GetAlias(ctx, name)
+			if errors.Is(getErr, domain.ErrAliasNotFound) {
+				return nil, fmt.Errorf("%w: %q was removed while it was being edited", domain.ErrAliasNotFound, name)
+			}
+			if getErr != nil {
+				return nil, getErr
+			}
+			if !resolve([]*domain.Alias{original}, []*domain.Alias{current}) {
+				return nil, err
+			}
+			original = current
+			annotate(node, fmt.Errorf("%w while you were editing it; save again to apply your edits on top", domain.ErrConflict))
+			return encodeNode(&root)
+		}
+		return nil, err
+	})
+}
+
+// editAllAliases edits the whole store in the editor and applies the result
+// as one batch of the aliases that were created, updated or removed in the
+// buffer; aliases added or removed elsewhere meanwhile are left alone.
+// Aliases changed in the meantime are not overwritten: resolve decides
+// whether to edit again on top of the changes. It returns the number of
+// created, updated and removed aliases and whether anything changed.
+func editAllAliases(ctx context.Context, svc service.AliasService, resolve conflictFunc) (created, updated, removed int, changed bool, err error) {
+	aliases, err := svc.ListAliases(ctx)
+	if err != nil {
+		return 0, 0, 0, false, err
+	}
+	sortAliases(aliases)
+
+	body, err := encodeEditable(aliases)
+	if err != nil {
+		return 0, 0, 0, false, err
+	}
+	doc := append([]byte(editAllHeader), body...)
+
+	changed, err = editLoop(ctx, doc, func(edited []byte) ([]byte, error) {
+		edited = stripEditErrors(edited)
+
+		var root yaml.Node
+		if err := yaml.Unmarshal(edited, &root); err != nil {
+			return withHeaderError(edited, err), nil
+		}
+		seq := root.Content[0]
+		if seq.Kind != yaml.SequenceNode {
+			return withHeaderError(edited, fmt.Errorf("line %d: expected a list of aliases", seq.Line)), nil
+		}
+
+		existing := make(map[string]*domain.Alias, len(aliases))
+		for _, a := range aliases {
+			existing[a.Name] = a
+		}
+
+		now := time.Now()
+		result := make([]*domain.Alias, 0, len(seq.Content))
+		problems := false
+		for _, item := range seq.Content {
+			entry, err := decodeEditable(item)
+			if err != nil {
+				annotate(item, err)
+				problems = true
+				continue
+			}
+			result = append(result, fromEditable(entry, existing[entry.Name], now))
+		}
+		if problems {
+			return encodeNode(&root)
+		}
+
+		err := svc.ValidateAliases(result)
+		var invalid service.ValidationErrors
+		if errors.As(err, &invalid) {
+			for i, problem := range invalid {
+				annotate(seq.Content[i], problem)
+			}
+			return encodeNode(&root)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		ops := editOps(aliases, result)
+		err = svc.BatchAliases(ctx, ops)
+		if changedMeanwhile(err) {
+			current, listErr := svc.ListAliases(ctx)
+			if listErr != nil {
+				return nil, listErr
+			}
+			if !resolve(aliases, current) {
+				return nil, err
+			}
+
+			conflicts := conflictingNames(aliases, current, ops)
+			rebased := rebase(current, ops)
+			sortAliases(rebased)
+			body, encodeErr := encodeEditable(rebased)
+			if encodeErr != nil {
+				return nil, encodeErr
+			}
+			// The next edit is applied to the current aliases
+			aliases = current
+			return withHeaderError(append([]byte(editAllHeader), body...),
+				fmt.Errorf("%w while you were editing: %s; save again to apply your edits on top",
+					domain.ErrConflict, strings.Join(conflicts, ", "))), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		created, updated, removed = 0, 0, 0
+		for _, op := range ops {
+			switch op.Kind {
+			case repository.OpCreate:
+				created++
+			case repository.OpUpdate:
+				updated++
+			case repository.OpDelete:
+				removed++
+			}
+		}
+		return nil, nil
+	})
+
+	return created, updated, removed, changed, err
+}
+
+// encodeEditable renders aliases as the list of an editor buffer.
+func encodeEditable(aliases []*domain.Alias) ([]byte, error) {
+	entries := make([]editableAlias, len(aliases))
+	for i, a := range aliases {
+		entries[i] = toEditable(a)
+	}
+	body, err := yaml.Marshal(entries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode aliases: %w", err)
+	}
+	return body, nil
+}
+
+// editOps returns the operations turning base, the aliases opened in the
+// editor, into result. Updates and deletions expect the revisions that were
+// opened, so that they fail rather than overwrite changes made meanwhile.
+func editOps(base, result []*domain.Alias) []repository.Op {
+	previous := make(map[string]*domain.Alias, len(base))
+	for _, a := range base {
+		previous[a.Name] = a
+	}
+
+	var ops []repository.Op
+	kept := make(map[string]bool, len(result))
+	for _, a := range result {
+		kept[a.Name] = true
+		switch p := previous[a.Name]; {
+		case p == nil:
+			ops = append(ops, repository.CreateOp(a))
+		case toEditable(p) != toEditable(a):
+			ops = append(ops, repository.UpdateOp(a).At(p.Revision))
+		}
+	}
+	for _, a := range base {
+		if !kept[a.Name] {
+			ops = append(ops, repository.DeleteOp(a.Name).At(a.Revision))
+		}
+	}
+	return ops
+}
+
+// rebase applies ops, made against aliases that have since become current,
+// to current: created and updated aliases replace the current alias of the
+// same name, if any, and deleted ones are removed if they still exist.
+func rebase(current []*domain.Alias, ops []repository.Op) []*domain.Alias {
+	result := append([]*domain.Alias{}, current...)
+	index := make(map[string]int, len(result))
+	for i, a := range result {
+		index[a.Name] = i
+	}
+
+	for _, op := range ops {
+		i, exists := index[op.Target()]
+		switch {
+		case op.Kind == repository.OpDelete && exists:
+			result[i] = nil
+			delete(index, op.Target())
+		case op.Kind == repository.OpDelete:
+		case exists:
+			result[i] = op.Alias
+		default:
+			index[op.Target()] = len(result)
+			result = append(result, op.Alias)
+		}
+	}
+
+	kept := result[:0]
+	for _, a := range result {
+		if a != nil {
+			kept = append(kept, a)
+		}
+	}
+	return kept
+}
+
+// conflictingNames returns the quoted names of the aliases changed by ops
+// that also changed from before to after, sorted.
+func conflictingNames(before, after []*domain.Alias, ops []repository.Op) []string {
+	revisions := func(aliases []*domain.Alias) map[string]int64 {
+		result := make(map[string]int64, len(aliases))
+		for _, a := range aliases {
+			result[a.Name] = a.Revision
+		}
+		return result
+	}
+	was, is := revisions(before), revisions(after)
+
+	var names []string
+	for _, op := range ops {
+		name := op.Target()
+		rev, existed := was[name]
+		if now, exists := is[name]; existed != exists || rev != now {
+			names = append(names, fmt.Sprintf("%q", name))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
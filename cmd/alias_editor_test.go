@@ -0,0 +1,354 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupEditor replaces the editor with edits, applied in order, one per
+// time the editor is opened. It returns the buffers the editor was shown.
+func setupEditor(t *testing.T, edits ...func(string) string) *[]string {
+	t.Helper()
+
+	shown := &[]string{}
+	original := openEditor
+	t.Cleanup(func() {
+		openEditor = original
+	})
+
+	openEditor = func(ctx context.Context, path string) error {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Less(t, len(*shown), len(edits), "editor opened too many times")
+
+		edit := edits[len(*shown)]
+		*shown = append(*shown, string(data))
+		return os.WriteFile(path, []byte(edit(string(data))), 0600)
+	}
+
+	return shown
+}
+
+func replace(old, new string) func(string) string {
+	return func(s string) string {
+		return strings.Replace(s, old, new, 1)
+	}
+}
+
+func TestEditAliasInEditor(t *testing.T) {
+	t.Run("edit command and description", func(t *testing.T) {
+		application := setupTestApp(t)
+		ctx := context.Background()
+		application.AliasService.CreateAlias(ctx, "deploy", "kubectl apply -f $1")
+		original, _ := application.AliasService.GetAlias(ctx, "deploy")
+
+		shown := setupEditor(t, func(s string) string {
+			return strings.Replace(s, "kubectl apply -f $1", "kubectl apply -f $1 -n $2", 1) +
+				"description: Deploy a manifest\n"
+		})
+
+		output, err := runCommand(t, "alias", "edit", "deploy")
+		require.NoError(t, err)
+		assert.Contains(t, output, "Alias 'deploy' updated successfully")
+		assert.Contains(t, (*shown)[0], "name: deploy")
+
+		alias, _ := application.AliasService.GetAlias(ctx, "deploy")
+		assert.Equal(t, "kubectl apply -f $1 -n $2", alias.Command)
+		assert.Equal(t, "Deploy a manifest", alias.Description)
+		assert.True(t, original.CreatedAt.Equal(alias.CreatedAt))
+	})
+
+	t.Run("rename in editor", func(t *testing.T) {
+		application := setupTestApp(t)
+		ctx := context.Background()
+		application.AliasService.CreateAlias(ctx, "old", "echo old")
+
+		setupEditor(t, replace("name: old", "name: new"))
+
+		_, err := runCommand(t, "alias", "edit", "old")
+		require.NoError(t, err)
+
+		_, err = application.AliasService.GetAlias(ctx, "new")
+		assert.NoError(t, err)
+		_, err = application.AliasService.GetAlias(ctx, "old")
+		assert.ErrorIs(t, err, domain.ErrAliasNotFound)
+	})
+
+	t.Run("invalid edit reopens editor with errors", func(t *testing.T) {
+		application := setupTestApp(t)
+		ctx := context.Background()
+		application.AliasService.CreateAlias(ctx, "deploy", "kubectl apply")
+
+		shown := setupEditor(t,
+			replace("name: deploy", "name: bad name"),
+			replace("name: bad name", "name: deploy2"),
+		)
+
+		_, err := runCommand(t, "alias", "edit", "deploy")
+		require.NoError(t, err)
+		require.Len(t, *shown, 2)
+		assert.Contains(t, (*shown)[1], editErrorPrefix+"alias name must contain only")
+
+		_, err = application.AliasService.GetAlias(ctx, "deploy2")
+		assert.NoError(t, err)
+	})
+
+	t.Run("set completion", func(t *testing.T) {
+		application := setupTestApp(t)
+		ctx := context.Background()
+		application.AliasService.CreateAlias(ctx, "deploy", "./deploy.sh $1")
+
+		shown := setupEditor(t,
+			func(s string) string { return s + "completion: hosts\n" },
+			replace("completion: hosts", "completion: words:dev,prod"),
+		)
+
+		_, err := runCommand(t, "alias", "edit", "deploy")
+		require.NoError(t, err)
+		require.Len(t, *shown, 2)
+		assert.Contains(t, (*shown)[1], editErrorPrefix+"completion must be one of")
+
+		alias, _ := application.AliasService.GetAlias(ctx, "deploy")
+		assert.Equal(t, "words:dev,prod", alias.Completion)
+	})
+
+	t.Run("unknown field reported", func(t *testing.T) {
+		application := setupTestApp(t)
+		ctx := context.Background()
+		application.AliasService.CreateAlias(ctx, "deploy", "kubectl apply")
+
+		shown := setupEditor(t,
+			replace("command:", "comand:"),
+			func(string) string { return "" },
+		)
+
+		output, err := runCommand(t, "alias", "edit", "deploy")
+		require.NoError(t, err)
+		assert.Contains(t, output, "No changes made")
+		assert.Contains(t, (*shown)[1], editErrorPrefix+`line 4: unknown field "comand"`)
+	})
+
+	t.Run("unchanged buffer", func(t *testing.T) {
+		application := setupTestApp(t)
+		application.AliasService.CreateAlias(context.Background(), "deploy", "kubectl apply")
+
+		setupEditor(t, func(s string) string { return s })
+
+		output, err := runCommand(t, "alias", "edit", "deploy")
+		require.NoError(t, err)
+		assert.Contains(t, output, "No changes made")
+	})
+
+	t.Run("change made meanwhile is shown and edited on top of", func(t *testing.T) {
+		application := setupTestApp(t)
+		ctx := context.Background()
+		application.AliasService.CreateAlias(ctx, "deploy", "kubectl apply")
+		rootCmd.SetIn(strings.NewReader("y\n"))
+		t.Cleanup(func() { rootCmd.SetIn(nil) })
+
+		shown := setupEditor(t,
+			func(s string) string {
+				// Another terminal changes the alias while it is being edited
+				require.NoError(t, application.AliasService.UpdateAlias(ctx, "deploy", "kubectl apply -n prod"))
+				return s + "description: Deploy\n"
+			},
+			func(s string) string { return s },
+		)
+
+		output, err := runCommand(t, "alias", "edit", "deploy")
+		require.NoError(t, err)
+		assert.Contains(t, output, "The aliases were changed while you were editing them:")
+		assert.Contains(t, output, `command: "kubectl apply" -> "kubectl apply -n prod"`)
+		assert.Contains(t, output, "Alias 'deploy' updated successfully")
+		require.Len(t, *shown, 2)
+		assert.Contains(t, (*shown)[1], editErrorPrefix+"alias was changed by someone else while you were editing it")
+
+		alias, _ := application.AliasService.GetAlias(ctx, "deploy")
+		assert.Equal(t, "Deploy", alias.Description)
+		assert.Equal(t, "kubectl apply", alias.Command)
+	})
+
+	t.Run("change made meanwhile is kept when not editing again", func(t *testing.T) {
+		application := setupTestApp(t)
+		ctx := context.Background()
+		application.AliasService.CreateAlias(ctx, "deploy", "kubectl apply")
+		rootCmd.SetIn(strings.NewReader("n\n"))
+		t.Cleanup(func() { rootCmd.SetIn(nil) })
+
+		setupEditor(t, func(s string) string {
+			require.NoError(t, application.AliasService.UpdateAlias(ctx, "deploy", "kubectl apply -n prod"))
+			return replace("kubectl apply", "kubectl apply -n dev")(s)
+		})
+
+		_, err := runCommand(t, "alias", "edit", "deploy")
+		assert.ErrorIs(t, err, domain.ErrConflict)
+
+		alias, _ := application.AliasService.GetAlias(ctx, "deploy")
+		assert.Equal(t, "kubectl apply -n prod", alias.Command)
+	})
+
+	t.Run("non-existent alias", func(t *testing.T) {
+		setupTestApp(t)
+		setupEditor(t)
+
+		_, err := runCommand(t, "alias", "edit", "missing")
+		assert.ErrorIs(t, err, domain.ErrAliasNotFound)
+	})
+}
+
+func TestEditAllAliasesInEditor(t *testing.T) {
+	setup := func(t *testing.T) context.Context {
+		application := setupTestApp(t)
+		ctx := context.Background()
+		application.AliasService.CreateAlias(ctx, "build", "go build")
+		application.AliasService.CreateAlias(ctx, "test", "go test ./...")
+		application.AliasService.CreateAlias(ctx, "vet", "go vet ./...")
+		return ctx
+	}
+
+	t.Run("create update and remove in one batch", func(t *testing.T) {
+		ctx := setup(t)
+		application, _ := appFactory(ctx, "")
+
+		setupEditor(t, func(s string) string {
+			s = strings.Replace(s, "go test ./...", "go test -race ./...", 1)
+			s = strings.Replace(s, "- name: vet\n  command: go vet ./...\n", "", 1)
+			return s + "- name: lint\n  command: golangci-lint run\n"
+		})
+
+		output, err := runCommand(t, "alias", "edit", "--all")
+		require.NoError(t, err)
+		assert.Contains(t, output, "Aliases updated: 1 created, 1 updated, 1 removed")
+
+		aliases, _ := application.AliasService.ListAliases(ctx)
+		sortAliases(aliases)
+		names := []string{}
+		for _, a := range aliases {
+			names = append(names, a.Name)
+		}
+		assert.Equal(t, []string{"build", "lint", "test"}, names)
+
+		updated, _ := application.AliasService.GetAlias(ctx, "test")
+		assert.Equal(t, "go test -race ./...", updated.Command)
+	})
+
+	t.Run("duplicate names reported inline", func(t *testing.T) {
+		ctx := setup(t)
+		application, _ := appFactory(ctx, "")
+
+		shown := setupEditor(t,
+			func(s string) string {
+				s = strings.Replace(s, "# An entry", "# Keep me\n# An entry", 1)
+				return strings.Replace(s, "name: vet", "name: build", 1)
+			},
+			func(string) string { return "" },
+		)
+
+		_, err := runCommand(t, "alias", "edit", "--all")
+		require.NoError(t, err)
+		require.Len(t, *shown, 2)
+		assert.Contains(t, (*shown)[1], "# Keep me")
+		assert.Regexp(t, `# error: alias already exists: "build" is also alias #1\n- name: build\n  command: go vet`, (*shown)[1])
+
+		// Nothing applied
+		aliases, _ := application.AliasService.ListAliases(ctx)
+		assert.Len(t, aliases, 3)
+	})
+
+	t.Run("changes made meanwhile to other aliases are kept", func(t *testing.T) {
+		ctx := setup(t)
+		application, _ := appFactory(ctx, "")
+
+		setupEditor(t, func(s string) string {
+			require.NoError(t, application.AliasService.UpdateAlias(ctx, "vet", "go vet -all ./..."))
+			return strings.Replace(s, "go build", "go build ./...", 1)
+		})
+
+		output, err := runCommand(t, "alias", "edit", "--all")
+		require.NoError(t, err)
+		assert.Contains(t, output, "Aliases updated: 0 created, 1 updated, 0 removed")
+
+		vet, _ := application.AliasService.GetAlias(ctx, "vet")
+		assert.Equal(t, "go vet -all ./...", vet.Command)
+	})
+
+	t.Run("aliases added and removed meanwhile are left alone", func(t *testing.T) {
+		ctx := setup(t)
+		application, _ := appFactory(ctx, "")
+
+		setupEditor(t, func(s string) string {
+			require.NoError(t, application.AliasService.CreateAlias(ctx, "fmt", "gofmt -l ."))
+			require.NoError(t, application.AliasService.DeleteAlias(ctx, "build", repository.AnyRevision))
+			return strings.Replace(s, "go test ./...", "go test -race ./...", 1)
+		})
+
+		output, err := runCommand(t, "alias", "edit", "--all")
+		require.NoError(t, err)
+		assert.Contains(t, output, "Aliases updated: 0 created, 1 updated, 0 removed")
+
+		aliases, _ := application.AliasService.ListAliases(ctx)
+		sortAliases(aliases)
+		names := []string{}
+		for _, a := range aliases {
+			names = append(names, a.Name)
+		}
+		assert.Equal(t, []string{"fmt", "test", "vet"}, names)
+	})
+
+	t.Run("conflicting change edited on top of", func(t *testing.T) {
+		ctx := setup(t)
+		application, _ := appFactory(ctx, "")
+		rootCmd.SetIn(strings.NewReader("y\n"))
+		t.Cleanup(func() { rootCmd.SetIn(nil) })
+
+		shown := setupEditor(t,
+			func(s string) string {
+				require.NoError(t, application.AliasService.UpdateAlias(ctx, "vet", "go vet -all ./..."))
+				require.NoError(t, application.AliasService.CreateAlias(ctx, "fmt", "gofmt -l ."))
+				return strings.Replace(s, "- name: vet\n  command: go vet ./...\n", "", 1)
+			},
+			func(s string) string { return s },
+		)
+
+		output, err := runCommand(t, "alias", "edit", "--all")
+		require.NoError(t, err)
+		assert.Contains(t, output, "+ fmt: gofmt -l .")
+		assert.Contains(t, output, "Aliases updated: 0 created, 0 updated, 1 removed")
+		require.Len(t, *shown, 2)
+		assert.Contains(t, (*shown)[1], editErrorPrefix+`alias was changed by someone else while you were editing: "vet"`)
+		assert.Contains(t, (*shown)[1], "name: fmt")
+		assert.NotContains(t, (*shown)[1], "name: vet")
+
+		aliases, _ := application.AliasService.ListAliases(ctx)
+		assert.Len(t, aliases, 3)
+		_, err = application.AliasService.GetAlias(ctx, "vet")
+		assert.ErrorIs(t, err, domain.ErrAliasNotFound)
+	})
+
+	t.Run("syntax error reported at the top", func(t *testing.T) {
+		setup(t)
+
+		shown := setupEditor(t,
+			func(s string) string { return s + "- name: [unclosed\n" },
+			func(string) string { return "" },
+		)
+
+		_, err := runCommand(t, "alias", "edit", "--all")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix((*shown)[1], editErrorPrefix+"yaml:"))
+	})
+
+	t.Run("all with arguments", func(t *testing.T) {
+		setup(t)
+
+		_, err := runCommand(t, "alias", "edit", "--all", "build")
+		assert.Error(t, err)
+	})
+}
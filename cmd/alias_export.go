@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/internal/export"
+	"github.com/msaglietto/mantrid/internal/fsutil"
+	"github.com/msaglietto/mantrid/internal/logging"
+	"github.com/msaglietto/mantrid/internal/portable"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportTo     string
+	exportFormat string
+	exportMatch  string
+	exportOutput string
+)
+
+var exportAliasCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export aliases to share them or use them without mantrid",
+	Long: `Write aliases to a JSON, YAML or TOML document that "mantrid alias import"
+reads back on another machine, or as native definitions of a shell or task
+runner, to use them where mantrid is not installed.
+
+  mantrid alias export --output aliases.json
+  mantrid alias export --format yaml --match 'k8s/*'
+  mantrid alias export --to bash >> ~/.bashrc
+  mantrid alias export --to fish --output ~/.config/fish/conf.d/aliases.fish
+  mantrid alias export --to just --output justfile
+  mantrid alias export --to vscode-tasks --output .vscode/tasks.json
+
+Supported formats are bash, zsh, fish, pwsh, make, just and vscode-tasks.
+Placeholders are translated to the parameters of each format: $1 becomes
+$argv[1] in fish, $args[0] in PowerShell, $(ARG1) in make and a prompt in
+VS Code. Aliases that cannot be represented, such as namespaced names in the
+shells, are left out, and commands using syntax the target reads differently
+are exported as they are; both are reported as warnings.
+
+--match limits the export to the aliases whose name matches a glob pattern,
+where "*" does not match the namespace separator.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if exportTo != "" && !slices.Contains(export.Formats, exportTo) {
+			return fmt.Errorf("unsupported export format %q: must be one of %s", exportTo, strings.Join(export.Formats, ", "))
+		}
+		if exportTo == "" && !slices.Contains(portable.Formats, exportFormat) {
+			return fmt.Errorf("unsupported format %q: must be one of %s", exportFormat, strings.Join(portable.Formats, ", "))
+		}
+		if _, err := path.Match(exportMatch, ""); err != nil {
+			return fmt.Errorf("invalid --match pattern %q: %w", exportMatch, err)
+		}
+
+		application, err := appFactory(cmd.Context(), GetConfigFile())
+		if err != nil {
+			return err
+		}
+
+		ctx := logging.WithLogger(cmd.Context(), application.Logger)
+
+		application.Logger.Info("exporting aliases", "to", exportTo, "format", exportFormat, "match", exportMatch, "output", exportOutput)
+
+		aliases, err := application.AliasService.ListAliases(ctx)
+		if err != nil {
+			application.Logger.Error("failed to list aliases", "error", err)
+			return fmt.Errorf("failed to list aliases: %w", err)
+		}
+		aliases = matchAliases(aliases, exportMatch)
+		sortAliases(aliases)
+
+		var buf bytes.Buffer
+		var warnings []export.Warning
+		if exportTo != "" {
+			warnings, err = export.Export(&buf, exportTo, aliases)
+		} else {
+			err = portable.Encode(&buf, exportFormat, aliases)
+		}
+		if err != nil {
+			application.Logger.Error("failed to export aliases", "error", err)
+			return fmt.Errorf("failed to export aliases: %w", err)
+		}
+		exported := len(aliases)
+		for _, warning := range warnings {
+			if warning.Skipped {
+				exported--
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %s\n", warning)
+		}
+
+		if exportOutput == "" {
+			_, err = cmd.OutOrStdout().Write(buf.Bytes())
+			return err
+		}
+		if err := fsutil.WriteFileAtomic(exportOutput, buf.Bytes(), 0644); err != nil {
+			application.Logger.Error("failed to write export", "error", err)
+			return fmt.Errorf("failed to write export: %w", err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Exported %d aliases to %s\n", exported, exportOutput)
+		return nil
+	},
+}
+
+// matchAliases returns the aliases whose name matches the glob pattern, or
+// all of them when pattern is empty.
+func matchAliases(aliases []*domain.Alias, pattern string) []*domain.Alias {
+	if pattern == "" {
+		return aliases
+	}
+	var matched []*domain.Alias
+	for _, alias := range aliases {
+		if ok, _ := path.Match(pattern, alias.Name); ok {
+			matched = append(matched, alias)
+		}
+	}
+	return matched
+}
+
+func init() {
+	aliasCmd.AddCommand(exportAliasCmd)
+	exportAliasCmd.Flags().StringVar(&exportTo, "to", "", "Export as definitions for bash, zsh, fish, pwsh, make, just or vscode-tasks")
+	exportAliasCmd.Flags().StringVar(&exportFormat, "format", portable.JSON, "Document format: json, yaml or toml")
+	exportAliasCmd.Flags().StringVar(&exportMatch, "match", "", "Only export aliases whose name matches this glob pattern")
+	exportAliasCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Write to a file instead of standard output")
+	exportAliasCmd.MarkFlagsMutuallyExclusive("to", "format")
+}
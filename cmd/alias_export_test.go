@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportAliasesCommand(t *testing.T) {
+	t.Run("export to stdout with warnings", func(t *testing.T) {
+		application := setupTestApp(t)
+		ctx := context.Background()
+		application.AliasService.CreateAlias(ctx, "gs", "git status")
+		application.AliasService.CreateAlias(ctx, "k8s/get", "kubectl get")
+
+		output, err := runCommand(t, "alias", "export", "--to", "zsh")
+		require.NoError(t, err)
+		assert.Contains(t, output, "alias gs='git status'")
+		assert.Contains(t, output, `Warning: k8s/get: skipped: "k8s/get" is not a valid zsh function name`)
+	})
+
+	t.Run("export to file", func(t *testing.T) {
+		application := setupTestApp(t)
+		ctx := context.Background()
+		application.AliasService.CreateAlias(ctx, "greet", "echo Hello, $1")
+		application.AliasService.CreateAlias(ctx, "k8s/get", "kubectl get")
+		path := filepath.Join(t.TempDir(), "justfile")
+
+		output, err := runCommand(t, "alias", "export", "--to", "just", "--output", path)
+		require.NoError(t, err)
+		assert.Contains(t, output, "Exported 2 aliases to "+path)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "greet *args:\n    @echo Hello, $1\n")
+	})
+
+	t.Run("json document by default", func(t *testing.T) {
+		application := setupTestApp(t)
+		application.AliasService.CreateAlias(context.Background(), "gs", "git status")
+
+		output, err := runCommand(t, "alias", "export")
+		require.NoError(t, err)
+		assert.Contains(t, output, `"version": 1`)
+		assert.Contains(t, output, `"command": "git status"`)
+	})
+
+	t.Run("invalid match pattern", func(t *testing.T) {
+		setupTestApp(t)
+
+		_, err := runCommand(t, "alias", "export", "--match", "[")
+		assert.ErrorContains(t, err, "invalid --match pattern")
+	})
+
+	t.Run("to and format are exclusive", func(t *testing.T) {
+		setupTestApp(t)
+
+		_, err := runCommand(t, "alias", "export", "--to", "bash", "--format", "yaml")
+		assert.Error(t, err)
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		setupTestApp(t)
+
+		_, err := runCommand(t, "alias", "export", "--to", "cmd")
+		assert.ErrorContains(t, err, `unsupported export format "cmd"`)
+	})
+}
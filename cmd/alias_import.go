@@ -0,0 +1,475 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/internal/app"
+	"github.com/msaglietto/mantrid/internal/logging"
+	"github.com/msaglietto/mantrid/internal/portable"
+	"github.com/msaglietto/mantrid/internal/shell"
+	"github.com/msaglietto/mantrid/internal/tasks"
+	"github.com/msaglietto/mantrid/service"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importFrom          string
+	importRenameInvalid bool
+	importDryRun        bool
+	importStrategy      string
+	importPrefix        string
+)
+
+// importShells lists the shells whose startup files can be imported.
+var importShells = []string{shell.Bash, shell.Zsh, shell.Fish}
+
+var importAliasCmd = &cobra.Command{
+	Use:   "import [file]",
+	Short: "Import aliases from exports, shell startup files and project task runners",
+	Long: `Import the aliases of a document written by "mantrid alias export", the
+aliases and one-line functions defined in a shell startup file, or the tasks
+of a project.
+
+  mantrid alias import aliases.json              # format from the extension
+  mantrid alias import --from yaml - < aliases.yaml
+  mantrid alias import team.toml --strategy newest
+  mantrid alias import --from bash ~/.bashrc
+  mantrid alias import --from zsh                # reads ~/.zshrc
+  mantrid alias import --from fish --dry-run     # reads config.fish
+  mantrid alias import --from make ~/src/app     # reads ~/src/app/Makefile
+
+bash and zsh "alias name='...'" lines and "name() { ...; }" functions are
+imported, as are fish "alias", "abbr" and "function name; ...; end" lines.
+Use "-" as the file to read standard input.
+
+Importing a document shows what it does to each alias before applying it.
+Aliases that already exist are handled according to --strategy: skip keeps
+them, overwrite replaces them, newest keeps whichever was updated last and
+interactive asks about each one. Everything is applied in a single write.
+
+Names that are not valid alias names are reported and skipped, or with
+--rename-invalid imported under a valid name ("k8s.get" becomes "k8s/get").
+Aliases that already exist are skipped unless --strategy overwrite is given.
+Everything is imported in a single write; --dry-run shows what would change
+without writing anything.
+
+With --from make, npm, just or task the targets of a Makefile, the scripts
+of a package.json, the recipes of a justfile or the tasks of a Taskfile
+become aliases that run them through that tool, in the project directory.
+The file is looked up in the given directory, the current one by default.
+The aliases are named after the project, as in "app/build"; use --prefix to
+choose another name. Importing the same file again updates and adds its
+aliases and removes the ones that are gone from it, but never touches
+aliases that were not imported from that file.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from := importFrom
+		if from == "" {
+			if len(args) == 0 {
+				return errors.New("give the file to import, or --from to read a shell startup file or project")
+			}
+			format, ok := portable.FormatOf(args[0])
+			if !ok {
+				return fmt.Errorf("cannot tell the format of %s from its extension: use --from %s", args[0], strings.Join(portable.Formats, ", --from "))
+			}
+			from = format
+		}
+
+		sources := slices.Concat(portable.Formats, importShells, tasks.Runners)
+		if !slices.Contains(sources, from) {
+			return fmt.Errorf("unsupported import source %q: must be one of %s", from, strings.Join(sources, ", "))
+		}
+		if importStrategy == string(service.ConflictInteractive) && len(args) > 0 && args[0] == "-" {
+			return errors.New("the interactive strategy reads answers from standard input, so the aliases cannot be read from it too")
+		}
+
+		application, err := appFactory(cmd.Context(), GetConfigFile())
+		if err != nil {
+			return err
+		}
+
+		ctx := logging.WithLogger(cmd.Context(), application.Logger)
+		out := cmd.OutOrStdout()
+
+		if slices.Contains(tasks.Runners, from) {
+			return importTasks(ctx, out, application, args)
+		}
+		if slices.Contains(portable.Formats, from) {
+			return importDocument(ctx, cmd, application, from, args[0])
+		}
+
+		path := defaultStartupFile(importFrom)
+		if len(args) > 0 {
+			path = args[0]
+		}
+
+		application.Logger.Info("importing aliases", "from", importFrom, "file", path, "dry_run", importDryRun)
+
+		var defs []shell.Definition
+		var problems []error
+		err = readInput(cmd, path, func(r io.Reader) error {
+			defs, problems, err = shell.ParseDefinitions(importFrom, r)
+			return err
+		})
+		if err != nil {
+			application.Logger.Error("failed to read aliases", "error", err)
+			return fmt.Errorf("failed to read aliases: %w", err)
+		}
+		for _, problem := range problems {
+			fmt.Fprintf(out, "Skipped %v\n", problem)
+		}
+
+		aliases := importCandidates(out, application.AliasService, defs, application.Config.NamespaceSeparator)
+		if len(aliases) == 0 {
+			fmt.Fprintf(out, "No aliases to import from %s\n", path)
+			return nil
+		}
+
+		result, err := application.AliasService.ImportAliases(ctx, aliases, importOptions(cmd, importDryRun))
+		if err != nil {
+			application.Logger.Error("failed to import aliases", "error", err)
+			return fmt.Errorf("failed to import aliases: %w", err)
+		}
+
+		if importDryRun {
+			return writeDryRun(out, aliases, result, path)
+		}
+
+		if len(result.Skipped) > 0 {
+			fmt.Fprintf(out, "Kept existing aliases: %s (use --strategy overwrite to replace them)\n", strings.Join(result.Skipped, ", "))
+		}
+		application.Logger.Info("aliases imported successfully",
+			"created", len(result.Created), "updated", len(result.Updated), "skipped", len(result.Skipped))
+		fmt.Fprintf(out, "Imported aliases from %s: %s\n", path, importSummary(result))
+		return nil
+	},
+}
+
+// importDocument imports the aliases of a portable document, showing the
+// plan before applying it.
+func importDocument(ctx context.Context, cmd *cobra.Command, application *app.App, format, path string) error {
+	out := cmd.OutOrStdout()
+
+	application.Logger.Info("importing aliases", "format", format, "file", path, "strategy", importStrategy, "dry_run", importDryRun)
+
+	var aliases []*domain.Alias
+	err := readInput(cmd, path, func(r io.Reader) error {
+		var err error
+		aliases, err = portable.Decode(r, format)
+		return err
+	})
+	if err != nil {
+		application.Logger.Error("failed to read aliases", "error", err)
+		return fmt.Errorf("failed to read aliases: %w", err)
+	}
+	if len(aliases) == 0 {
+		fmt.Fprintf(out, "No aliases to import from %s\n", path)
+		return nil
+	}
+
+	plan, err := application.AliasService.ImportAliases(ctx, aliases, importOptions(cmd, true))
+	if err != nil {
+		application.Logger.Error("failed to import aliases", "error", err)
+		return fmt.Errorf("failed to import aliases: %w", err)
+	}
+	if importDryRun {
+		return writeDryRun(out, aliases, plan, path)
+	}
+	if err := writeImportPlan(out, aliases, plan); err != nil {
+		return err
+	}
+	if len(plan.Created)+len(plan.Updated)+len(plan.Conflicts) == 0 {
+		fmt.Fprintf(out, "Nothing to import from %s: %s\n", path, importSummary(plan))
+		return nil
+	}
+
+	result, err := application.AliasService.ImportAliases(ctx, aliases, importOptions(cmd, false))
+	if err != nil {
+		application.Logger.Error("failed to import aliases", "error", err)
+		return fmt.Errorf("failed to import aliases: %w", err)
+	}
+
+	if len(result.Skipped) > 0 {
+		fmt.Fprintf(out, "Kept existing aliases: %s\n", strings.Join(result.Skipped, ", "))
+	}
+	application.Logger.Info("aliases imported successfully",
+		"created", len(result.Created), "updated", len(result.Updated), "skipped", len(result.Skipped))
+	fmt.Fprintf(out, "Imported aliases from %s: %s\n", path, importSummary(result))
+	return nil
+}
+
+// importOptions returns the options of an import with the flags given,
+// asking on cmd's input about conflicts with --strategy interactive.
+func importOptions(cmd *cobra.Command, dryRun bool) service.ImportOptions {
+	opts := service.ImportOptions{
+		Strategy: service.ConflictStrategy(importStrategy),
+		DryRun:   dryRun,
+	}
+	if opts.Strategy == service.ConflictInteractive {
+		// One reader for all questions, so that buffered answers are not lost
+		in := bufio.NewReader(cmd.InOrStdin())
+		opts.Decide = func(stored, imported *domain.Alias) (bool, error) {
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "Alias '%s' already exists:\n", stored.Name)
+			fmt.Fprintf(out, "  stored:   %s (updated %s)\n", stored.Command, formatTime(stored.UpdatedAt))
+			fmt.Fprintf(out, "  imported: %s (updated %s)\n", imported.Command, formatTime(imported.UpdatedAt))
+			return confirm(cmd, in, "Replace it?"), nil
+		}
+	}
+	return opts
+}
+
+// importTasks imports the tasks of the project at args[0], or the current
+// directory, as aliases maintained by the import.
+func importTasks(ctx context.Context, out io.Writer, application *app.App, args []string) error {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+
+	application.Logger.Info("importing tasks", "from", importFrom, "path", path, "dry_run", importDryRun)
+
+	project, err := tasks.Load(importFrom, path)
+	if err != nil {
+		application.Logger.Error("failed to read tasks", "error", err)
+		return fmt.Errorf("failed to read tasks: %w", err)
+	}
+
+	aliases := taskAliases(out, application.AliasService, project, application.Config.NamespaceSeparator)
+	result, err := application.AliasService.ImportAliases(ctx, aliases, service.ImportOptions{
+		DryRun: importDryRun,
+		Source: importFrom + ":" + project.File,
+	})
+	if err != nil {
+		application.Logger.Error("failed to import tasks", "error", err)
+		return fmt.Errorf("failed to import tasks: %w", err)
+	}
+
+	if importDryRun {
+		return writeDryRun(out, aliases, result, project.File)
+	}
+
+	if len(result.Skipped) > 0 {
+		fmt.Fprintf(out, "Kept aliases not imported from %s: %s\n", project.File, strings.Join(result.Skipped, ", "))
+	}
+	application.Logger.Info("tasks imported successfully", "created", len(result.Created),
+		"updated", len(result.Updated), "removed", len(result.Removed), "skipped", len(result.Skipped))
+	fmt.Fprintf(out, "Imported tasks from %s: %s\n", project.File, importSummary(result))
+	return nil
+}
+
+// taskAliases turns the tasks of project into aliases named
+// "<prefix>/<task>" that run in the project directory. Task names are made
+// valid alias names, with ":" as used for namespaces by npm and Task becoming
+// the separator; tasks that still cannot be named are reported to out.
+func taskAliases(out io.Writer, svc service.AliasService, project *tasks.Project, separator string) []*domain.Alias {
+	prefix := strings.Trim(importPrefix, domain.NamespaceSeparators)
+	if prefix == "" {
+		prefix = sanitizeAliasName(project.Name(), separator)
+	}
+
+	now := time.Now()
+	var aliases []*domain.Alias
+	seen := map[string]string{}
+	for _, task := range project.Tasks {
+		alias := &domain.Alias{
+			Name:        prefix + separator + sanitizeAliasName(strings.ReplaceAll(task.Name, ":", separator), separator),
+			Command:     task.Command,
+			Description: task.Description,
+			WorkDir:     project.Dir,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		if err := svc.ValidateAlias(alias); err != nil {
+			fmt.Fprintf(out, "Skipped task '%s': %v\n", task.Name, err)
+			continue
+		}
+		if other, ok := seen[alias.Name]; ok {
+			fmt.Fprintf(out, "Skipped task '%s': alias '%s' is already taken by task '%s'\n", task.Name, alias.Name, other)
+			continue
+		}
+		seen[alias.Name] = task.Name
+		aliases = append(aliases, alias)
+	}
+	return aliases
+}
+
+// importCandidates turns parsed definitions into aliases, reporting to out
+// the ones that are invalid and, with --rename-invalid, the ones renamed to
+// a valid name. When a name is defined more than once the last definition
+// wins, as it would in the shell.
+func importCandidates(out io.Writer, svc service.AliasService, defs []shell.Definition, separator string) []*domain.Alias {
+	now := time.Now()
+	var aliases []*domain.Alias
+	index := map[string]int{}
+
+	for _, def := range defs {
+		alias := &domain.Alias{
+			Name:        def.Name,
+			Command:     def.Command,
+			Description: def.Description,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+
+		err := svc.ValidateAlias(alias)
+		if err != nil && importRenameInvalid && isNameError(err) {
+			if name := sanitizeAliasName(def.Name, separator); name != "" && name != def.Name {
+				alias.Name = name
+				if err = svc.ValidateAlias(alias); err == nil {
+					fmt.Fprintf(out, "Renamed '%s' to '%s' (line %d)\n", def.Name, name, def.Line)
+				}
+			}
+		}
+		if err != nil {
+			hint := ""
+			if !importRenameInvalid && isNameError(err) {
+				hint = " (use --rename-invalid to import it under a valid name)"
+			}
+			fmt.Fprintf(out, "Skipped invalid alias '%s' (line %d): %v%s\n", def.Name, def.Line, err, hint)
+			continue
+		}
+
+		if i, ok := index[alias.Name]; ok {
+			aliases[i] = alias
+			continue
+		}
+		index[alias.Name] = len(aliases)
+		aliases = append(aliases, alias)
+	}
+	return aliases
+}
+
+// isNameError reports whether err is about the alias name.
+func isNameError(err error) bool {
+	return errors.Is(err, domain.ErrInvalidAliasName) || errors.Is(err, domain.ErrNameTooLong)
+}
+
+// sanitizeAliasName derives a valid alias name from name: namespace
+// separators become separator, other unsupported characters become "_", and
+// separators at either end are dropped. It returns "" when nothing usable is
+// left.
+func sanitizeAliasName(name, separator string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			b.WriteRune(r)
+		case strings.ContainsRune(domain.NamespaceSeparators, r):
+			if !strings.HasSuffix(b.String(), separator) {
+				b.WriteString(separator)
+			}
+		default:
+			if !strings.HasSuffix(b.String(), "_") {
+				b.WriteRune('_')
+			}
+		}
+	}
+	return strings.Trim(b.String(), separator)
+}
+
+// defaultStartupFile returns the usual startup file of a shell.
+func defaultStartupFile(sh string) string {
+	home, _ := os.UserHomeDir()
+	switch sh {
+	case shell.Zsh:
+		if dir := os.Getenv("ZDOTDIR"); dir != "" {
+			return filepath.Join(dir, ".zshrc")
+		}
+		return filepath.Join(home, ".zshrc")
+	case shell.Fish:
+		config := os.Getenv("XDG_CONFIG_HOME")
+		if config == "" {
+			config = filepath.Join(home, ".config")
+		}
+		return filepath.Join(config, "fish", "config.fish")
+	default:
+		return filepath.Join(home, ".bashrc")
+	}
+}
+
+// readInput calls read with the contents of path, or of standard input when
+// path is "-".
+func readInput(cmd *cobra.Command, path string, read func(io.Reader) error) error {
+	if path == "-" {
+		return read(cmd.InOrStdin())
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return read(f)
+}
+
+// writeDryRun prints the plan and summary of an import that was not applied.
+func writeDryRun(out io.Writer, aliases []*domain.Alias, result *service.ImportResult, path string) error {
+	if err := writeImportPlan(out, aliases, result); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Dry run: %s from %s; nothing was written\n", importSummary(result), path)
+	return nil
+}
+
+// writeImportPlan prints what importing aliases does to each of them,
+// followed by the aliases the import removes.
+func writeImportPlan(out io.Writer, aliases []*domain.Alias, result *service.ImportResult) error {
+	actions := map[string]string{}
+	for action, names := range map[string][]string{
+		"create":    result.Created,
+		"update":    result.Updated,
+		"skip":      result.Skipped,
+		"unchanged": result.Unchanged,
+		"conflict":  result.Conflicts,
+	} {
+		for _, name := range names {
+			actions[name] = action
+		}
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ACTION\tNAME\tCOMMAND\t")
+	fmt.Fprintln(w, "------\t----\t-------\t")
+	for _, alias := range aliases {
+		fmt.Fprintf(w, "%s\t%s\t%s\t\n", actions[alias.Name], alias.Name, alias.Command)
+	}
+	for _, name := range result.Removed {
+		fmt.Fprintf(w, "remove\t%s\t\t\n", name)
+	}
+	return w.Flush()
+}
+
+// importSummary counts the outcomes of an import. Removals and conflicts are
+// only mentioned when there are any, as only task imports remove aliases and
+// only interactive dry runs leave conflicts.
+func importSummary(result *service.ImportResult) string {
+	summary := fmt.Sprintf("%d created, %d updated", len(result.Created), len(result.Updated))
+	if len(result.Removed) > 0 {
+		summary += fmt.Sprintf(", %d removed", len(result.Removed))
+	}
+	summary += fmt.Sprintf(", %d skipped, %d unchanged", len(result.Skipped), len(result.Unchanged))
+	if len(result.Conflicts) > 0 {
+		summary += fmt.Sprintf(", %d conflicts", len(result.Conflicts))
+	}
+	return summary
+}
+
+func init() {
+	aliasCmd.AddCommand(importAliasCmd)
+	importAliasCmd.Flags().StringVar(&importFrom, "from", "", "Format to import from: json, yaml, toml, bash, zsh, fish, make, npm, just or task (default from the file extension)")
+	importAliasCmd.Flags().BoolVar(&importRenameInvalid, "rename-invalid", false, "Import aliases with invalid names under a valid name instead of skipping them")
+	importAliasCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Show what would be imported without changing anything")
+	importAliasCmd.Flags().StringVar(&importStrategy, "strategy", string(service.ConflictSkip), "What to do with aliases that already exist: skip, overwrite, newest or interactive")
+	importAliasCmd.Flags().StringVar(&importPrefix, "prefix", "", "Namespace for imported tasks (default the project directory name)")
+}
@@ -0,0 +1,352 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/repository"
+	jsonrepo "github.com/msaglietto/mantrid/repository/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeRCFile writes a shell startup file for an import test.
+func writeRCFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rc")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+const testBashRC = `alias ll='ls -la'
+alias k8s.get='kubectl get'
+alias ..='cd ..'
+gst() { git status "$@"; }
+mkcd() {
+  mkdir -p "$1" && cd "$1"
+}
+alias k='kubectl --context prod'
+`
+
+func TestImportAliasesCommand(t *testing.T) {
+	t.Run("import from bash", func(t *testing.T) {
+		application := setupTestApp(t)
+		path := writeRCFile(t, testBashRC)
+
+		output, err := runCommand(t, "alias", "import", "--from", "bash", path)
+		require.NoError(t, err)
+		assert.Contains(t, output, "Skipped line 5: only one-line functions can be imported")
+		assert.Contains(t, output, "Skipped invalid alias 'k8s.get' (line 2)")
+		assert.Contains(t, output, "use --rename-invalid")
+		assert.Contains(t, output, "Skipped invalid alias '..' (line 3)")
+		assert.Contains(t, output, "3 created, 0 updated, 0 skipped, 0 unchanged")
+
+		aliases, err := application.AliasService.ListAliases(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, aliases, 3)
+
+		gst, err := application.AliasService.GetAlias(context.Background(), "gst")
+		require.NoError(t, err)
+		assert.Equal(t, `git status "$@"`, gst.Command)
+	})
+
+	t.Run("rename invalid names", func(t *testing.T) {
+		application := setupTestApp(t)
+		path := writeRCFile(t, testBashRC)
+
+		output, err := runCommand(t, "alias", "import", "--from", "bash", "--rename-invalid", path)
+		require.NoError(t, err)
+		assert.Contains(t, output, "Renamed 'k8s.get' to 'k8s/get' (line 2)")
+		assert.Contains(t, output, "Skipped invalid alias '..' (line 3)")
+
+		alias, err := application.AliasService.GetAlias(context.Background(), "k8s/get")
+		require.NoError(t, err)
+		assert.Equal(t, "kubectl get", alias.Command)
+	})
+
+	t.Run("existing aliases are skipped or overwritten", func(t *testing.T) {
+		application := setupTestApp(t)
+		ctx := context.Background()
+		require.NoError(t, application.AliasService.CreateAlias(ctx, "k", "kubectl"))
+		require.NoError(t, application.AliasService.CreateAlias(ctx, "ll", "ls -la"))
+		path := writeRCFile(t, testBashRC)
+
+		output, err := runCommand(t, "alias", "import", "--from", "bash", path)
+		require.NoError(t, err)
+		assert.Contains(t, output, "Kept existing aliases: k (use --strategy overwrite to replace them)")
+		assert.Contains(t, output, "1 created, 0 updated, 1 skipped, 1 unchanged")
+
+		alias, _ := application.AliasService.GetAlias(ctx, "k")
+		assert.Equal(t, "kubectl", alias.Command)
+
+		output, err = runCommand(t, "alias", "import", "--from", "bash", "--strategy", "overwrite", path)
+		require.NoError(t, err)
+		assert.Contains(t, output, "0 created, 1 updated, 0 skipped, 2 unchanged")
+
+		alias, _ = application.AliasService.GetAlias(ctx, "k")
+		assert.Equal(t, "kubectl --context prod", alias.Command)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		application := setupTestApp(t)
+		require.NoError(t, application.AliasService.CreateAlias(context.Background(), "k", "kubectl"))
+		path := writeRCFile(t, testBashRC)
+
+		output, err := runCommand(t, "alias", "import", "--from", "bash", "--dry-run", path)
+		require.NoError(t, err)
+		assert.Regexp(t, `create\s+ll\s+ls -la`, output)
+		assert.Regexp(t, `skip\s+k\s+kubectl --context prod`, output)
+		assert.Contains(t, output, "nothing was written")
+
+		_, err = application.AliasService.GetAlias(context.Background(), "ll")
+		assert.ErrorIs(t, err, domain.ErrAliasNotFound)
+	})
+
+	t.Run("import from fish on stdin", func(t *testing.T) {
+		application := setupTestApp(t)
+		rootCmd.SetIn(strings.NewReader("abbr -a gco git checkout\nalias ll 'ls -la'\n"))
+		t.Cleanup(func() { rootCmd.SetIn(nil) })
+
+		output, err := runCommand(t, "alias", "import", "--from", "fish", "-")
+		require.NoError(t, err)
+		assert.Contains(t, output, "2 created")
+
+		alias, err := application.AliasService.GetAlias(context.Background(), "gco")
+		require.NoError(t, err)
+		assert.Equal(t, "git checkout", alias.Command)
+	})
+
+	t.Run("unsupported source", func(t *testing.T) {
+		setupTestApp(t)
+
+		_, err := runCommand(t, "alias", "import", "--from", "csh", "rc")
+		assert.ErrorContains(t, err, `unsupported import source "csh"`)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		setupTestApp(t)
+
+		_, err := runCommand(t, "alias", "import", "--from", "bash", filepath.Join(t.TempDir(), "missing"))
+		assert.ErrorContains(t, err, "failed to read aliases")
+	})
+}
+
+func TestSanitizeAliasName(t *testing.T) {
+	assert.Equal(t, "k8s/get", sanitizeAliasName("k8s.get", "/"))
+	assert.Equal(t, "k8s.get", sanitizeAliasName("k8s/get", "."))
+	assert.Equal(t, "g_", sanitizeAliasName("g++", "/"))
+	assert.Equal(t, "", sanitizeAliasName("..", "/"))
+	assert.Equal(t, "a/b", sanitizeAliasName("a..b.", "/"))
+}
+
+// writeProject writes a Makefile into a new project directory called app.
+func writeProject(t *testing.T, makefile string) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "app")
+	require.NoError(t, os.Mkdir(dir, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Makefile"), []byte(makefile), 0600))
+	return dir
+}
+
+func TestImportTasksCommand(t *testing.T) {
+	t.Run("import and re-import a Makefile", func(t *testing.T) {
+		application := setupTestApp(t)
+		ctx := context.Background()
+		dir := writeProject(t, "build: ## Build it\n\tgo build\nlint:\n\tgo vet\nold:\n\ttrue\n")
+		application.AliasService.CreateAlias(ctx, "app/test", "go test ./...")
+
+		output, err := runCommand(t, "alias", "import", "--from", "make", dir)
+		require.NoError(t, err)
+		assert.Contains(t, output, "3 created, 0 updated, 0 skipped, 0 unchanged")
+
+		build, err := application.AliasService.GetAlias(ctx, "app/build")
+		require.NoError(t, err)
+		assert.Equal(t, "make build", build.Command)
+		assert.Equal(t, "Build it", build.Description)
+		assert.Equal(t, dir, build.WorkDir)
+		assert.Equal(t, "make:"+filepath.Join(dir, "Makefile"), build.Source)
+
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "Makefile"),
+			[]byte("build: ## Build everything\n\tgo build\nlint:\n\tgo vet\ntest:\n\tgo test\n"), 0600))
+
+		output, err = runCommand(t, "alias", "import", "--from", "make", dir)
+		require.NoError(t, err)
+		assert.Contains(t, output, "Kept aliases not imported from "+filepath.Join(dir, "Makefile")+": app/test")
+		assert.Contains(t, output, "0 created, 1 updated, 1 removed, 1 skipped, 1 unchanged")
+
+		build, _ = application.AliasService.GetAlias(ctx, "app/build")
+		assert.Equal(t, "Build everything", build.Description)
+		_, err = application.AliasService.GetAlias(ctx, "app/old")
+		assert.ErrorIs(t, err, domain.ErrAliasNotFound)
+		test, _ := application.AliasService.GetAlias(ctx, "app/test")
+		assert.Equal(t, "go test ./...", test.Command)
+		assert.Empty(t, test.Source)
+	})
+
+	t.Run("prefix and task namespaces", func(t *testing.T) {
+		application := setupTestApp(t)
+		dir := filepath.Join(t.TempDir(), "web")
+		require.NoError(t, os.Mkdir(dir, 0700))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"),
+			[]byte(`{"scripts": {"test:unit": "vitest", "build": "vite build"}}`), 0600))
+
+		_, err := runCommand(t, "alias", "import", "--from", "npm", "--prefix", "front/", dir)
+		require.NoError(t, err)
+
+		alias, err := application.AliasService.GetAlias(context.Background(), "front/test/unit")
+		require.NoError(t, err)
+		assert.Equal(t, "npm run test:unit --", alias.Command)
+	})
+
+	t.Run("dry run lists removals", func(t *testing.T) {
+		application := setupTestApp(t)
+		dir := writeProject(t, "build:\n\tgo build\nold:\n\ttrue\n")
+
+		_, err := runCommand(t, "alias", "import", "--from", "make", dir)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "Makefile"), []byte("build:\n\tgo build\n"), 0600))
+
+		output, err := runCommand(t, "alias", "import", "--from", "make", "--dry-run", dir)
+		require.NoError(t, err)
+		assert.Regexp(t, `remove\s+app/old`, output)
+		assert.Contains(t, output, "nothing was written")
+
+		_, err = application.AliasService.GetAlias(context.Background(), "app/old")
+		assert.NoError(t, err)
+	})
+
+	t.Run("missing task file", func(t *testing.T) {
+		setupTestApp(t)
+
+		_, err := runCommand(t, "alias", "import", "--from", "just", t.TempDir())
+		assert.ErrorContains(t, err, "failed to read tasks: no justfile")
+	})
+}
+
+func TestImportDocumentCommand(t *testing.T) {
+	// exportDocument exports the aliases of a fresh store to a file.
+	exportDocument := func(t *testing.T, name string, args ...string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), name)
+		_, err := runCommand(t, append([]string{"alias", "export", "--output", path}, args...)...)
+		require.NoError(t, err)
+		return path
+	}
+
+	t.Run("alias file of the json storage", func(t *testing.T) {
+		ctx := context.Background()
+		path := filepath.Join(t.TempDir(), "aliases.json")
+		store := jsonrepo.NewAliasRepository(path)
+		gs, _ := domain.NewAlias("gs", "git status", domain.WithDescription("Status"))
+		require.NoError(t, store.Create(ctx, gs))
+
+		application := setupTestApp(t)
+		output, err := runCommand(t, "alias", "import", path)
+		require.NoError(t, err)
+		assert.Contains(t, output, "1 created, 0 updated, 0 skipped, 0 unchanged")
+		imported, err := application.AliasService.GetAlias(ctx, "gs")
+		require.NoError(t, err)
+		assert.Equal(t, "Status", imported.Description)
+	})
+
+	t.Run("round trip with match", func(t *testing.T) {
+		application := setupTestApp(t)
+		ctx := context.Background()
+		application.AliasService.CreateAlias(ctx, "k8s/get", "kubectl get", domain.WithDescription("Get resources"))
+		application.AliasService.CreateAlias(ctx, "k8s/logs", "kubectl logs $1")
+		application.AliasService.CreateAlias(ctx, "gs", "git status")
+		path := exportDocument(t, "aliases.yaml", "--format", "yaml", "--match", "k8s/*")
+
+		application = setupTestApp(t)
+		output, err := runCommand(t, "alias", "import", path)
+		require.NoError(t, err)
+		assert.Regexp(t, `create\s+k8s/get\s+kubectl get`, output)
+		assert.Contains(t, output, "2 created, 0 updated, 0 skipped, 0 unchanged")
+
+		aliases, _ := application.AliasService.ListAliases(ctx)
+		assert.Len(t, aliases, 2)
+		get, _ := application.AliasService.GetAlias(ctx, "k8s/get")
+		assert.Equal(t, "Get resources", get.Description)
+	})
+
+	t.Run("newest strategy", func(t *testing.T) {
+		application := setupTestApp(t)
+		ctx := context.Background()
+		application.AliasService.CreateAlias(ctx, "gs", "git status -sb")
+		application.AliasService.CreateAlias(ctx, "k", "kubectl --context prod")
+		path := exportDocument(t, "aliases.toml", "--format", "toml")
+
+		// The stored gs is older than the exported one, k is newer
+		application = setupTestApp(t)
+		now := time.Now()
+		require.NoError(t, application.AliasService.BatchAliases(ctx, []repository.Op{
+			repository.CreateOp(&domain.Alias{Name: "gs", Command: "git status", CreatedAt: now.Add(-time.Hour), UpdatedAt: now.Add(-time.Hour)}),
+			repository.CreateOp(&domain.Alias{Name: "k", Command: "kubectl", CreatedAt: now.Add(-time.Hour), UpdatedAt: now.Add(time.Hour)}),
+		}))
+
+		output, err := runCommand(t, "alias", "import", path, "--strategy", "newest")
+		require.NoError(t, err)
+		assert.Regexp(t, `update\s+gs`, output)
+		assert.Regexp(t, `skip\s+k\s`, output)
+		assert.Contains(t, output, "Kept existing aliases: k")
+
+		gs, _ := application.AliasService.GetAlias(ctx, "gs")
+		assert.Equal(t, "git status -sb", gs.Command)
+		k, _ := application.AliasService.GetAlias(ctx, "k")
+		assert.Equal(t, "kubectl", k.Command)
+	})
+
+	t.Run("interactive strategy", func(t *testing.T) {
+		application := setupTestApp(t)
+		ctx := context.Background()
+		application.AliasService.CreateAlias(ctx, "a", "echo new a")
+		application.AliasService.CreateAlias(ctx, "b", "echo new b")
+		application.AliasService.CreateAlias(ctx, "c", "echo c")
+		path := exportDocument(t, "aliases.json")
+
+		application = setupTestApp(t)
+		application.AliasService.CreateAlias(ctx, "a", "echo a")
+		application.AliasService.CreateAlias(ctx, "b", "echo b")
+		rootCmd.SetIn(strings.NewReader("y\nn\n"))
+		t.Cleanup(func() { rootCmd.SetIn(nil) })
+
+		output, err := runCommand(t, "alias", "import", path, "--strategy", "interactive")
+		require.NoError(t, err)
+		assert.Regexp(t, `conflict\s+a\s+echo new a`, output)
+		assert.Contains(t, output, "Alias 'a' already exists:\n  stored:   echo a")
+		assert.Contains(t, output, "1 created, 1 updated, 1 skipped, 0 unchanged")
+
+		a, _ := application.AliasService.GetAlias(ctx, "a")
+		assert.Equal(t, "echo new a", a.Command)
+		b, _ := application.AliasService.GetAlias(ctx, "b")
+		assert.Equal(t, "echo b", b.Command)
+	})
+
+	t.Run("invalid aliases import nothing", func(t *testing.T) {
+		application := setupTestApp(t)
+		path := writeRCFile(t, `{"version": 1, "aliases": [{"name": "ok", "command": "true"}, {"name": "bad name", "command": "true"}]}`)
+		jsonPath := path + ".json"
+		require.NoError(t, os.Rename(path, jsonPath))
+
+		_, err := runCommand(t, "alias", "import", jsonPath)
+		assert.ErrorContains(t, err, "alias #2")
+
+		aliases, _ := application.AliasService.ListAliases(context.Background())
+		assert.Empty(t, aliases)
+	})
+
+	t.Run("unknown extension", func(t *testing.T) {
+		setupTestApp(t)
+
+		_, err := runCommand(t, "alias", "import", "aliases.txt")
+		assert.ErrorContains(t, err, "cannot tell the format of aliases.txt")
+
+		_, err = runCommand(t, "alias", "import")
+		assert.ErrorContains(t, err, "give the file to import")
+	})
+}
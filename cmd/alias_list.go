@@ -7,14 +7,18 @@ import (
 	"text/tabwriter"
 	"time"
 
+	"github.com/msaglietto/mantrid/domain"
 	"github.com/msaglietto/mantrid/internal/logging"
 	"github.com/spf13/cobra"
 )
 
 var listAliasCmd = &cobra.Command{
-	Use:   "list",
+	Use:   "list [namespace]",
 	Short: "List all aliases",
-	Long:  `Display a list of all configured aliases with their commands and creation dates.`,
+	Long: `Display a list of all configured aliases with their commands and creation dates.
+
+Pass a namespace such as "k8s/" to list only the aliases below it.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		application, err := appFactory(cmd.Context(), GetConfigFile())
 		if err != nil {
@@ -22,10 +26,15 @@ var listAliasCmd = &cobra.Command{
 		}
 
 		ctx := logging.WithLogger(cmd.Context(), application.Logger)
-		application.Logger.Info("listing aliases")
+		namespace := ""
+		if len(args) > 0 {
+			namespace = args[0]
+		}
+
+		application.Logger.Info("listing aliases", "namespace", namespace)
 
-		// Get all aliases
-		aliases, err := application.AliasService.ListAliases(ctx)
+		// Get all aliases, or the ones below the requested namespace
+		aliases, err := application.AliasService.ListNamespace(ctx, namespace)
 		if err != nil {
 			application.Logger.Error("failed to list aliases", "error", err)
 			return fmt.Errorf("failed to list aliases: %w", err)
@@ -36,6 +45,8 @@ var listAliasCmd = &cobra.Command{
 			jsonOutput, _ := cmd.Flags().GetBool("json")
 			if jsonOutput {
 				fmt.Fprintln(cmd.OutOrStdout(), "[]")
+			} else if namespace != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "No aliases found in namespace '%s'\n", namespace)
 			} else {
 				fmt.Fprintln(cmd.OutOrStdout(), "No aliases found")
 			}
@@ -45,7 +56,14 @@ var listAliasCmd = &cobra.Command{
 		// Check if JSON output is requested
 		jsonOutput, _ := cmd.Flags().GetBool("json")
 		if jsonOutput {
-			output, err := stdjson.MarshalIndent(aliases, "", "  ")
+			entries := make([]listedAlias, len(aliases))
+			for i, alias := range aliases {
+				entries[i] = listedAlias{Alias: alias}
+				if alias.Layer != nil {
+					entries[i].Layer = alias.Layer.Name
+				}
+			}
+			output, err := stdjson.MarshalIndent(entries, "", "  ")
 			if err != nil {
 				application.Logger.Error("failed to marshal aliases to JSON", "error", err)
 				return fmt.Errorf("failed to marshal aliases to JSON: %w", err)
@@ -54,12 +72,28 @@ var listAliasCmd = &cobra.Command{
 			return nil
 		}
 
-		// Initialize tabwriter for formatted output
+		// Initialize tabwriter for formatted output, with the layer of each
+		// alias when project alias files are in play
+		layered := len(application.Layers) > 0
 		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "NAME\tCOMMAND\tCREATED\t")
-		fmt.Fprintln(w, "----\t-------\t-------\t")
+		if layered {
+			fmt.Fprintln(w, "NAME\tCOMMAND\tLAYER\tCREATED\t")
+			fmt.Fprintln(w, "----\t-------\t-----\t-------\t")
+		} else {
+			fmt.Fprintln(w, "NAME\tCOMMAND\tCREATED\t")
+			fmt.Fprintln(w, "----\t-------\t-------\t")
+		}
 
 		for _, alias := range aliases {
+			if layered {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n",
+					alias.Name,
+					alias.Command,
+					layerName(alias),
+					formatTime(alias.CreatedAt),
+				)
+				continue
+			}
 			fmt.Fprintf(w, "%s\t%s\t%s\t\n",
 				alias.Name,
 				alias.Command,
@@ -71,6 +105,21 @@ var listAliasCmd = &cobra.Command{
 	},
 }
 
+// listedAlias is an alias in the JSON output of the list, with the name of
+// the layer it comes from.
+type listedAlias struct {
+	*domain.Alias
+	Layer string `json:"layer,omitempty"`
+}
+
+// layerName returns the name of the layer alias was read from.
+func layerName(alias *domain.Alias) string {
+	if alias.Layer == nil {
+		return domain.GlobalLayerName
+	}
+	return alias.Layer.Name
+}
+
 func formatTime(t time.Time) string {
 	return t.Format("2006-01-02 15:04:05")
 }
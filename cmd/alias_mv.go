@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/msaglietto/mantrid/internal/logging"
+	"github.com/spf13/cobra"
+)
+
+var forceMove bool
+
+var mvAliasCmd = &cobra.Command{
+	Use:   "mv [from-namespace] [to-namespace]",
+	Short: "Move a namespace of aliases",
+	Long: `Rename every alias below one namespace to the same name below another,
+for example "mantrid alias mv k8s/ kube/" turns "k8s/prod/logs" into
+"kube/prod/logs". The whole subtree is renamed in a single operation.
+
+Fails if a destination name is taken unless --force flag is used.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		application, err := appFactory(cmd.Context(), GetConfigFile())
+		if err != nil {
+			return err
+		}
+
+		ctx := logging.WithLogger(cmd.Context(), application.Logger)
+		from, to := args[0], args[1]
+
+		application.Logger.Info("moving alias namespace", "from", from, "to", to)
+
+		renames, err := application.AliasService.MoveNamespace(ctx, from, to, forceMove)
+		if err != nil {
+			application.Logger.Error("failed to move aliases", "error", err)
+			return fmt.Errorf("failed to move aliases: %w", err)
+		}
+
+		oldNames := make([]string, 0, len(renames))
+		for oldName := range renames {
+			oldNames = append(oldNames, oldName)
+		}
+		sort.Strings(oldNames)
+
+		for _, oldName := range oldNames {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s -> %s\n", oldName, renames[oldName])
+		}
+
+		application.Logger.Info("alias namespace moved successfully", "from", from, "to", to, "count", len(renames))
+		fmt.Fprintf(cmd.OutOrStdout(), "Moved %d aliases from '%s' to '%s'\n", len(renames), from, to)
+		return nil
+	},
+}
+
+func init() {
+	mvAliasCmd.Flags().BoolVarP(&forceMove, "force", "f", false, "Overwrite existing aliases at the destination")
+	aliasCmd.AddCommand(mvAliasCmd)
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	stdjson "encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/internal/app"
+	"github.com/msaglietto/mantrid/repository/layered"
+	"github.com/msaglietto/mantrid/repository/memory"
+	"github.com/msaglietto/mantrid/repository/yaml"
+	"github.com/msaglietto/mantrid/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupProjectApp sets up a test app whose global aliases are layered under
+// the .mantrid.yaml of a project directory, returned as its layer.
+func setupProjectApp(t *testing.T, projectAliases string) (*app.App, *domain.Layer) {
+	t.Helper()
+	application := setupTestApp(t)
+
+	root := t.TempDir()
+	layer := &domain.Layer{Name: ".mantrid.yaml", File: filepath.Join(root, ".mantrid.yaml"), Root: root}
+	require.NoError(t, os.WriteFile(layer.File, []byte(projectAliases), 0644))
+
+	global := memory.NewAliasRepository()
+	repo := layered.NewAliasRepository(
+		layered.Layer{Layer: layer, Repo: yaml.NewAliasRepository(layer.File)},
+		layered.Layer{Layer: &domain.Layer{Name: domain.GlobalLayerName}, Repo: global},
+	)
+	application.AliasService = service.NewAliasService(repo)
+	application.GlobalAliases = service.NewAliasService(global)
+	application.Layers = []*domain.Layer{layer}
+	return application, layer
+}
+
+func TestProjectAliases(t *testing.T) {
+	const projectAliases = `aliases:
+  - name: build
+    command: go build ./...
+`
+
+	t.Run("list shows the layer", func(t *testing.T) {
+		setupProjectApp(t, projectAliases)
+		_, err := runCommand(t, "alias", "add", "gs", "git status")
+		require.NoError(t, err)
+
+		output, err := runCommand(t, "alias", "list")
+		require.NoError(t, err)
+		assert.Contains(t, output, "LAYER")
+		assert.Regexp(t, `build\s+go build \./\.\.\.\s+\.mantrid\.yaml`, output)
+		assert.Regexp(t, `gs\s+git status\s+global`, output)
+
+		output, err = runCommand(t, "alias", "list", "--json")
+		require.NoError(t, err)
+		var listed []map[string]any
+		require.NoError(t, stdjson.Unmarshal([]byte(output), &listed))
+		require.Len(t, listed, 2)
+		assert.Equal(t, ".mantrid.yaml", listed[0]["layer"])
+	})
+
+	t.Run("add --local writes to the project file", func(t *testing.T) {
+		_, layer := setupProjectApp(t, projectAliases)
+
+		output, err := runCommand(t, "alias", "add", "--local", "test", "go test ./...")
+		require.NoError(t, err)
+		assert.Contains(t, output, "Alias 'test' created successfully in .mantrid.yaml")
+
+		data, err := os.ReadFile(layer.File)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "go test ./...")
+
+		output, err = runCommand(t, "alias", "show", "test")
+		require.NoError(t, err)
+		assert.Regexp(t, `Layer:\s+\.mantrid\.yaml`, output)
+	})
+
+	t.Run("add --local outside a project", func(t *testing.T) {
+		setupTestApp(t)
+
+		_, err := runCommand(t, "alias", "add", "--local", "test", "go test ./...")
+		assert.ErrorContains(t, err, "no project alias file in use")
+	})
+
+	t.Run("shell functions leave project aliases out", func(t *testing.T) {
+		setupProjectApp(t, projectAliases)
+		_, err := runCommand(t, "alias", "add", "gs", "git status")
+		require.NoError(t, err)
+
+		output, err := runCommand(t, "shell", "init", "bash", "--functions")
+		require.NoError(t, err)
+		assert.Contains(t, output, "function gs {")
+		assert.NotContains(t, output, "function build")
+	})
+
+	t.Run("project aliases run in the project root", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("uses a POSIX shell")
+		}
+		_, layer := setupProjectApp(t, `aliases:
+  - name: where
+    command: pwd > pwd.txt
+`)
+
+		_, err := runCommand(t, "do", "where")
+		require.NoError(t, err)
+		data, err := os.ReadFile(filepath.Join(layer.Root, "pwd.txt"))
+		require.NoError(t, err)
+		resolved, _ := filepath.EvalSymlinks(layer.Root)
+		assert.Equal(t, resolved, strings.TrimSpace(string(data)))
+	})
+}
@@ -2,22 +2,34 @@ package cmd
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strings"
 
+	"github.com/msaglietto/mantrid/domain"
 	"github.com/msaglietto/mantrid/internal/logging"
+	"github.com/msaglietto/mantrid/repository"
+	"github.com/msaglietto/mantrid/service"
 	"github.com/spf13/cobra"
 )
 
-var forceRemove bool
+var (
+	forceRemove     bool
+	recursiveRemove bool
+)
 
 var removeAliasCmd = &cobra.Command{
 	Use:   "remove [name]",
 	Short: "Remove an alias",
-	Long:  `Remove an existing alias by name. Prompts for confirmation unless --force flag is used.`,
-	Args:  cobra.ExactArgs(1),
+	Long: `Remove an existing alias by name. Prompts for confirmation unless --force flag is used.
+
+With --recursive, the argument is a namespace such as "k8s/prod/" and every
+alias below it is removed.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeAliasNames,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		application, err := appFactory(cmd.Context(), GetConfigFile())
 		if err != nil {
@@ -27,13 +39,22 @@ var removeAliasCmd = &cobra.Command{
 		ctx := logging.WithLogger(cmd.Context(), application.Logger)
 		name := args[0]
 
+		if recursiveRemove {
+			return removeNamespace(ctx, cmd, application.AliasService, name)
+		}
+
 		application.Logger.Info("removing alias", "name", name)
 
-		// Get alias details for confirmation prompt
+		// Get alias details for confirmation prompt; the alias is then removed
+		// only as it was shown
+		rev := repository.AnyRevision
 		if !forceRemove {
 			alias, err := application.AliasService.GetAlias(ctx, name)
 			if err != nil {
 				application.Logger.Error("failed to get alias", "error", err)
+				if errors.Is(err, domain.ErrAliasNotFound) {
+					return fmt.Errorf("failed to get alias: %w.%s", err, didYouMean(ctx, application.AliasService, name))
+				}
 				return fmt.Errorf("failed to get alias: %w", err)
 			}
 
@@ -47,11 +68,19 @@ var removeAliasCmd = &cobra.Command{
 				fmt.Fprintln(cmd.OutOrStdout(), "Removal cancelled")
 				return nil
 			}
+			rev = alias.Revision
 		}
 
 		// Delete the alias
-		if err := application.AliasService.DeleteAlias(ctx, name); err != nil {
+		if err := application.AliasService.DeleteAlias(ctx, name, rev); err != nil {
 			application.Logger.Error("failed to delete alias", "error", err)
+			if errors.Is(err, domain.ErrConflict) {
+				return fmt.Errorf("failed to delete alias: %w while you were confirming; nothing was removed",
+					domain.ErrConflict)
+			}
+			if errors.Is(err, domain.ErrAliasNotFound) {
+				return fmt.Errorf("failed to delete alias: %w.%s", err, didYouMean(ctx, application.AliasService, name))
+			}
 			return fmt.Errorf("failed to delete alias: %w", err)
 		}
 
@@ -61,9 +90,61 @@ var removeAliasCmd = &cobra.Command{
 	},
 }
 
+// removeNamespace removes every alias below namespace, listing them and
+// prompting for confirmation unless --force flag is used.
+func removeNamespace(ctx context.Context, cmd *cobra.Command, svc service.AliasService, namespace string) error {
+	logger := logging.FromContext(ctx)
+	logger.Info("removing alias namespace", "namespace", namespace)
+
+	// The aliases listed for confirmation are removed only as they were shown
+	var confirmed []*domain.Alias
+	if !forceRemove {
+		aliases, err := svc.ListNamespace(ctx, namespace)
+		if err != nil {
+			logger.Error("failed to list aliases", "error", err)
+			return fmt.Errorf("failed to list aliases: %w", err)
+		}
+		if len(aliases) == 0 {
+			return fmt.Errorf("no aliases found in namespace '%s'", namespace)
+		}
+
+		for _, alias := range aliases {
+			fmt.Fprintf(cmd.OutOrStdout(), "Alias: %s\n", alias.Name)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "\n")
+
+		prompt := fmt.Sprintf("Are you sure you want to remove %d aliases in namespace '%s'?", len(aliases), namespace)
+		if !confirm(cmd, os.Stdin, prompt) {
+			logger.Info("namespace removal cancelled by user", "namespace", namespace)
+			fmt.Fprintln(cmd.OutOrStdout(), "Removal cancelled")
+			return nil
+		}
+		confirmed = aliases
+	}
+
+	removed, err := svc.DeleteNamespace(ctx, namespace, confirmed)
+	if err != nil {
+		logger.Error("failed to delete aliases", "error", err, "removed", removed)
+		if confirmed != nil && changedMeanwhile(err) {
+			return fmt.Errorf("failed to delete aliases: %w while you were confirming; nothing was removed",
+				domain.ErrConflict)
+		}
+		return fmt.Errorf("failed to delete aliases: %w", err)
+	}
+
+	logger.Info("alias namespace removed successfully", "namespace", namespace, "count", len(removed))
+	fmt.Fprintf(cmd.OutOrStdout(), "Removed %d aliases from namespace '%s'\n", len(removed), namespace)
+	return nil
+}
+
 func confirmDelete(cmd *cobra.Command, in io.Reader, aliasName string) bool {
+	return confirm(cmd, in, fmt.Sprintf("Are you sure you want to remove alias '%s'?", aliasName))
+}
+
+// confirm asks a yes/no question and reports whether the user answered yes.
+func confirm(cmd *cobra.Command, in io.Reader, prompt string) bool {
 	reader := bufio.NewReader(in)
-	fmt.Fprintf(cmd.OutOrStdout(), "Are you sure you want to remove alias '%s'? (y/N): ", aliasName)
+	fmt.Fprintf(cmd.OutOrStdout(), "%s (y/N): ", prompt)
 
 	response, err := reader.ReadString('\n')
 	if err != nil {
@@ -76,5 +157,6 @@ func confirmDelete(cmd *cobra.Command, in io.Reader, aliasName string) bool {
 
 func init() {
 	removeAliasCmd.Flags().BoolVarP(&forceRemove, "force", "f", false, "Skip confirmation prompt")
+	removeAliasCmd.Flags().BoolVarP(&recursiveRemove, "recursive", "r", false, "Remove every alias in the given namespace")
 	aliasCmd.AddCommand(removeAliasCmd)
 }
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/internal/logging"
+	"github.com/spf13/cobra"
+)
+
+var forceRename bool
+
+var renameAliasCmd = &cobra.Command{
+	Use:   "rename [old-name] [new-name]",
+	Short: "Rename an alias",
+	Long: `Give an existing alias a new name, keeping its command, description and
+creation date. Fails if the new name is taken unless --force flag is used.`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeAliasNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		application, err := appFactory(cmd.Context(), GetConfigFile())
+		if err != nil {
+			return err
+		}
+
+		ctx := logging.WithLogger(cmd.Context(), application.Logger)
+		oldName, newName := args[0], args[1]
+
+		application.Logger.Info("renaming alias", "from", oldName, "to", newName)
+
+		if err := application.AliasService.RenameAlias(ctx, oldName, newName, forceRename); err != nil {
+			application.Logger.Error("failed to rename alias", "error", err)
+			switch {
+			case errors.Is(err, domain.ErrAliasExists):
+				return fmt.Errorf("failed to rename alias: alias '%s' already exists. Use --force to overwrite it: %w", newName, err)
+			case errors.Is(err, domain.ErrAliasNotFound):
+				return fmt.Errorf("failed to rename alias: %w.%s", err, didYouMean(ctx, application.AliasService, oldName))
+			}
+			return fmt.Errorf("failed to rename alias: %w", err)
+		}
+
+		application.Logger.Info("alias renamed successfully", "from", oldName, "to", newName)
+		fmt.Fprintf(cmd.OutOrStdout(), "Alias '%s' renamed to '%s'\n", oldName, newName)
+		return nil
+	},
+}
+
+func init() {
+	renameAliasCmd.Flags().BoolVarP(&forceRename, "force", "f", false, "Overwrite an existing alias with the new name")
+	aliasCmd.AddCommand(renameAliasCmd)
+}
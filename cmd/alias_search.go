@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	stdjson "encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/internal/logging"
+	"github.com/msaglietto/mantrid/service"
+	"github.com/spf13/cobra"
+)
+
+var (
+	searchRegex  bool
+	searchFields []string
+)
+
+// Terminal escape sequences used to highlight matches.
+const (
+	highlightStart = "\x1b[1;33m"
+	highlightEnd   = "\x1b[0m"
+)
+
+var searchAliasCmd = &cobra.Command{
+	Use:   "search [query]",
+	Short: "Search aliases",
+	Long: `Search alias names, commands and descriptions, most relevant first.
+
+The query is matched fuzzily: its characters must appear in order, and
+contiguous matches at the start of a word rank highest. A match in the name
+ranks above one in the description, which ranks above one in the command.
+Aliases with equal relevance are ordered by most recent update.
+
+Examples:
+  mantrid alias search deploy
+  mantrid alias search kgp --field name
+  mantrid alias search --regex 'kubectl (get|describe)' --field command`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		application, err := appFactory(cmd.Context(), GetConfigFile())
+		if err != nil {
+			return err
+		}
+
+		ctx := logging.WithLogger(cmd.Context(), application.Logger)
+		query := args[0]
+
+		application.Logger.Info("searching aliases", "query", query, "regex", searchRegex, "fields", searchFields)
+
+		results, err := application.AliasService.SearchAliases(ctx, query, service.SearchOptions{
+			Regex:  searchRegex,
+			Fields: searchFields,
+		})
+		if err != nil {
+			application.Logger.Error("failed to search aliases", "error", err)
+			return fmt.Errorf("failed to search aliases: %w", err)
+		}
+
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+		if jsonOutput {
+			return writeSearchJSON(cmd.OutOrStdout(), results)
+		}
+
+		if len(results) == 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "No aliases match '%s'\n", query)
+			return nil
+		}
+
+		highlight := isTerminal(cmd.OutOrStdout()) && os.Getenv("NO_COLOR") == ""
+		return writeSearchTable(cmd.OutOrStdout(), results, highlight)
+	},
+}
+
+// searchResultJSON is the JSON form of a search result: the alias fields
+// followed by its score and matched ranges.
+type searchResultJSON struct {
+	*domain.Alias
+	Score   int                       `json:"score"`
+	Matches map[string][]service.Span `json:"matches"`
+}
+
+func writeSearchJSON(w io.Writer, results []service.SearchResult) error {
+	out := make([]searchResultJSON, len(results))
+	for i, r := range results {
+		out[i] = searchResultJSON{Alias: r.Alias, Score: r.Score, Matches: r.Matches}
+	}
+
+	data, err := stdjson.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal search results to JSON: %w", err)
+	}
+	fmt.Fprintln(w, string(data))
+	return nil
+}
+
+// writeSearchTable prints results as an aligned table. Columns are padded
+// on the plain text so that highlighting does not break the alignment.
+func writeSearchTable(w io.Writer, results []service.SearchResult, highlight bool) error {
+	columns := []struct {
+		header string
+		field  string
+	}{
+		{"NAME", service.FieldName},
+		{"COMMAND", service.FieldCommand},
+		{"DESCRIPTION", service.FieldDescription},
+	}
+
+	widths := make([]int, len(columns))
+	for i, c := range columns {
+		widths[i] = len(c.header)
+		for _, r := range results {
+			widths[i] = max(widths[i], utf8.RuneCountInString(service.FieldValue(r.Alias, c.field)))
+		}
+	}
+
+	var b strings.Builder
+	for i, c := range columns {
+		b.WriteString(pad(c.header, widths[i]))
+	}
+	b.WriteString("\n")
+	for i, c := range columns {
+		b.WriteString(pad(strings.Repeat("-", len(c.header)), widths[i]))
+	}
+	b.WriteString("\n")
+
+	for _, r := range results {
+		for i, c := range columns {
+			text := service.FieldValue(r.Alias, c.field)
+			padding := strings.Repeat(" ", widths[i]-utf8.RuneCountInString(text)+2)
+			if highlight {
+				text = highlightSpans(text, r.Matches[c.field])
+			}
+			b.WriteString(text + padding)
+		}
+		b.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// pad left-aligns text in a column of the given width plus the gap between
+// columns.
+func pad(text string, width int) string {
+	return text + strings.Repeat(" ", width-utf8.RuneCountInString(text)+2)
+}
+
+// highlightSpans wraps the matched ranges of text in terminal highlighting.
+func highlightSpans(text string, spans []service.Span) string {
+	var b strings.Builder
+	last := 0
+	for _, s := range spans {
+		b.WriteString(text[last:s.Start])
+		b.WriteString(highlightStart + text[s.Start:s.End] + highlightEnd)
+		last = s.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// isTerminal reports whether w is an interactive terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func init() {
+	aliasCmd.AddCommand(searchAliasCmd)
+	searchAliasCmd.Flags().Bool("json", false, "Output results in JSON format")
+	searchAliasCmd.Flags().BoolVar(&searchRegex, "regex", false, "Treat the query as a regular expression")
+	searchAliasCmd.Flags().StringSliceVar(&searchFields, "field", nil, "Restrict the search to fields: name, command, description")
+}
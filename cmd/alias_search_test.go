@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteSearchTable(t *testing.T) {
+	results := []service.SearchResult{
+		{
+			Alias:   &domain.Alias{Name: "kgp", Command: "kubectl get pods"},
+			Matches: map[string][]service.Span{service.FieldCommand: {{Start: 8, End: 11}}},
+		},
+		{
+			Alias:   &domain.Alias{Name: "deploy", Command: "kubectl apply", Description: "Deploy"},
+			Matches: map[string][]service.Span{service.FieldName: {{Start: 0, End: 3}}},
+		},
+	}
+
+	t.Run("highlighting keeps columns aligned", func(t *testing.T) {
+		var plain, highlighted bytes.Buffer
+		assert.NoError(t, writeSearchTable(&plain, results, false))
+		assert.NoError(t, writeSearchTable(&highlighted, results, true))
+
+		stripped := strings.NewReplacer(highlightStart, "", highlightEnd, "").Replace(highlighted.String())
+		assert.Equal(t, plain.String(), stripped)
+		assert.Contains(t, highlighted.String(), "kubectl "+highlightStart+"get"+highlightEnd+" pods")
+		assert.Contains(t, highlighted.String(), highlightStart+"dep"+highlightEnd+"loy")
+	})
+
+	t.Run("plain output has no escapes", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, writeSearchTable(&buf, results, false))
+		assert.NotContains(t, buf.String(), "\x1b")
+
+		lines := strings.Split(buf.String(), "\n")
+		assert.Equal(t, strings.Index(lines[0], "COMMAND"), strings.Index(lines[2], "kubectl"))
+	})
+}
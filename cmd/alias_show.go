@@ -0,0 +1,251 @@
+package cmd
+
+import (
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/internal/logging"
+	"github.com/spf13/cobra"
+)
+
+var showFormat string
+
+// showWrapWidth is the column at which long descriptions are wrapped.
+const showWrapWidth = 72
+
+var showAliasCmd = &cobra.Command{
+	Use:   "show [name]",
+	Short: "Show the details of an alias",
+	Long: `Display everything about one alias: its full command, description,
+timestamps, the placeholders it uses and how to call it.
+
+Use --json for machine-readable output, or --format with a Go template.
+The template fields are .Name, .Command, .Description, .Completion,
+.WorkDir, .Source, .CreatedAt, .UpdatedAt, .Revision, .Placeholders,
+.Usage and .Layer, and the function join is available, e.g.:
+
+  mantrid alias show deploy --format '{{.Usage}}'
+  mantrid alias show deploy --format '{{.Name}}: {{join .Placeholders " "}}'`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeAliasNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		application, err := appFactory(cmd.Context(), GetConfigFile())
+		if err != nil {
+			return err
+		}
+
+		ctx := logging.WithLogger(cmd.Context(), application.Logger)
+		name := args[0]
+
+		application.Logger.Info("showing alias", "name", name)
+
+		alias, err := application.AliasService.GetAlias(ctx, name)
+		if err != nil {
+			application.Logger.Error("failed to get alias", "error", err)
+			if errors.Is(err, domain.ErrAliasNotFound) {
+				return fmt.Errorf("failed to get alias: %w.%s", err, didYouMean(ctx, application.AliasService, name))
+			}
+			return fmt.Errorf("failed to get alias: %w", err)
+		}
+
+		details := newAliasDetails(alias)
+
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+		switch {
+		case jsonOutput:
+			enc := stdjson.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			// Keep the <n> of the usage line readable
+			enc.SetEscapeHTML(false)
+			if err := enc.Encode(details); err != nil {
+				application.Logger.Error("failed to marshal alias to JSON", "error", err)
+				return fmt.Errorf("failed to marshal alias to JSON: %w", err)
+			}
+			return nil
+		case showFormat != "":
+			return writeAliasTemplate(cmd.OutOrStdout(), showFormat, details)
+		default:
+			return writeAliasDetails(cmd.OutOrStdout(), details)
+		}
+	},
+}
+
+// aliasDetails is an alias together with what can be derived from its command.
+type aliasDetails struct {
+	*domain.Alias
+	Placeholders []string `json:"placeholders"`
+	Usage        string   `json:"usage"`
+	// Layer names the alias file the alias comes from, when project alias
+	// files are layered over the global store.
+	Layer string `json:"layer,omitempty"`
+}
+
+func newAliasDetails(alias *domain.Alias) aliasDetails {
+	details := aliasDetails{
+		Alias:        alias,
+		Placeholders: detectPlaceholders(alias.Command),
+		Usage:        aliasUsage(alias),
+	}
+	if alias.Layer != nil {
+		details.Layer = alias.Layer.Name
+	}
+	return details
+}
+
+// detectPlaceholders returns the distinct placeholders in command, with
+// positional ones in numeric order followed by $@ and $*.
+func detectPlaceholders(command string) []string {
+	seen := map[string]bool{}
+	var positional []int
+	var rest []string
+
+	for _, m := range placeholderRe.FindAllStringSubmatch(command, -1) {
+		if seen[m[0]] {
+			continue
+		}
+		seen[m[0]] = true
+
+		if m[1] != "" {
+			n, _ := strconv.Atoi(m[1])
+			positional = append(positional, n)
+		} else {
+			rest = append(rest, m[0])
+		}
+	}
+
+	sort.Ints(positional)
+	sort.Strings(rest)
+
+	result := make([]string, 0, len(positional)+len(rest))
+	for _, n := range positional {
+		result = append(result, fmt.Sprintf("$%d", n))
+	}
+	return append(result, rest...)
+}
+
+// aliasUsage builds a synopsis such as "mantrid do deploy <1> <2> [rest...]".
+// Every positional placeholder up to the highest one is an argument; trailing
+// arguments are accepted when the command uses $@ or $*, or has no
+// placeholders at all and gets its parameters appended.
+func aliasUsage(alias *domain.Alias) string {
+	matches := placeholderRe.FindAllStringSubmatch(alias.Command, -1)
+
+	highest := 0
+	takesRest := len(matches) == 0
+	for _, m := range matches {
+		if m[1] != "" {
+			n, _ := strconv.Atoi(m[1])
+			highest = max(highest, n)
+		} else {
+			takesRest = true
+		}
+	}
+
+	parts := []string{"mantrid", "do", alias.Name}
+	for i := 1; i <= highest; i++ {
+		parts = append(parts, fmt.Sprintf("<%d>", i))
+	}
+	if takesRest {
+		parts = append(parts, "[rest...]")
+	}
+	return strings.Join(parts, " ")
+}
+
+// writeAliasDetails prints details as labelled lines, wrapping the
+// description. The command is printed as is, one line per line of it, so
+// that its spacing is not lost.
+func writeAliasDetails(out io.Writer, details aliasDetails) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(w, "Name:\t%s\n", details.Name)
+	if details.Description != "" {
+		writeLabelled(w, "Description:", wrapWords(details.Description, showWrapWidth))
+	}
+	writeLabelled(w, "Command:", strings.Split(details.Command, "\n"))
+
+	placeholders := "none"
+	if len(details.Placeholders) > 0 {
+		placeholders = strings.Join(details.Placeholders, ", ")
+	}
+	fmt.Fprintf(w, "Placeholders:\t%s\n", placeholders)
+	fmt.Fprintf(w, "Usage:\t%s\n", details.Usage)
+	if details.Completion != "" {
+		fmt.Fprintf(w, "Completion:\t%s\n", details.Completion)
+	}
+	if details.WorkDir != "" {
+		fmt.Fprintf(w, "Directory:\t%s\n", details.WorkDir)
+	}
+	if details.Source != "" {
+		fmt.Fprintf(w, "Imported from:\t%s\n", details.Source)
+	}
+	if details.Layer != "" {
+		fmt.Fprintf(w, "Layer:\t%s\n", details.Layer)
+	}
+	fmt.Fprintf(w, "Created:\t%s\n", formatTime(details.CreatedAt))
+	fmt.Fprintf(w, "Updated:\t%s\n", formatTime(details.UpdatedAt))
+
+	return w.Flush()
+}
+
+// writeLabelled prints lines to w, the first one after label.
+func writeLabelled(w io.Writer, label string, lines []string) {
+	for i, line := range lines {
+		if i > 0 {
+			label = ""
+		}
+		fmt.Fprintf(w, "%s\t%s\n", label, line)
+	}
+}
+
+// writeAliasTemplate renders details with a user-supplied Go template.
+func writeAliasTemplate(out io.Writer, format string, details aliasDetails) error {
+	tmpl, err := template.New("alias").Funcs(template.FuncMap{
+		"join": strings.Join,
+	}).Parse(format)
+	if err != nil {
+		return fmt.Errorf("invalid format template: %w", err)
+	}
+
+	if err := tmpl.Execute(out, details); err != nil {
+		return fmt.Errorf("failed to render format template: %w", err)
+	}
+	fmt.Fprintln(out)
+	return nil
+}
+
+// wrapWords splits text into lines of at most width characters, breaking
+// at spaces. Existing line breaks are kept and words longer than width get
+// a line of their own.
+func wrapWords(text string, width int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			switch {
+			case line == "":
+				line = word
+			case len(line)+1+len(word) > width:
+				lines = append(lines, line)
+				line = word
+			default:
+				line += " " + word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func init() {
+	aliasCmd.AddCommand(showAliasCmd)
+	showAliasCmd.Flags().Bool("json", false, "Output the alias in JSON format")
+	showAliasCmd.Flags().StringVar(&showFormat, "format", "", "Format the output using a Go template")
+}
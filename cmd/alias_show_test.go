@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAliasUsage(t *testing.T) {
+	tests := []struct {
+		name                 string
+		command              string
+		expectedPlaceholders []string
+		expectedUsage        string
+	}{
+		{
+			name:                 "no placeholders appends params",
+			command:              "kubectl",
+			expectedPlaceholders: []string{},
+			expectedUsage:        "mantrid do x [rest...]",
+		},
+		{
+			name:                 "positional only",
+			command:              "kubectl apply -f $1 -n $2",
+			expectedPlaceholders: []string{"$1", "$2"},
+			expectedUsage:        "mantrid do x <1> <2>",
+		},
+		{
+			name:                 "positional and all",
+			command:              "docker run $2 $1 $@",
+			expectedPlaceholders: []string{"$1", "$2", "$@"},
+			expectedUsage:        "mantrid do x <1> <2> [rest...]",
+		},
+		{
+			name:                 "gap in positional",
+			command:              "echo $3 $3",
+			expectedPlaceholders: []string{"$3"},
+			expectedUsage:        "mantrid do x <1> <2> <3>",
+		},
+		{
+			name:                 "all with star",
+			command:              "echo $*",
+			expectedPlaceholders: []string{"$*"},
+			expectedUsage:        "mantrid do x [rest...]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alias := &domain.Alias{Name: "x", Command: tt.command}
+			assert.Equal(t, tt.expectedPlaceholders, detectPlaceholders(tt.command))
+			assert.Equal(t, tt.expectedUsage, aliasUsage(alias))
+		})
+	}
+}
+
+func TestWrapWords(t *testing.T) {
+	long := "kubectl get pods --namespace production --selector app=web --output wide --watch"
+	lines := wrapWords(long, 40)
+	assert.Greater(t, len(lines), 1)
+	for _, line := range lines {
+		assert.LessOrEqual(t, len(line), 40)
+	}
+	assert.Equal(t, long, strings.Join(lines, " "))
+
+	assert.Equal(t, []string{"echo a", "echo b"}, wrapWords("echo a\necho b", 40))
+}
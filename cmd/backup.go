@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/internal/app"
+	"github.com/msaglietto/mantrid/internal/backup"
+	"github.com/msaglietto/mantrid/internal/logging"
+	"github.com/msaglietto/mantrid/repository"
+	"github.com/spf13/cobra"
+)
+
+var backupOutput string
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Manage snapshots of the alias file",
+	Long: `Before each change, a snapshot of the alias file is kept in the backups
+directory next to it. backup_count and backup_max_age in the config file set
+how many snapshots are kept and for how long.`,
+}
+
+var backupListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the snapshots of the alias file",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, snapshots, err := backupApp(cmd)
+		if err != nil {
+			return err
+		}
+
+		list, err := snapshots.List()
+		if err != nil {
+			return fmt.Errorf("failed to list snapshots: %w", err)
+		}
+		if len(list) == 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "No snapshots in %s\n", snapshots.Dir())
+			return nil
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SNAPSHOT\tCREATED\tSIZE\t")
+		fmt.Fprintln(w, "--------\t-------\t----\t")
+		for _, s := range list {
+			fmt.Fprintf(w, "%s\t%s\t%d\t\n", s.ID, formatTime(s.Created.Local()), s.Size)
+		}
+		return w.Flush()
+	},
+}
+
+var backupDiffCmd = &cobra.Command{
+	Use:   "diff <snapshot>",
+	Short: "Show how the aliases changed since a snapshot",
+	Long: `Show the aliases added (+), removed (-) and changed (~) since a snapshot,
+given by the ID shown by "mantrid backup list" or as "latest".`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		application, snapshots, err := backupApp(cmd)
+		if err != nil {
+			return err
+		}
+
+		ctx := logging.WithLogger(cmd.Context(), application.Logger)
+		snapshot, old, err := readSnapshot(cmd, application, snapshots, args[0])
+		if err != nil {
+			return err
+		}
+		store, err := app.OpenStore(application.Config, application.FileManager, application.Trust, application.Config.StorageType)
+		if err != nil {
+			return err
+		}
+		defer repository.Close(store)
+		current, err := store.List(ctx)
+		if err != nil {
+			application.Logger.Error("failed to read aliases", "error", err)
+			return fmt.Errorf("failed to read aliases: %w", err)
+		}
+
+		if !writeAliasDiff(cmd.OutOrStdout(), old, current) {
+			fmt.Fprintf(cmd.OutOrStdout(), "No changes since snapshot %s\n", snapshot.ID)
+		}
+		return nil
+	},
+}
+
+var backupRestoreCmd = &cobra.Command{
+	Use:   "restore <snapshot>",
+	Short: "Restore the aliases of a snapshot",
+	Long: `Replace the aliases with the ones in a snapshot, given by the ID shown by
+"mantrid backup list" or as "latest". The restore waits for other mantrid
+processes changing the aliases, and the aliases it replaces are kept in a
+new snapshot.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		application, snapshots, err := backupApp(cmd)
+		if err != nil {
+			return err
+		}
+
+		ctx := logging.WithLogger(cmd.Context(), application.Logger)
+		snapshot, aliases, err := readSnapshot(cmd, application, snapshots, args[0])
+		if err != nil {
+			return err
+		}
+		store, err := app.OpenStore(application.Config, application.FileManager, application.Trust, application.Config.StorageType)
+		if err != nil {
+			return err
+		}
+		defer repository.Close(store)
+		if err := store.Replace(ctx, aliases); err != nil {
+			application.Logger.Error("failed to restore aliases", "snapshot", snapshot.ID, "error", err)
+			return fmt.Errorf("failed to restore aliases: %w", err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Restored %d aliases from snapshot %s\n", len(aliases), snapshot.ID)
+		return nil
+	},
+}
+
+var backupCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Take a snapshot of the alias file",
+	Long: `Take a snapshot of the alias file now, or copy it to another file with
+--output. Encrypted alias files stay encrypted in the copy.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		application, snapshots, err := backupApp(cmd)
+		if err != nil {
+			return err
+		}
+
+		if backupOutput != "" {
+			if err := snapshots.CopyTo(backupOutput, application.Config.LockTimeout); err != nil {
+				application.Logger.Error("failed to back up aliases", "output", backupOutput, "error", err)
+				return fmt.Errorf("failed to back up aliases: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Backed up aliases to %s\n", backupOutput)
+			return nil
+		}
+
+		snapshot, err := snapshots.Create(application.Config.LockTimeout)
+		if err != nil {
+			application.Logger.Error("failed to take snapshot", "error", err)
+			return fmt.Errorf("failed to take snapshot: %w", err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Created snapshot %s\n", snapshot.ID)
+		return nil
+	},
+}
+
+// backupApp creates the application for a backup command and returns it
+// with the snapshots of the alias file, failing for storage that is not a
+// single file.
+func backupApp(cmd *cobra.Command) (*app.App, *backup.Snapshots, error) {
+	application, err := appFactory(cmd.Context(), GetConfigFile())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cfg := application.Config
+	snapshots := app.Snapshots(cfg, application.FileManager, cfg.StorageType)
+	if snapshots == nil {
+		return nil, nil, fmt.Errorf("backups need json, yaml or toml storage, not %s", cfg.StorageType)
+	}
+	return application, snapshots, nil
+}
+
+// readSnapshot finds the snapshot ref and reads its aliases.
+func readSnapshot(cmd *cobra.Command, application *app.App, snapshots *backup.Snapshots, ref string) (*backup.Snapshot, []*domain.Alias, error) {
+	snapshot, err := snapshots.Find(ref)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	repo := app.SnapshotRepository(application.Config, application.Config.StorageType, snapshot.Path)
+	aliases, err := repo.List(cmd.Context())
+	if err != nil {
+		application.Logger.Error("failed to read snapshot", "path", snapshot.Path, "error", err)
+		return nil, nil, fmt.Errorf("failed to read snapshot %s: %w", snapshot.ID, err)
+	}
+	return snapshot, aliases, nil
+}
+
+// writeAliasDiff writes the aliases added, removed and changed from old to
+// current to w, sorted by name, and reports whether there were any.
+func writeAliasDiff(w io.Writer, old, current []*domain.Alias) bool {
+	before := make(map[string]*domain.Alias, len(old))
+	for _, a := range old {
+		before[a.Name] = a
+	}
+	after := make(map[string]*domain.Alias, len(current))
+	for _, a := range current {
+		after[a.Name] = a
+	}
+
+	all := append(append([]*domain.Alias{}, old...), current...)
+	sortAliases(all)
+
+	changed := false
+	for i, a := range all {
+		if i > 0 && all[i-1].Name == a.Name {
+			continue
+		}
+		was, is := before[a.Name], after[a.Name]
+		switch {
+		case was == nil:
+			fmt.Fprintf(w, "+ %s: %s\n", a.Name, is.Command)
+		case is == nil:
+			fmt.Fprintf(w, "- %s: %s\n", a.Name, was.Command)
+		default:
+			fields := changedFields(was, is)
+			if len(fields) == 0 {
+				continue
+			}
+			fmt.Fprintf(w, "~ %s\n", a.Name)
+			for _, f := range fields {
+				fmt.Fprintf(w, "    %s: %q -> %q\n", f.name, f.old, f.new)
+			}
+		}
+		changed = true
+	}
+	return changed
+}
+
+// fieldChange is a field of an alias that differs between two versions.
+type fieldChange struct {
+	name, old, new string
+}
+
+// changedFields returns the stored fields that differ between a and b.
+func changedFields(a, b *domain.Alias) []fieldChange {
+	var changes []fieldChange
+	for _, f := range []fieldChange{
+		{"command", a.Command, b.Command},
+		{"description", a.Description, b.Description},
+		{"completion", a.Completion, b.Completion},
+		{"workdir", a.WorkDir, b.WorkDir},
+		{"source", a.Source, b.Source},
+	} {
+		if f.old != f.new {
+			changes = append(changes, f)
+		}
+	}
+	return changes
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+	backupCmd.AddCommand(backupListCmd)
+	backupCmd.AddCommand(backupDiffCmd)
+	backupCmd.AddCommand(backupRestoreCmd)
+	backupCmd.AddCommand(backupCreateCmd)
+	backupCreateCmd.Flags().StringVarP(&backupOutput, "output", "o", "", "File to copy the alias file to, instead of a snapshot")
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/msaglietto/mantrid/internal/app"
+	"github.com/msaglietto/mantrid/internal/paths"
+	"github.com/msaglietto/mantrid/internal/trust"
+	"github.com/msaglietto/mantrid/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupCommands(t *testing.T) {
+	application := setupTestApp(t)
+	dir := t.TempDir()
+	application.Config.StorageType = "json"
+	application.Config.AliasFile = filepath.Join(dir, "aliases.json")
+	application.Config.BackupCount = 5
+	application.FileManager = paths.NewFileManager(application.Config)
+	db, err := trust.Open(filepath.Join(dir, "trusted.json"))
+	require.NoError(t, err)
+	application.Trust = db
+	store, err := app.OpenStore(application.Config, application.FileManager, db, "json")
+	require.NoError(t, err)
+	application.AliasService = service.NewAliasService(store)
+
+	output, err := runCommand(t, "backup", "list")
+	require.NoError(t, err)
+	assert.Equal(t, "No snapshots in "+filepath.Join(dir, "backups"), output)
+
+	_, err = runCommand(t, "alias", "add", "deploy", "make deploy")
+	require.NoError(t, err)
+	_, err = runCommand(t, "alias", "add", "gs", "git status")
+	require.NoError(t, err)
+
+	// The snapshot taken before adding gs holds deploy only
+	output, err = runCommand(t, "backup", "list")
+	require.NoError(t, err)
+	assert.Contains(t, output, "SNAPSHOT")
+	snapshots, err := app.Snapshots(application.Config, application.FileManager, "json").List()
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+	assert.Contains(t, output, snapshots[0].ID)
+
+	_, err = runCommand(t, "alias", "edit", "deploy", "make deploy ENV=prod")
+	require.NoError(t, err)
+
+	output, err = runCommand(t, "backup", "diff", snapshots[0].ID)
+	require.NoError(t, err)
+	assert.Contains(t, output, "+ gs: git status")
+	assert.Contains(t, output, "~ deploy\n    command: \"make deploy\" -> \"make deploy ENV=prod\"")
+
+	output, err = runCommand(t, "backup", "restore", snapshots[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Restored 1 aliases from snapshot "+snapshots[0].ID, output)
+	output, err = runCommand(t, "alias", "list")
+	require.NoError(t, err)
+	assert.NotContains(t, output, "git status")
+
+	// The aliases replaced by the restore are in the latest snapshot
+	output, err = runCommand(t, "backup", "diff", "latest")
+	require.NoError(t, err)
+	assert.Contains(t, output, "- gs: git status")
+
+	output, err = runCommand(t, "backup", "diff", snapshots[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "No changes since snapshot "+snapshots[0].ID, output)
+
+	output, err = runCommand(t, "backup", "create")
+	require.NoError(t, err)
+	assert.Contains(t, output, "Created snapshot ")
+
+	copyFile := filepath.Join(dir, "copy.json")
+	output, err = runCommand(t, "backup", "create", "--output", copyFile)
+	require.NoError(t, err)
+	assert.Equal(t, "Backed up aliases to "+copyFile, output)
+	data, err := os.ReadFile(copyFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "make deploy")
+
+	_, err = runCommand(t, "backup", "restore", "20000101T000000.000000Z")
+	assert.ErrorContains(t, err, "snapshot not found")
+
+	application.Config.StorageType = "sqlite"
+	_, err = runCommand(t, "backup", "list")
+	assert.ErrorContains(t, err, "backups need json, yaml or toml storage")
+}
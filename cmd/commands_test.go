@@ -9,9 +9,11 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/msaglietto/mantrid/domain"
 	"github.com/msaglietto/mantrid/internal/app"
 	"github.com/msaglietto/mantrid/internal/config"
 	"github.com/msaglietto/mantrid/internal/logging"
+	"github.com/msaglietto/mantrid/internal/portable"
 	"github.com/msaglietto/mantrid/repository/memory"
 	"github.com/msaglietto/mantrid/service"
 	"github.com/stretchr/testify/assert"
@@ -25,18 +27,20 @@ func setupTestApp(t *testing.T) *app.App {
 	logger := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelDebug}))
 
 	cfg := &config.Config{
-		StorageType: "memory",
-		LogLevel:    "debug",
-		LogFormat:   "text",
+		StorageType:        "memory",
+		NamespaceSeparator: "/",
+		LogLevel:           "debug",
+		LogFormat:          "text",
 	}
 
 	memRepo := memory.NewAliasRepository()
 	svc := service.NewAliasService(memRepo)
 
 	application := &app.App{
-		Config:       cfg,
-		Logger:       logger,
-		AliasService: svc,
+		Config:        cfg,
+		Logger:        logger,
+		AliasService:  svc,
+		GlobalAliases: svc,
 	}
 
 	originalFactory := appFactory
@@ -61,6 +65,56 @@ func runCommand(t *testing.T, args ...string) (string, error) {
 	cfgFile = ""
 	forceRemove = false
 	removeAliasCmd.Flags().Set("force", "false")
+	aliasDescription = ""
+	aliasCompletion = ""
+	aliasLocal = false
+	addAliasCmd.Flags().Set("local", "false")
+	recursiveRemove = false
+	removeAliasCmd.Flags().Set("recursive", "false")
+	forceMove = false
+	mvAliasCmd.Flags().Set("force", "false")
+	listAliasCmd.Flags().Set("json", "false")
+	forceRename = false
+	renameAliasCmd.Flags().Set("force", "false")
+	forceCopy = false
+	copyAliasCmd.Flags().Set("force", "false")
+	searchRegex = false
+	searchFields = nil
+	searchAliasCmd.Flags().Set("regex", "false")
+	searchAliasCmd.Flags().Set("json", "false")
+	editAll = false
+	editAliasCmd.Flags().Set("all", "false")
+	showFormat = ""
+	showAliasCmd.Flags().Set("json", "false")
+	shellFunctionsOnly = false
+	shellOutput = ""
+	shellInitCmd.Flags().Set("functions", "false")
+	importFrom = ""
+	importRenameInvalid = false
+	importDryRun = false
+	importStrategy = string(service.ConflictSkip)
+	importPrefix = ""
+	exportTo = ""
+	exportFormat = portable.JSON
+	exportMatch = ""
+	exportAliasCmd.Flags().Lookup("to").Changed = false
+	exportAliasCmd.Flags().Lookup("format").Changed = false
+	exportOutput = ""
+	storeLogLimit = 20
+	encryptKeyFile = ""
+	rekeyKeyFile = ""
+	backupOutput = ""
+	forceRepair = false
+	storeRepairCmd.Flags().Set("force", "false")
+	migrateTo = ""
+	migrateFrom = ""
+	migrateForce = false
+	migrateDecrypt = false
+	storeMigrateCmd.Flags().Set("force", "false")
+	storeMigrateCmd.Flags().Set("decrypt", "false")
+	storeMigrateCmd.Flags().Lookup("to").Changed = false
+	importAliasCmd.Flags().Set("rename-invalid", "false")
+	importAliasCmd.Flags().Set("dry-run", "false")
 
 	buf := new(bytes.Buffer)
 	rootCmd.SetOut(buf)
@@ -81,6 +135,17 @@ func TestAddAliasCommand(t *testing.T) {
 		assert.Contains(t, output, "Alias 'test' created successfully")
 	})
 
+	t.Run("add alias with description", func(t *testing.T) {
+		application := setupTestApp(t)
+
+		_, err := runCommand(t, "alias", "add", "k", "kubectl", "--description", "Run kubectl")
+		require.NoError(t, err)
+
+		alias, err := application.AliasService.GetAlias(context.Background(), "k")
+		require.NoError(t, err)
+		assert.Equal(t, "Run kubectl", alias.Description)
+	})
+
 	t.Run("add alias missing command", func(t *testing.T) {
 		setupTestApp(t)
 
@@ -153,6 +218,272 @@ func TestListAliasesCommand(t *testing.T) {
 	})
 }
 
+func TestAliasNamespaces(t *testing.T) {
+	setup := func(t *testing.T) *app.App {
+		application := setupTestApp(t)
+		ctx := context.Background()
+		application.AliasService.CreateAlias(ctx, "k8s/logs", "kubectl logs")
+		application.AliasService.CreateAlias(ctx, "k8s/prod/logs", "kubectl logs -n prod")
+		application.AliasService.CreateAlias(ctx, "git/st", "git status")
+		return application
+	}
+
+	t.Run("list namespace", func(t *testing.T) {
+		setup(t)
+
+		output, err := runCommand(t, "alias", "list", "k8s/prod/")
+		assert.NoError(t, err)
+		assert.Contains(t, output, "k8s/prod/logs")
+		assert.NotContains(t, output, "git/st")
+		assert.NotContains(t, output, "k8s/logs ")
+	})
+
+	t.Run("list empty namespace", func(t *testing.T) {
+		setup(t)
+
+		output, err := runCommand(t, "alias", "list", "aws/")
+		assert.NoError(t, err)
+		assert.Contains(t, output, "No aliases found in namespace 'aws/'")
+	})
+
+	t.Run("remove recursive", func(t *testing.T) {
+		application := setup(t)
+
+		output, err := runCommand(t, "alias", "remove", "--recursive", "--force", "k8s/")
+		assert.NoError(t, err)
+		assert.Contains(t, output, "Removed 2 aliases from namespace 'k8s/'")
+
+		aliases, _ := application.AliasService.ListAliases(context.Background())
+		assert.Len(t, aliases, 1)
+	})
+
+	t.Run("move namespace", func(t *testing.T) {
+		application := setup(t)
+
+		output, err := runCommand(t, "alias", "mv", "k8s/", "kube/")
+		assert.NoError(t, err)
+		assert.Contains(t, output, "k8s/prod/logs -> kube/prod/logs")
+		assert.Contains(t, output, "Moved 2 aliases from 'k8s/' to 'kube/'")
+
+		_, err = application.AliasService.GetAlias(context.Background(), "kube/prod/logs")
+		assert.NoError(t, err)
+	})
+
+	t.Run("move onto existing alias", func(t *testing.T) {
+		application := setup(t)
+		application.AliasService.CreateAlias(context.Background(), "git/logs", "git log")
+
+		_, err := runCommand(t, "alias", "mv", "k8s/", "git/")
+		assert.ErrorIs(t, err, domain.ErrAliasExists)
+
+		_, err = runCommand(t, "alias", "mv", "--force", "k8s/", "git/")
+		assert.NoError(t, err)
+	})
+}
+
+func TestRenameAliasCommand(t *testing.T) {
+	t.Run("rename keeps metadata", func(t *testing.T) {
+		application := setupTestApp(t)
+		ctx := context.Background()
+		application.AliasService.CreateAlias(ctx, "old", "echo old", domain.WithDescription("Old one"))
+		original, _ := application.AliasService.GetAlias(ctx, "old")
+
+		output, err := runCommand(t, "alias", "rename", "old", "new")
+		assert.NoError(t, err)
+		assert.Contains(t, output, "Alias 'old' renamed to 'new'")
+
+		renamed, err := application.AliasService.GetAlias(ctx, "new")
+		require.NoError(t, err)
+		assert.Equal(t, "echo old", renamed.Command)
+		assert.Equal(t, "Old one", renamed.Description)
+		assert.True(t, original.CreatedAt.Equal(renamed.CreatedAt))
+
+		_, err = application.AliasService.GetAlias(ctx, "old")
+		assert.ErrorIs(t, err, domain.ErrAliasNotFound)
+	})
+
+	t.Run("rename onto existing alias", func(t *testing.T) {
+		application := setupTestApp(t)
+		ctx := context.Background()
+		application.AliasService.CreateAlias(ctx, "old", "echo old")
+		application.AliasService.CreateAlias(ctx, "new", "echo new")
+
+		output, err := runCommand(t, "alias", "rename", "old", "new")
+		assert.ErrorIs(t, err, domain.ErrAliasExists)
+		assert.Contains(t, output, "Use --force to overwrite it")
+
+		_, err = runCommand(t, "alias", "rename", "old", "new", "--force")
+		assert.NoError(t, err)
+
+		renamed, _ := application.AliasService.GetAlias(ctx, "new")
+		assert.Equal(t, "echo old", renamed.Command)
+	})
+
+	t.Run("rename non-existent alias", func(t *testing.T) {
+		setupTestApp(t)
+
+		_, err := runCommand(t, "alias", "rename", "missing", "new")
+		assert.ErrorIs(t, err, domain.ErrAliasNotFound)
+	})
+}
+
+func TestCopyAliasCommand(t *testing.T) {
+	t.Run("copy alias", func(t *testing.T) {
+		application := setupTestApp(t)
+		ctx := context.Background()
+		application.AliasService.CreateAlias(ctx, "src", "echo src", domain.WithDescription("Source"))
+
+		output, err := runCommand(t, "alias", "copy", "src", "dst")
+		assert.NoError(t, err)
+		assert.Contains(t, output, "Alias 'src' copied to 'dst'")
+
+		copied, err := application.AliasService.GetAlias(ctx, "dst")
+		require.NoError(t, err)
+		assert.Equal(t, "echo src", copied.Command)
+		assert.Equal(t, "Source", copied.Description)
+
+		_, err = application.AliasService.GetAlias(ctx, "src")
+		assert.NoError(t, err)
+	})
+
+	t.Run("copy onto existing alias", func(t *testing.T) {
+		application := setupTestApp(t)
+		ctx := context.Background()
+		application.AliasService.CreateAlias(ctx, "src", "echo src")
+		application.AliasService.CreateAlias(ctx, "dst", "echo dst")
+
+		output, err := runCommand(t, "alias", "copy", "src", "dst")
+		assert.ErrorIs(t, err, domain.ErrAliasExists)
+		assert.Contains(t, output, "Use --force to overwrite it")
+
+		_, err = runCommand(t, "alias", "copy", "--force", "src", "dst")
+		assert.NoError(t, err)
+
+		copied, _ := application.AliasService.GetAlias(ctx, "dst")
+		assert.Equal(t, "echo src", copied.Command)
+	})
+}
+
+func TestSearchAliasCommand(t *testing.T) {
+	setup := func(t *testing.T) {
+		application := setupTestApp(t)
+		ctx := context.Background()
+		application.AliasService.CreateAlias(ctx, "kgp", "kubectl get pods")
+		application.AliasService.CreateAlias(ctx, "deploy", "kubectl apply -f $1", domain.WithDescription("Deploy a manifest"))
+		application.AliasService.CreateAlias(ctx, "gs", "git status")
+	}
+
+	t.Run("table output", func(t *testing.T) {
+		setup(t)
+
+		output, err := runCommand(t, "alias", "search", "kubectl")
+		assert.NoError(t, err)
+		assert.Contains(t, output, "NAME")
+		assert.Contains(t, output, "kgp")
+		assert.Contains(t, output, "Deploy a manifest")
+		assert.NotContains(t, output, "git status")
+	})
+
+	t.Run("json output", func(t *testing.T) {
+		setup(t)
+
+		output, err := runCommand(t, "alias", "search", "deploy", "--json", "--field", "name")
+		assert.NoError(t, err)
+		assert.Contains(t, output, `"name": "deploy"`)
+		assert.Contains(t, output, `"score"`)
+		assert.NotContains(t, output, `"kgp"`)
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		setup(t)
+
+		output, err := runCommand(t, "alias", "search", "terraform")
+		assert.NoError(t, err)
+		assert.Contains(t, output, "No aliases match 'terraform'")
+	})
+
+	t.Run("regex", func(t *testing.T) {
+		setup(t)
+
+		output, err := runCommand(t, "alias", "search", "--regex", "^git")
+		assert.NoError(t, err)
+		assert.Contains(t, output, "gs")
+		assert.NotContains(t, output, "kgp")
+	})
+}
+
+func TestShowAliasCommand(t *testing.T) {
+	setup := func(t *testing.T) {
+		application := setupTestApp(t)
+		application.AliasService.CreateAlias(context.Background(), "deploy", "kubectl apply -f $1 -n $2",
+			domain.WithDescription("Deploy a manifest"))
+	}
+
+	t.Run("detailed view", func(t *testing.T) {
+		setup(t)
+
+		output, err := runCommand(t, "alias", "show", "deploy")
+		assert.NoError(t, err)
+		assert.Regexp(t, `Name:\s+deploy`, output)
+		assert.Regexp(t, `Description:\s+Deploy a manifest`, output)
+		assert.Regexp(t, `Command:\s+kubectl apply -f \$1 -n \$2`, output)
+		assert.Regexp(t, `Placeholders:\s+\$1, \$2`, output)
+		assert.Regexp(t, `Usage:\s+mantrid do deploy <1> <2>`, output)
+		assert.Contains(t, output, "Created:")
+		assert.Contains(t, output, "Updated:")
+	})
+
+	t.Run("command printed verbatim", func(t *testing.T) {
+		application := setupTestApp(t)
+		command := "printf '%s\\t%s\\n'   a    b --" + strings.Repeat(" --verbose", 10)
+		application.AliasService.CreateAlias(context.Background(), "spaced", command)
+
+		output, err := runCommand(t, "alias", "show", "spaced")
+		assert.NoError(t, err)
+		assert.Contains(t, output, command+"\n")
+	})
+
+	t.Run("json output", func(t *testing.T) {
+		setup(t)
+
+		output, err := runCommand(t, "alias", "show", "deploy", "--json")
+		assert.NoError(t, err)
+		assert.Contains(t, output, `"description": "Deploy a manifest"`)
+		assert.Contains(t, output, `"placeholders": [`)
+		assert.Contains(t, output, `"usage": "mantrid do deploy <1> <2>"`)
+	})
+
+	t.Run("template output", func(t *testing.T) {
+		setup(t)
+
+		output, err := runCommand(t, "alias", "show", "deploy", "--format", `{{.Name}}: {{join .Placeholders " "}}`)
+		assert.NoError(t, err)
+		assert.Equal(t, "deploy: $1 $2", output)
+
+		application, _ := appFactory(context.Background(), "")
+		deploy, err := application.AliasService.GetAlias(context.Background(), "deploy")
+		require.NoError(t, err)
+		output, err = runCommand(t, "alias", "show", "deploy", "--format", `{{.WorkDir}}|{{.Revision}}`)
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("|%d", deploy.Revision), output)
+	})
+
+	t.Run("invalid template", func(t *testing.T) {
+		setup(t)
+
+		_, err := runCommand(t, "alias", "show", "deploy", "--format", `{{.Name`)
+		assert.Error(t, err)
+	})
+
+	t.Run("non-existent alias", func(t *testing.T) {
+		setup(t)
+
+		output, err := runCommand(t, "alias", "show", "deplyo")
+		assert.ErrorIs(t, err, domain.ErrAliasNotFound)
+		assert.Contains(t, output, "Did you mean 'deploy'?")
+	})
+}
+
 func TestEditAliasCommand(t *testing.T) {
 	t.Run("edit existing alias", func(t *testing.T) {
 		application := setupTestApp(t)
@@ -172,6 +503,16 @@ func TestEditAliasCommand(t *testing.T) {
 		assert.Contains(t, output, "failed to update alias")
 	})
 
+	t.Run("edit non-existent alias suggests similar names", func(t *testing.T) {
+		application := setupTestApp(t)
+		ctx := context.Background()
+		application.AliasService.CreateAlias(ctx, "status", "git status")
+
+		output, err := runCommand(t, "alias", "edit", "stauts", "echo test")
+		assert.Error(t, err)
+		assert.Contains(t, output, "Did you mean 'status'?")
+	})
+
 	t.Run("edit missing args", func(t *testing.T) {
 		setupTestApp(t)
 
@@ -198,6 +539,16 @@ func TestRemoveAliasCommand(t *testing.T) {
 		assert.Error(t, err)
 	})
 
+	t.Run("remove non-existent alias suggests similar names", func(t *testing.T) {
+		application := setupTestApp(t)
+		ctx := context.Background()
+		application.AliasService.CreateAlias(ctx, "status", "git status")
+
+		output, err := runCommand(t, "alias", "remove", "stat", "--force")
+		assert.Error(t, err)
+		assert.Contains(t, output, "Did you mean 'status'?")
+	})
+
 	t.Run("remove missing args", func(t *testing.T) {
 		setupTestApp(t)
 
@@ -215,6 +566,29 @@ func TestDoCommand(t *testing.T) {
 		assert.Contains(t, output, "not found")
 	})
 
+	t.Run("alias not found suggests similar names", func(t *testing.T) {
+		application := setupTestApp(t)
+		ctx := context.Background()
+		application.AliasService.CreateAlias(ctx, "deploy-prod", "echo prod")
+		application.AliasService.CreateAlias(ctx, "deploy-stg", "echo stg")
+
+		output, err := runCommand(t, "do", "deploy")
+		assert.Error(t, err)
+		assert.Contains(t, output, "Did you mean 'deploy-prod' or 'deploy-stg'?")
+	})
+
+	t.Run("ambiguous abbreviation", func(t *testing.T) {
+		application := setupTestApp(t)
+		application.Config.ResolvePrefix = true
+		ctx := context.Background()
+		application.AliasService.CreateAlias(ctx, "deploy-prod", "echo prod")
+		application.AliasService.CreateAlias(ctx, "deploy-stg", "echo stg")
+
+		output, err := runCommand(t, "do", "depl")
+		assert.Error(t, err)
+		assert.Contains(t, output, "ambiguous")
+	})
+
 	t.Run("do missing args", func(t *testing.T) {
 		setupTestApp(t)
 
@@ -38,11 +38,20 @@ Parameter substitution:
   $@             - All parameters (space-separated)
   $*             - All parameters (same as $@)
 
+Alias resolution:
+  If no alias has the exact name given, a unique prefix of an alias name
+  runs that alias (e.g. "mantrid do depl" runs "deploy"). This can be
+  disabled with "resolve_prefix: false" in the config file. Unknown names
+  get "did you mean" suggestions.
+
 Parameter passing:
   mantrid do <alias> [params...]      - Direct parameters
   mantrid do <alias> -- [params...]   - Parameters after -- separator
                                         (useful for passing flags like -l, --verbose)
 
+Every alias is also available as a top-level command, so
+"mantrid k get pods" is the same as "mantrid do k -- get pods".
+
 Examples:
   # Alias without placeholders - parameters auto-append
   mantrid alias add ls "ls"
@@ -65,41 +74,55 @@ Examples:
 WARNING: Aliases execute commands directly in your system shell.
 Only create aliases for commands you trust. Parameter substitution
 does not perform shell escaping - use with caution.`,
-	Args: cobra.MinimumNArgs(1),
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeDoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Extract alias name and parameters
 		aliasName, params := parseDoArgs(args)
+		return runAlias(cmd, aliasName, params)
+	},
+}
 
-		application, err := appFactory(cmd.Context(), GetConfigFile())
-		if err != nil {
-			return err
-		}
+// runAlias resolves aliasName and executes its command with params.
+// It backs both "mantrid do" and the top-level alias commands.
+func runAlias(cmd *cobra.Command, aliasName string, params []string) error {
+	application, err := appFactory(cmd.Context(), GetConfigFile())
+	if err != nil {
+		return err
+	}
 
-		ctx := logging.WithLogger(cmd.Context(), application.Logger)
+	ctx := logging.WithLogger(cmd.Context(), application.Logger)
 
-		// Get the alias
-		alias, err := application.AliasService.GetAlias(ctx, aliasName)
-		if err != nil {
-			if errors.Is(err, domain.ErrAliasNotFound) {
-				application.Logger.Error("alias not found", "name", aliasName)
-				return fmt.Errorf("alias '%s' not found. Use 'mantrid alias list' to see available aliases", aliasName)
-			}
-			application.Logger.Error("failed to get alias", "error", err)
-			return fmt.Errorf("failed to get alias: %w", err)
+	// Resolve the alias, allowing unique prefixes when configured
+	alias, err := application.AliasService.ResolveAlias(ctx, aliasName, application.Config.ResolvePrefix)
+	if err != nil {
+		if errors.Is(err, domain.ErrAliasNotFound) {
+			application.Logger.Error("alias not found", "name", aliasName)
+			return fmt.Errorf("alias '%s' not found.%s Use 'mantrid alias list' to see available aliases",
+				aliasName, didYouMean(ctx, application.AliasService, aliasName))
 		}
+		application.Logger.Error("failed to get alias", "error", err)
+		return fmt.Errorf("failed to get alias: %w", err)
+	}
 
-		application.Logger.Info("found alias", "name", aliasName, "command", alias.Command)
+	if alias.Name != aliasName {
+		application.Logger.Info("resolved alias abbreviation", "abbreviation", aliasName, "name", alias.Name)
+	}
+	application.Logger.Info("found alias", "name", alias.Name, "command", alias.Command)
 
-		// Substitute parameters
-		command := substituteParams(alias.Command, params)
+	// Substitute parameters
+	command := substituteParams(alias.Command, params)
 
-		if len(params) > 0 {
-			application.Logger.Info("substituted parameters", "original", alias.Command, "final", command)
-		}
+	if len(params) > 0 {
+		application.Logger.Info("substituted parameters", "original", alias.Command, "final", command)
+	}
 
-		// Execute the command
-		return executeCommand(ctx, command)
-	},
+	// Execute the command; aliases of a project run in its root by default
+	dir := alias.WorkDir
+	if dir == "" && alias.Layer.IsProject() {
+		dir = alias.Layer.Root
+	}
+	return executeCommand(ctx, command, dir)
 }
 
 // placeholderRe matches $@, $*, or $N (positional) in a single pass.
@@ -163,19 +186,18 @@ func parseDoArgs(args []string) (aliasName string, params []string) {
 	return aliasName, params
 }
 
-// executeCommand runs the command in the system shell
+// executeCommand runs the command in the system shell, in dir unless it is
+// empty.
 // Returns the exit code of the executed command
-func executeCommand(ctx context.Context, command string) error {
+func executeCommand(ctx context.Context, command, dir string) error {
 	logger := logging.FromContext(ctx)
 
-	var cmd *exec.Cmd
-
-	// Platform-specific shell selection
-	if runtime.GOOS == "windows" {
-		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
-	} else {
-		// Unix-like systems (Linux, macOS)
-		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	cmd := shellCommand(ctx, command)
+	if dir != "" {
+		if _, err := os.Stat(dir); err != nil {
+			return fmt.Errorf("cannot run in working directory: %w", err)
+		}
+		cmd.Dir = dir
 	}
 
 	// Connect stdin/stdout/stderr to current process
@@ -183,7 +205,7 @@ func executeCommand(ctx context.Context, command string) error {
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
-	logger.Info("executing alias command", "command", command)
+	logger.Info("executing alias command", "command", command, "dir", dir)
 
 	// Run the command
 	if err := cmd.Run(); err != nil {
@@ -199,6 +221,16 @@ func executeCommand(ctx context.Context, command string) error {
 	return nil
 }
 
+// shellCommand prepares command to run in the system shell.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	// Platform-specific shell selection
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	// Unix-like systems (Linux, macOS)
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
 func init() {
 	rootCmd.AddCommand(doCmd)
 }
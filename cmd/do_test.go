@@ -1,9 +1,15 @@
 package cmd
 
 import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParseDoArgs(t *testing.T) {
@@ -387,3 +393,19 @@ func TestSubstituteParams_AutoAppend(t *testing.T) {
 		})
 	}
 }
+
+func TestExecuteCommandWorkDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	dir := t.TempDir()
+
+	require.NoError(t, executeCommand(context.Background(), "pwd > pwd.txt", dir))
+	data, err := os.ReadFile(filepath.Join(dir, "pwd.txt"))
+	require.NoError(t, err)
+	resolved, _ := filepath.EvalSymlinks(dir)
+	assert.Equal(t, resolved, strings.TrimSpace(string(data)))
+
+	err = executeCommand(context.Background(), "true", filepath.Join(dir, "missing"))
+	assert.ErrorContains(t, err, "cannot run in working directory")
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"os"
 
 	"github.com/msaglietto/mantrid/internal/app"
 	"github.com/spf13/cobra"
@@ -20,6 +21,33 @@ func defaultAppFactory(ctx context.Context, configFilePath string) (*app.App, er
 	return app.New(ctx)
 }
 
+// sharedAppFactory returns factory building the application at most once
+// per config file, handing out the same App, or the same error, afterwards,
+// and a function closing the applications it built.
+func sharedAppFactory(factory func(context.Context, string) (*app.App, error)) (func(context.Context, string) (*app.App, error), func()) {
+	type built struct {
+		app *app.App
+		err error
+	}
+	apps := make(map[string]built)
+	shared := func(ctx context.Context, configFilePath string) (*app.App, error) {
+		if b, ok := apps[configFilePath]; ok {
+			return b.app, b.err
+		}
+		application, err := factory(ctx, configFilePath)
+		apps[configFilePath] = built{app: application, err: err}
+		return application, err
+	}
+	closeApps := func() {
+		for _, b := range apps {
+			if b.app != nil {
+				b.app.Close()
+			}
+		}
+	}
+	return shared, closeApps
+}
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "mantrid",
@@ -29,7 +57,39 @@ var rootCmd = &cobra.Command{
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// Stored aliases are registered as top-level commands before execution.
 func Execute(ctx context.Context) error {
+	configFile, rest := splitLeadingConfigFlag(os.Args[1:])
+
+	// Completion requests carry the command line being completed, where a
+	// --config flag may equally precede an alias command
+	var request []string
+	if len(rest) > 0 && (rest[0] == cobra.ShellCompRequestCmd || rest[0] == cobra.ShellCompNoDescRequestCmd) {
+		request = []string{rest[0]}
+		completeConfigFile, words := splitLeadingConfigFlag(rest[1:])
+		if completeConfigFile != "" {
+			configFile = completeConfigFile
+		}
+		rest = words
+	}
+
+	// Loading the alias commands and running the command share one
+	// application: one store open, one trust check, one passphrase prompt
+	factory := appFactory
+	shared, closeApps := sharedAppFactory(factory)
+	appFactory = shared
+	defer func() {
+		closeApps()
+		appFactory = factory
+	}()
+
+	registerAliasCommands(ctx, configFile)
+
+	if configFile != "" && len(rest) > 0 && isAliasCommand(rest[0]) {
+		cfgFile = configFile
+		rootCmd.SetArgs(append(request, rest...))
+	}
+
 	return rootCmd.ExecuteContext(ctx)
 }
 
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/msaglietto/mantrid/internal/fsutil"
+	"github.com/msaglietto/mantrid/internal/logging"
+	"github.com/msaglietto/mantrid/internal/shell"
+	"github.com/spf13/cobra"
+)
+
+var (
+	shellFunctionsOnly bool
+	shellOutput        string
+)
+
+var shellCmd = &cobra.Command{
+	Use:   "shell",
+	Short: "Integrate aliases with your shell",
+	Long:  `Commands to make stored aliases available as shell functions.`,
+}
+
+var shellInitCmd = &cobra.Command{
+	Use:   "init <shell>",
+	Short: "Print shell code defining a function per alias",
+	Long: `Print code that defines a shell function for every stored alias, so that
+"k get pods" runs "mantrid do k -- get pods". Supported shells are bash, zsh,
+fish and pwsh. Add the matching line to your shell's startup file:
+
+  bash:  eval "$(mantrid shell init bash)"
+  zsh:   eval "$(mantrid shell init zsh)"
+  fish:  mantrid shell init fish | source
+  pwsh:  Invoke-Expression (& mantrid shell init pwsh | Out-String)
+
+The functions are kept in a cache file next to the alias store. Before each
+prompt the shell checks whether the store changed and, if so, regenerates the
+cache and reloads it, so new, renamed and removed aliases take effect without
+opening a new shell.
+
+The functions are those of the global store: project aliases depend on the
+directory you are in, so run them with 'mantrid do' instead.
+
+Aliases whose names cannot be shell functions, such as namespaced "k8s/logs",
+are skipped with a warning; use 'mantrid do' to run them.
+
+Use --functions to print only the function definitions, without the refresh
+hook.`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: shell.Supported,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target := args[0]
+		if !shell.IsSupported(target) {
+			return fmt.Errorf("unsupported shell %q: must be one of %s", target, strings.Join(shell.Supported, ", "))
+		}
+
+		application, err := appFactory(cmd.Context(), GetConfigFile())
+		if err != nil {
+			return err
+		}
+
+		ctx := logging.WithLogger(cmd.Context(), application.Logger)
+
+		application.Logger.Info("generating shell integration", "shell", target, "functions", shellFunctionsOnly)
+
+		command := []string{"mantrid"}
+		if configFile := GetConfigFile(); configFile != "" {
+			command = append(command, "--config", configFile)
+		}
+
+		var script string
+		// Without a store file there is nothing to watch, so the functions
+		// are printed directly
+		if shellFunctionsOnly || application.Config.StorageType == "memory" {
+			// Not the project aliases of the working directory, which would
+			// end up in a cache shared by every directory
+			aliases, err := application.GlobalAliases.ListAliases(ctx)
+			if err != nil {
+				application.Logger.Error("failed to list aliases", "error", err)
+				return fmt.Errorf("failed to list aliases: %w", err)
+			}
+			sortAliases(aliases)
+
+			functions := make([]shell.Function, 0, len(aliases))
+			for _, alias := range aliases {
+				if err := shell.ValidFunctionName(target, alias.Name); err != nil {
+					application.Logger.Warn("alias skipped in shell integration",
+						"name", alias.Name, "reason", err, "hint", fmt.Sprintf("use 'mantrid do %s'", alias.Name))
+					continue
+				}
+				functions = append(functions, shell.Function{Name: alias.Name, Description: alias.Description})
+			}
+
+			script, err = shell.Functions(target, command, functions)
+		} else {
+			script, err = shell.Init(target, shell.InitOptions{
+				Command: command,
+				Store:   application.FileManager.GetStoreFilePath(application.Config.StorageType),
+				Cache:   filepath.Join(application.FileManager.GetShellCacheDir(), "init."+shell.Extension(target)),
+			})
+		}
+		if err != nil {
+			application.Logger.Error("failed to generate shell integration", "error", err)
+			return fmt.Errorf("failed to generate shell integration: %w", err)
+		}
+
+		if shellOutput != "" {
+			if err := fsutil.WriteFileAtomic(shellOutput, []byte(script), 0644); err != nil {
+				application.Logger.Error("failed to write shell integration", "error", err)
+				return fmt.Errorf("failed to write shell integration: %w", err)
+			}
+			return nil
+		}
+
+		fmt.Fprint(cmd.OutOrStdout(), script)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(shellCmd)
+	shellCmd.AddCommand(shellInitCmd)
+	shellInitCmd.Flags().BoolVar(&shellFunctionsOnly, "functions", false, "Print only the function definitions, without the refresh hook")
+	shellInitCmd.Flags().StringVarP(&shellOutput, "output", "o", "", "Write the script to a file instead of standard output")
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/msaglietto/mantrid/internal/config"
+	"github.com/msaglietto/mantrid/internal/paths"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShellInitCommand(t *testing.T) {
+	t.Run("defines a function per alias", func(t *testing.T) {
+		application := setupTestApp(t)
+		ctx := context.Background()
+		require.NoError(t, application.AliasService.CreateAlias(ctx, "k", "kubectl"))
+		require.NoError(t, application.AliasService.CreateAlias(ctx, "deploy", "./deploy.sh $1"))
+
+		output, err := runCommand(t, "shell", "init", "bash")
+		assert.NoError(t, err)
+		assert.Contains(t, output, "function k { command mantrid do 'k' -- \"$@\"; }")
+		assert.Contains(t, output, "function deploy { command mantrid do 'deploy' -- \"$@\"; }")
+		assert.NotContains(t, output, "PROMPT_COMMAND", "memory stores have nothing to watch")
+	})
+
+	t.Run("skips names that are not valid functions", func(t *testing.T) {
+		application := setupTestApp(t)
+		ctx := context.Background()
+		require.NoError(t, application.AliasService.CreateAlias(ctx, "k8s/logs", "kubectl logs"))
+		require.NoError(t, application.AliasService.CreateAlias(ctx, "if", "echo if"))
+		require.NoError(t, application.AliasService.CreateAlias(ctx, "k", "kubectl"))
+
+		output, err := runCommand(t, "shell", "init", "fish")
+		assert.NoError(t, err)
+		assert.Contains(t, output, "function k\n")
+		assert.NotContains(t, output, "k8s/logs")
+		assert.NotContains(t, output, "function if")
+	})
+
+	t.Run("passes the config file on", func(t *testing.T) {
+		application := setupTestApp(t)
+		require.NoError(t, application.AliasService.CreateAlias(context.Background(), "k", "kubectl"))
+
+		output, err := runCommand(t, "--config", "/tmp/my config.yaml", "shell", "init", "pwsh")
+		assert.NoError(t, err)
+		assert.Contains(t, output, "$global:__mantridFunctions = @('k')")
+		assert.Contains(t, output, "function global:k { & mantrid '--config' '/tmp/my config.yaml' do 'k' '--' @args }")
+	})
+
+	t.Run("file store installs the refresh hook", func(t *testing.T) {
+		application := setupTestApp(t)
+		dir := t.TempDir()
+		application.Config = &config.Config{StorageType: "json", AliasFile: filepath.Join(dir, "aliases.json")}
+		application.FileManager = paths.NewFileManager(application.Config)
+
+		output, err := runCommand(t, "shell", "init", "zsh")
+		assert.NoError(t, err)
+		assert.Contains(t, output, "__mantrid_store='"+filepath.Join(dir, "aliases.json")+"'")
+		assert.Contains(t, output, "__mantrid_cache='"+filepath.Join(dir, "shell", "init.zsh")+"'")
+		assert.Contains(t, output, "add-zsh-hook precmd __mantrid_refresh")
+	})
+
+	t.Run("writes to output file", func(t *testing.T) {
+		application := setupTestApp(t)
+		require.NoError(t, application.AliasService.CreateAlias(context.Background(), "k", "kubectl"))
+		path := filepath.Join(t.TempDir(), "shell", "init.bash")
+
+		output, err := runCommand(t, "shell", "init", "bash", "--functions", "--output", path)
+		assert.NoError(t, err)
+		assert.Empty(t, output)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "function k {")
+	})
+
+	t.Run("unsupported shell", func(t *testing.T) {
+		setupTestApp(t)
+
+		_, err := runCommand(t, "shell", "init", "csh")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), `unsupported shell "csh"`)
+	})
+}
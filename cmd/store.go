@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/msaglietto/mantrid/internal/app"
+	"github.com/spf13/cobra"
+)
+
+var storeCmd = &cobra.Command{
+	Use:     "store",
+	Aliases: []string{"storage"},
+	Short:   "Manage the alias store",
+}
+
+// rewriteStore runs rewrite, which replaces the contents of the alias
+// file, as a change of mantrid's own: a trusted file stays trusted and the
+// change is committed to the git history with message.
+func rewriteStore(cmd *cobra.Command, application *app.App, file, message string, rewrite func() error) error {
+	trusted := false
+	if application.Trust != nil {
+		entry, err := application.Trust.Check(file)
+		trusted = err == nil && entry != nil
+	}
+
+	if err := rewrite(); err != nil {
+		return err
+	}
+
+	if trusted {
+		if _, err := application.Trust.Allow(file); err != nil {
+			return fmt.Errorf("failed to keep %s trusted: %w", file, err)
+		}
+	}
+	if application.History != nil {
+		if err := application.History.Commit(cmd.Context(), message); err != nil {
+			return fmt.Errorf("aliases saved but not committed: %w", err)
+		}
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(storeCmd)
+}
@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/internal/app"
+	"github.com/msaglietto/mantrid/internal/crypt"
+	"github.com/msaglietto/mantrid/repository/filestore"
+	jsonrepo "github.com/msaglietto/mantrid/repository/json"
+	"github.com/msaglietto/mantrid/service"
+	"github.com/spf13/cobra"
+)
+
+var forceRepair bool
+
+var storeCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check the alias file for damage and invalid aliases",
+	Long: `Check the json alias file: where it stops being valid JSON, and which
+aliases are duplicated or invalid. Each problem is listed with the fix
+"mantrid store repair" would make. The command fails when problems are found.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		application, file, key, err := checkApp(cmd)
+		if err != nil {
+			return err
+		}
+
+		inspection, err := jsonrepo.Inspect(file, key)
+		if err != nil {
+			application.Logger.Error("failed to read alias file", "error", err)
+			return fmt.Errorf("failed to read alias file: %w", err)
+		}
+
+		aliases, problems := repairAliases(application.AliasService, inspection.Aliases, application.Config.NamespaceSeparator)
+		out := cmd.OutOrStdout()
+		writeStoreProblems(out, inspection, problems)
+		if inspection.Damage == nil && len(problems) == 0 {
+			fmt.Fprintf(out, "No problems found in %s (%d aliases)\n", file, len(aliases))
+			return nil
+		}
+
+		count := len(problems)
+		if inspection.Damage != nil {
+			count++
+		}
+		return fmt.Errorf("found %d problems in %s; run 'mantrid store repair' to fix them", count, file)
+	},
+}
+
+var storeRepairCmd = &cobra.Command{
+	Use:   "repair",
+	Short: "Fix the problems found by store check",
+	Long: `Rewrite the json alias file with the aliases that can be read from it:
+damaged parts are dropped, duplicated aliases keep the definition updated
+last, and invalid names are made valid where possible, otherwise the alias
+is removed. The original file is kept next to it as aliases.json.damaged-*.
+Prompts for confirmation unless --force flag is used.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		application, file, key, err := checkApp(cmd)
+		if err != nil {
+			return err
+		}
+
+		inspection, err := jsonrepo.Inspect(file, key)
+		if err != nil {
+			application.Logger.Error("failed to read alias file", "error", err)
+			return fmt.Errorf("failed to read alias file: %w", err)
+		}
+
+		svc, separator := application.AliasService, application.Config.NamespaceSeparator
+		_, problems := repairAliases(svc, inspection.Aliases, separator)
+		out := cmd.OutOrStdout()
+		if inspection.Damage == nil && len(problems) == 0 {
+			fmt.Fprintf(out, "No problems found in %s\n", file)
+			return nil
+		}
+
+		writeStoreProblems(out, inspection, problems)
+		if !forceRepair {
+			fmt.Fprintln(out)
+			if !confirm(cmd, os.Stdin, fmt.Sprintf("Repair %s?", file)) {
+				fmt.Fprintln(out, "Repair cancelled")
+				return nil
+			}
+		}
+
+		var quarantine string
+		var kept int
+		err = rewriteStore(cmd, application, file, "store repair", func() (err error) {
+			quarantine, err = jsonrepo.Repair(file, key, application.Config.LockTimeout, func(aliases []*domain.Alias) []*domain.Alias {
+				fixed, _ := repairAliases(svc, aliases, separator)
+				kept = len(fixed)
+				return fixed
+			})
+			return err
+		})
+		if err != nil {
+			application.Logger.Error("failed to repair alias file", "error", err)
+			return fmt.Errorf("failed to repair alias file: %w", err)
+		}
+
+		application.Logger.Info("alias file repaired", "file", file, "quarantined", quarantine)
+		fmt.Fprintf(out, "Repaired %s, keeping %d aliases\n", file, kept)
+		fmt.Fprintf(out, "The original file is kept as %s\n", quarantine)
+		return nil
+	},
+}
+
+// storeProblem is a problem with an alias in the alias file and how it is
+// fixed.
+type storeProblem struct {
+	problem string
+	fix     string
+}
+
+// repairAliases returns aliases with duplicated and invalid aliases fixed,
+// and the problems it fixed. Of duplicated aliases, the one updated last is
+// kept. Aliases with an invalid name are renamed when a valid name that is
+// not taken can be derived from it; other invalid aliases are dropped.
+func repairAliases(svc service.AliasService, aliases []*domain.Alias, separator string) ([]*domain.Alias, []storeProblem) {
+	names := make(map[string]bool, len(aliases))
+	for _, a := range aliases {
+		names[a.Name] = true
+	}
+
+	var fixed []*domain.Alias
+	var problems []storeProblem
+	index := map[string]int{}
+	for _, alias := range aliases {
+		if err := svc.ValidateAlias(alias); err != nil {
+			name := ""
+			if isNameError(err) {
+				name = sanitizeAliasName(alias.Name, separator)
+			}
+			renamed := *alias
+			renamed.Name = name
+			if name == "" || names[name] || svc.ValidateAlias(&renamed) != nil {
+				problems = append(problems, storeProblem{fmt.Sprintf("Invalid alias '%s' (%v)", alias.Name, err), "remove it"})
+				continue
+			}
+			problems = append(problems, storeProblem{fmt.Sprintf("Invalid alias '%s' (%v)", alias.Name, err), fmt.Sprintf("rename it to '%s'", name)})
+			names[name] = true
+			alias = &renamed
+		}
+
+		i, seen := index[alias.Name]
+		if !seen {
+			index[alias.Name] = len(fixed)
+			fixed = append(fixed, alias)
+			continue
+		}
+		if alias.UpdatedAt.After(fixed[i].UpdatedAt) {
+			fixed[i] = alias
+		}
+		problems = append(problems, storeProblem{
+			fmt.Sprintf("Duplicate alias '%s'", alias.Name),
+			fmt.Sprintf("keep the one updated %s", formatTime(fixed[i].UpdatedAt)),
+		})
+	}
+	return fixed, problems
+}
+
+// writeStoreProblems lists the damage and the problems found in the alias
+// file to out.
+func writeStoreProblems(out io.Writer, inspection *filestore.Inspection, problems []storeProblem) {
+	if d := inspection.Damage; d != nil {
+		fmt.Fprintf(out, "Damaged at line %d, column %d (%v): keep the %d aliases that can be read\n",
+			d.Line, d.Column, d.Err, len(inspection.Aliases))
+	}
+	for _, p := range problems {
+		fmt.Fprintf(out, "%s: %s\n", p.problem, p.fix)
+	}
+}
+
+// checkApp creates the application for store check and store repair and
+// returns it with the alias file and the key it is encrypted with, failing
+// unless aliases are kept in json storage.
+func checkApp(cmd *cobra.Command) (*app.App, string, *crypt.Key, error) {
+	application, err := appFactory(cmd.Context(), GetConfigFile())
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	cfg := application.Config
+	if cfg.StorageType != "json" {
+		return nil, "", nil, fmt.Errorf("checking the alias file needs json storage, not %s", cfg.StorageType)
+	}
+	var key *crypt.Key
+	if cfg.Encrypt {
+		key = app.EncryptionKey(cfg)
+	}
+	return application, application.FileManager.GetStoreFilePath("json"), key, nil
+}
+
+func init() {
+	storeCmd.AddCommand(storeCheckCmd)
+	storeCmd.AddCommand(storeRepairCmd)
+	storeRepairCmd.Flags().BoolVarP(&forceRepair, "force", "f", false, "Skip confirmation prompt")
+}
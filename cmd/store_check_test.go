@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/msaglietto/mantrid/internal/paths"
+	jsonrepo "github.com/msaglietto/mantrid/repository/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreCheckCommands(t *testing.T) {
+	application := setupTestApp(t)
+	dir := t.TempDir()
+	application.Config.StorageType = "json"
+	application.Config.AliasFile = filepath.Join(dir, "aliases.json")
+	application.FileManager = paths.NewFileManager(application.Config)
+	file := application.Config.AliasFile
+
+	require.NoError(t, os.WriteFile(file, []byte(`[
+  {"name": "gs", "command": "git status", "created_at": "2024-01-01T00:00:00Z", "updated_at": "2024-01-01T00:00:00Z"},
+  {"name": "gs", "command": "git status -sb", "created_at": "2024-01-01T00:00:00Z", "updated_at": "2024-03-01T00:00:00Z"},
+  {"name": "my alias", "command": "echo hi", "created_at": "2024-01-01T00:00:00Z", "updated_at": "2024-01-01T00:00:00Z"},
+  {"name": "relative", "command": "make", "workdir": "src", "created_at": "2024-01-01T00:00:00Z", "updated_at": "2024-01-01T00:00:00Z"}
+  {"name": "ll", "command": "ls -l", "created_at": "2024-01-01T00:00:00Z", "updated_at": "2024-01-01T00:00:00Z"}
+]`), 0600))
+
+	output, err := runCommand(t, "store", "check")
+	assert.ErrorContains(t, err, "found 4 problems in "+file+"; run 'mantrid store repair'")
+	assert.Contains(t, output, "Damaged at line 6, column 3")
+	assert.Contains(t, output, "keep the 5 aliases that can be read")
+	assert.Contains(t, output, "Duplicate alias 'gs': keep the one updated 2024-03-01 00:00:00")
+	assert.Contains(t, output, "Invalid alias 'my alias' (alias name must contain")
+	assert.Contains(t, output, "rename it to 'my_alias'")
+	assert.Contains(t, output, "Invalid alias 'relative' (alias working directory must be an absolute path: \"src\"): remove it")
+
+	output, err = runCommand(t, "store", "repair", "--force")
+	require.NoError(t, err)
+	assert.Contains(t, output, "Repaired "+file+", keeping 3 aliases")
+	assert.Contains(t, output, "The original file is kept as "+file+".damaged-")
+
+	inspection, err := jsonrepo.Inspect(file, nil)
+	require.NoError(t, err)
+	assert.Nil(t, inspection.Damage)
+	require.Len(t, inspection.Aliases, 3)
+	assert.Equal(t, "git status -sb", inspection.Aliases[0].Command)
+	assert.Equal(t, "my_alias", inspection.Aliases[1].Name)
+	assert.Equal(t, "ll", inspection.Aliases[2].Name)
+
+	output, err = runCommand(t, "store", "check")
+	require.NoError(t, err)
+	assert.Equal(t, "No problems found in "+file+" (3 aliases)", output)
+
+	output, err = runCommand(t, "store", "repair")
+	require.NoError(t, err)
+	assert.Equal(t, "No problems found in "+file, output)
+
+	application.Config.StorageType = "yaml"
+	_, err = runCommand(t, "store", "check")
+	assert.ErrorContains(t, err, "checking the alias file needs json storage")
+}
@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/msaglietto/mantrid/internal/app"
+	"github.com/msaglietto/mantrid/internal/crypt"
+	"github.com/msaglietto/mantrid/repository/filestore"
+	jsonrepo "github.com/msaglietto/mantrid/repository/json"
+	"github.com/spf13/cobra"
+)
+
+// newPassphraseEnv holds the new passphrase when rotating keys, which is
+// asked for on the terminal otherwise.
+const newPassphraseEnv = "MANTRID_NEW_PASSPHRASE"
+
+var (
+	encryptKeyFile string
+	rekeyKeyFile   string
+)
+
+var storeEncryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Encrypt the alias file",
+	Long: `Encrypt the json alias file with AES-256-GCM, using the key in a key file
+(--key-file or key_file in the config file) or a passphrase stretched with
+scrypt. The passphrase is read from MANTRID_PASSPHRASE, or asked for twice
+on the terminal.
+
+Set encrypt: true in the config file afterwards to use the encrypted file.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		application, file, err := encryptionApp(cmd)
+		if err != nil {
+			return err
+		}
+		cfg := application.Config
+
+		keyFile := encryptKeyFile
+		if keyFile == "" {
+			keyFile = cfg.KeyFile
+		}
+		key := newEncryptionKey(keyFile, app.PassphraseEnv)
+
+		var count int
+		err = rewriteStore(cmd, application, file, "store encrypt", func() (err error) {
+			count, err = jsonrepo.Reencrypt(file, nil, key, reencryptOptions(application)...)
+			return err
+		})
+		if errors.Is(err, crypt.ErrSealed) {
+			return fmt.Errorf("%s is already encrypted", file)
+		}
+		if err != nil {
+			application.Logger.Error("failed to encrypt aliases", "error", err)
+			return fmt.Errorf("failed to encrypt aliases: %w", err)
+		}
+
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "Encrypted %d aliases in %s with a %s\n", count, file, key.Describe())
+		if !cfg.Encrypt {
+			fmt.Fprintln(out, "Set encrypt: true in the config file to use it")
+		}
+		if keyFile != cfg.KeyFile {
+			fmt.Fprintf(out, "Set key_file: %s in the config file\n", keyFile)
+		}
+		if application.History != nil {
+			fmt.Fprintln(out, "Earlier versions in the git history are not encrypted")
+		}
+		if plaintext := plaintextSnapshots(application); plaintext > 0 {
+			fmt.Fprintf(out, "%d earlier snapshots in %s are not encrypted; delete them\n", plaintext, application.FileManager.GetBackupDir())
+		}
+		return nil
+	},
+}
+
+var storeDecryptCmd = &cobra.Command{
+	Use:   "decrypt",
+	Short: "Decrypt the alias file",
+	Long: `Decrypt the json alias file with the configured key file or passphrase,
+leaving it in plaintext. Set encrypt: false in the config file afterwards.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		application, file, err := encryptionApp(cmd)
+		if err != nil {
+			return err
+		}
+		cfg := application.Config
+
+		var count int
+		err = rewriteStore(cmd, application, file, "store decrypt", func() (err error) {
+			count, err = jsonrepo.Reencrypt(file, app.EncryptionKey(cfg), nil, reencryptOptions(application)...)
+			return err
+		})
+		if errors.Is(err, crypt.ErrNotSealed) {
+			return fmt.Errorf("%s is not encrypted", file)
+		}
+		if err != nil {
+			application.Logger.Error("failed to decrypt aliases", "error", err)
+			return fmt.Errorf("failed to decrypt aliases: %w", err)
+		}
+
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "Decrypted %d aliases in %s\n", count, file)
+		if cfg.Encrypt {
+			fmt.Fprintln(out, "Set encrypt: false in the config file to use it")
+		}
+		return nil
+	},
+}
+
+var storeRekeyCmd = &cobra.Command{
+	Use:   "rekey",
+	Short: "Encrypt the alias file with a new key",
+	Long: `Re-encrypt the alias file, decrypted with the configured key file or
+passphrase, with a new key: the one in --key-file, or else a new passphrase
+read from MANTRID_NEW_PASSPHRASE or asked for twice on the terminal.
+
+  mantrid store keygen ~/.mantrid/new.key
+  mantrid store rekey --key-file ~/.mantrid/new.key`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		application, file, err := encryptionApp(cmd)
+		if err != nil {
+			return err
+		}
+		cfg := application.Config
+		key := newEncryptionKey(rekeyKeyFile, newPassphraseEnv)
+
+		var count int
+		err = rewriteStore(cmd, application, file, "store rekey", func() (err error) {
+			count, err = jsonrepo.Reencrypt(file, app.EncryptionKey(cfg), key, reencryptOptions(application)...)
+			return err
+		})
+		if errors.Is(err, crypt.ErrNotSealed) {
+			return fmt.Errorf("%s is not encrypted; use 'mantrid store encrypt'", file)
+		}
+		if err != nil {
+			application.Logger.Error("failed to re-encrypt aliases", "error", err)
+			return fmt.Errorf("failed to re-encrypt aliases: %w", err)
+		}
+
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "Re-encrypted %d aliases in %s with a new %s\n", count, file, key.Describe())
+		switch {
+		case rekeyKeyFile != "" && rekeyKeyFile != cfg.KeyFile:
+			fmt.Fprintf(out, "Set key_file: %s in the config file\n", rekeyKeyFile)
+		case rekeyKeyFile == "" && cfg.KeyFile != "":
+			fmt.Fprintln(out, "Remove key_file from the config file")
+		}
+		if application.History != nil {
+			fmt.Fprintln(out, "Earlier versions in the git history stay encrypted with the old key")
+		}
+		return nil
+	},
+}
+
+// reencryptOptions returns the options of the alias file of application
+// when changing its key, so that its snapshots change key with it.
+func reencryptOptions(application *app.App) []filestore.Option {
+	cfg := application.Config
+	opts := []filestore.Option{filestore.WithLockTimeout(cfg.LockTimeout)}
+	if cfg.BackupCount > 0 {
+		opts = append(opts, filestore.WithSnapshots(app.Snapshots(cfg, application.FileManager, cfg.StorageType)))
+	}
+	return opts
+}
+
+// plaintextSnapshots returns how many snapshots of the alias file of
+// application are not encrypted, such as those left by encrypting it with
+// backups off.
+func plaintextSnapshots(application *app.App) int {
+	cfg := application.Config
+	snapshots, _ := app.Snapshots(cfg, application.FileManager, cfg.StorageType).List()
+	plaintext := 0
+	for _, snapshot := range snapshots {
+		if data, err := os.ReadFile(snapshot.Path); err == nil && !crypt.IsSealed(data) {
+			plaintext++
+		}
+	}
+	return plaintext
+}
+
+var storeKeygenCmd = &cobra.Command{
+	Use:   "keygen <path>",
+	Short: "Create a key file for encrypting the alias file",
+	Long: `Write a new random key to a file readable only by you, for use with
+--key-file or key_file in the config file. Keep a copy somewhere safe: the
+aliases cannot be decrypted without it.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := crypt.GenerateKeyFile(args[0]); err != nil {
+			return fmt.Errorf("failed to create key file: %w", err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Wrote a new key to %s\n", args[0])
+		return nil
+	},
+}
+
+// encryptionApp creates the application for an encryption command and
+// returns it with the alias file, failing unless aliases are kept in json
+// storage.
+func encryptionApp(cmd *cobra.Command) (*app.App, string, error) {
+	application, err := appFactory(cmd.Context(), GetConfigFile())
+	if err != nil {
+		return nil, "", err
+	}
+	if storageType := application.Config.StorageType; storageType != "json" {
+		return nil, "", fmt.Errorf("encryption needs json storage, not %s", storageType)
+	}
+	return application, application.FileManager.GetStoreFilePath("json"), nil
+}
+
+// newEncryptionKey returns the key in keyFile, or else one derived from a
+// new passphrase read from env or the terminal.
+func newEncryptionKey(keyFile, env string) *crypt.Key {
+	if keyFile != "" {
+		return crypt.KeyFile(keyFile)
+	}
+	return crypt.Passphrase(crypt.ReadPassphrase(env, "New passphrase: ", true))
+}
+
+func init() {
+	storeCmd.AddCommand(storeEncryptCmd)
+	storeCmd.AddCommand(storeDecryptCmd)
+	storeCmd.AddCommand(storeRekeyCmd)
+	storeCmd.AddCommand(storeKeygenCmd)
+	storeEncryptCmd.Flags().StringVar(&encryptKeyFile, "key-file", "", "Key file to encrypt with (default is key_file, else a passphrase)")
+	storeRekeyCmd.Flags().StringVar(&rekeyKeyFile, "key-file", "", "Key file to encrypt with from now on (default is a new passphrase)")
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/msaglietto/mantrid/internal/app"
+	"github.com/msaglietto/mantrid/internal/crypt"
+	"github.com/msaglietto/mantrid/internal/paths"
+	"github.com/msaglietto/mantrid/repository/git"
+	"github.com/msaglietto/mantrid/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreEncryptionCommands(t *testing.T) {
+	application := setupTestApp(t)
+	dir := t.TempDir()
+	application.Config.StorageType = "json"
+	application.Config.AliasFile = filepath.Join(dir, "aliases.json")
+	application.Config.BackupCount = 5
+	application.FileManager = paths.NewFileManager(application.Config)
+	file := application.Config.AliasFile
+	keyFile := filepath.Join(dir, "aliases.key")
+
+	require.NoError(t, os.WriteFile(file, []byte(`[
+  {"name": "db", "command": "psql -h db.internal", "created_at": "2024-01-01T00:00:00Z", "updated_at": "2024-01-01T00:00:00Z"}
+]`), 0600))
+	snapshots := app.Snapshots(application.Config, application.FileManager, "json")
+	_, err := snapshots.Create(time.Second)
+	require.NoError(t, err)
+
+	output, err := runCommand(t, "store", "keygen", keyFile)
+	require.NoError(t, err)
+	assert.Equal(t, "Wrote a new key to "+keyFile, output)
+	_, err = runCommand(t, "store", "keygen", keyFile)
+	assert.ErrorContains(t, err, "failed to create key file")
+
+	output, err = runCommand(t, "store", "encrypt", "--key-file", keyFile)
+	require.NoError(t, err)
+	assert.Contains(t, output, "Encrypted 1 aliases in "+file+" with a key file")
+	assert.Contains(t, output, "Set encrypt: true in the config file")
+	assert.Contains(t, output, "Set key_file: "+keyFile)
+	assert.NotContains(t, output, "not encrypted")
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.True(t, crypt.IsSealed(data))
+
+	// Snapshots from before are encrypted in place
+	list, err := snapshots.List()
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	for _, snapshot := range list {
+		data, err := os.ReadFile(snapshot.Path)
+		require.NoError(t, err)
+		assert.True(t, crypt.IsSealed(data), snapshot.ID)
+	}
+
+	application.Config.Encrypt = true
+	application.Config.KeyFile = keyFile
+	_, err = runCommand(t, "store", "encrypt")
+	assert.ErrorContains(t, err, "is already encrypted")
+
+	t.Setenv(newPassphraseEnv, "correct horse")
+	output, err = runCommand(t, "store", "rekey")
+	require.NoError(t, err)
+	assert.Contains(t, output, "Re-encrypted 1 aliases in "+file+" with a new passphrase")
+	assert.Contains(t, output, "Remove key_file from the config file")
+
+	application.Config.KeyFile = ""
+	t.Setenv("MANTRID_PASSPHRASE", "wrong")
+	_, err = runCommand(t, "store", "decrypt")
+	assert.ErrorIs(t, err, crypt.ErrWrongKey)
+
+	t.Setenv("MANTRID_PASSPHRASE", "correct horse")
+	output, err = runCommand(t, "store", "decrypt")
+	require.NoError(t, err)
+	assert.Contains(t, output, "Decrypted 1 aliases in "+file)
+	assert.Contains(t, output, "Set encrypt: false in the config file")
+	data, err = os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "psql -h db.internal")
+
+	_, err = runCommand(t, "store", "decrypt")
+	assert.ErrorContains(t, err, "is not encrypted")
+
+	application.Config.StorageType = "sqlite"
+	_, err = runCommand(t, "store", "encrypt")
+	assert.ErrorContains(t, err, "encryption needs json storage")
+}
+
+func TestStoreRekeyKeepsEarlierVersions(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	application := setupTestApp(t)
+	dir := t.TempDir()
+	cfg := application.Config
+	cfg.StorageType = "json"
+	cfg.AliasFile = filepath.Join(dir, "aliases.json")
+	cfg.BackupCount = 5
+	cfg.GitHistory = true
+	cfg.Encrypt = true
+	cfg.KeyFile = filepath.Join(dir, "aliases.key")
+	require.NoError(t, crypt.GenerateKeyFile(cfg.KeyFile))
+	application.FileManager = paths.NewFileManager(cfg)
+	application.History = git.NewStore(cfg.AliasFile)
+	open := func() {
+		store, err := app.OpenStore(cfg, application.FileManager, application.Trust, "json")
+		require.NoError(t, err)
+		application.AliasService = service.NewAliasService(store)
+	}
+	open()
+
+	_, err := runCommand(t, "alias", "add", "deploy", "make deploy")
+	require.NoError(t, err)
+	_, err = runCommand(t, "alias", "add", "gs", "git status")
+	require.NoError(t, err)
+	snapshots, err := app.Snapshots(cfg, application.FileManager, "json").List()
+	require.NoError(t, err)
+	require.NotEmpty(t, snapshots)
+
+	newKeyFile := filepath.Join(dir, "new.key")
+	require.NoError(t, crypt.GenerateKeyFile(newKeyFile))
+	_, err = runCommand(t, "store", "rekey", "--key-file", newKeyFile)
+	require.NoError(t, err)
+	cfg.KeyFile = newKeyFile
+	open()
+
+	t.Run("snapshots are encrypted with the new key", func(t *testing.T) {
+		output, err := runCommand(t, "backup", "restore", snapshots[len(snapshots)-1].ID)
+		require.NoError(t, err)
+		assert.Contains(t, output, "Restored 1 aliases from snapshot")
+	})
+
+	t.Run("versions under the old key are not checked out", func(t *testing.T) {
+		before, err := os.ReadFile(cfg.AliasFile)
+		require.NoError(t, err)
+
+		_, err = runCommand(t, "store", "checkout", "HEAD~2")
+		assert.ErrorIs(t, err, crypt.ErrWrongKey)
+		after, err := os.ReadFile(cfg.AliasFile)
+		require.NoError(t, err)
+		assert.Equal(t, before, after)
+	})
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/msaglietto/mantrid/internal/app"
+	"github.com/msaglietto/mantrid/internal/logging"
+	"github.com/msaglietto/mantrid/repository"
+	"github.com/spf13/cobra"
+)
+
+var storeLogLimit int
+
+// errNoHistory is returned by the history commands when git history is off.
+var errNoHistory = errors.New("git history is off; set git_history: true in the config file")
+
+var storeLogCmd = &cobra.Command{
+	Use:   "log",
+	Short: "List the versions of the alias file",
+	Long: `List the commits of the alias file, newest first. With git_history set in
+the config file, every change to the aliases is committed to a git
+repository in the directory of the alias file.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		application, err := historyApp(cmd)
+		if err != nil {
+			return err
+		}
+
+		commits, err := application.History.Log(cmd.Context(), storeLogLimit)
+		if err != nil {
+			application.Logger.Error("failed to read history", "error", err)
+			return fmt.Errorf("failed to read history: %w", err)
+		}
+		if len(commits) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "No history yet")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "REV\tDATE\tMESSAGE\t")
+		fmt.Fprintln(w, "---\t----\t-------\t")
+		for _, c := range commits {
+			fmt.Fprintf(w, "%s\t%s\t%s\t\n", c.Short, formatTime(c.Date.Local()), c.Subject)
+		}
+		return w.Flush()
+	},
+}
+
+var storeCheckoutCmd = &cobra.Command{
+	Use:   "checkout <rev>",
+	Short: "Restore the alias file to an earlier version",
+	Long: `Restore the alias file to its contents at a revision listed by
+"mantrid store log", or any other git revision such as HEAD~2. The restored
+version is committed on top of the history, so a checkout can be undone
+with another one, and the aliases it replaces are snapshotted first, as
+before any other change.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		application, err := historyApp(cmd)
+		if err != nil {
+			return err
+		}
+
+		// The version is written like any change, through the store of the
+		// alias file, once it reads with the current key
+		cfg := application.Config
+		ctx := logging.WithLogger(cmd.Context(), application.Logger)
+		store, err := app.OpenStore(cfg, application.FileManager, application.Trust, cfg.StorageType)
+		if err != nil {
+			return err
+		}
+		defer repository.Close(store)
+		restore := func(ctx context.Context, data []byte) error {
+			aliases, err := app.DecodeContents(cfg, cfg.StorageType, application.History.File(), data)
+			if err != nil {
+				return fmt.Errorf("the alias file cannot be read: %w", err)
+			}
+			return store.Replace(ctx, aliases)
+		}
+		commit, err := application.History.Checkout(ctx, args[0], restore)
+		if err != nil {
+			application.Logger.Error("failed to restore aliases", "rev", args[0], "error", err)
+			return fmt.Errorf("failed to restore aliases: %w", err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Restored aliases from %s (%s)\n", commit.Short, commit.Subject)
+		return nil
+	},
+}
+
+// historyApp creates the application for a history command, failing when
+// git history is off.
+func historyApp(cmd *cobra.Command) (*app.App, error) {
+	application, err := appFactory(cmd.Context(), GetConfigFile())
+	if err != nil {
+		return nil, err
+	}
+	if application.History == nil {
+		return nil, errNoHistory
+	}
+	return application, nil
+}
+
+func init() {
+	storeCmd.AddCommand(storeLogCmd)
+	storeCmd.AddCommand(storeCheckoutCmd)
+	storeLogCmd.Flags().IntVarP(&storeLogLimit, "max-count", "n", 20, "Number of versions to list")
+}
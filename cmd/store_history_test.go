@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/msaglietto/mantrid/internal/app"
+	"github.com/msaglietto/mantrid/internal/paths"
+	"github.com/msaglietto/mantrid/repository/git"
+	"github.com/msaglietto/mantrid/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreHistoryCommands(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	application := setupTestApp(t)
+
+	_, err := runCommand(t, "store", "log")
+	assert.ErrorContains(t, err, "git history is off; set git_history: true")
+
+	cfg := application.Config
+	cfg.StorageType = "json"
+	cfg.AliasFile = filepath.Join(t.TempDir(), "aliases.json")
+	cfg.BackupCount = 5
+	cfg.GitHistory = true
+	application.FileManager = paths.NewFileManager(cfg)
+	application.History = git.NewStore(cfg.AliasFile)
+	store, err := app.OpenStore(cfg, application.FileManager, application.Trust, "json")
+	require.NoError(t, err)
+	application.AliasService = service.NewAliasService(store)
+
+	output, err := runCommand(t, "store", "log")
+	require.NoError(t, err)
+	assert.Equal(t, "No history yet", output)
+
+	_, err = runCommand(t, "alias", "add", "deploy", "make deploy")
+	require.NoError(t, err)
+	_, err = runCommand(t, "alias", "add", "gs", "git status")
+	require.NoError(t, err)
+
+	output, err = runCommand(t, "store", "log")
+	require.NoError(t, err)
+	assert.Contains(t, output, "REV")
+	assert.Contains(t, output, "alias add gs on ")
+	assert.Contains(t, output, "alias add deploy on ")
+
+	output, err = runCommand(t, "store", "log", "-n", "1")
+	require.NoError(t, err)
+	assert.NotContains(t, output, "alias add deploy")
+
+	snapshots := app.Snapshots(cfg, application.FileManager, "json")
+	before, err := snapshots.List()
+	require.NoError(t, err)
+
+	output, err = runCommand(t, "store", "checkout", "HEAD~1")
+	require.NoError(t, err)
+	assert.Contains(t, output, "Restored aliases from ")
+	assert.Contains(t, output, "(alias add deploy on ")
+
+	output, err = runCommand(t, "alias", "list")
+	require.NoError(t, err)
+	assert.Contains(t, output, "deploy")
+	assert.NotContains(t, output, "git status")
+
+	output, err = runCommand(t, "store", "log", "-n", "1")
+	require.NoError(t, err)
+	assert.Contains(t, output, "store checkout ")
+
+	// The aliases the checkout replaced are kept in a snapshot
+	after, err := snapshots.List()
+	require.NoError(t, err)
+	require.Len(t, after, len(before)+1)
+	output, err = runCommand(t, "backup", "restore", after[0].ID)
+	require.NoError(t, err)
+	assert.Contains(t, output, "Restored 2 aliases from snapshot")
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/msaglietto/mantrid/internal/app"
+	"github.com/msaglietto/mantrid/internal/crypt"
+	"github.com/msaglietto/mantrid/internal/logging"
+	"github.com/msaglietto/mantrid/repository"
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateTo      string
+	migrateFrom    string
+	migrateForce   bool
+	migrateDecrypt bool
+)
+
+// fileStorageTypes are the storage types that keep aliases in a file, which
+// aliases can be migrated between.
+var fileStorageTypes = []string{"json", "yaml", "toml", "sqlite"}
+
+var storeMigrateCmd = &cobra.Command{
+	Use:   "migrate --to <type>",
+	Short: "Copy the aliases to another storage type",
+	Long: `Copy every alias from the store of the configured storage type, or the
+one given with --from, to the store of another type:
+
+  mantrid store migrate --to sqlite
+  mantrid store migrate --to yaml     # next to the alias file, as aliases.yaml
+
+The source store is left as it is. A target store that already holds aliases
+is only replaced with --force. An encrypted alias file is only migrated with
+--decrypt, since the other storage types keep the aliases in plaintext. Set storage_type in the config file afterwards
+to start using the new store.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		application, err := appFactory(cmd.Context(), GetConfigFile())
+		if err != nil {
+			return err
+		}
+
+		ctx := logging.WithLogger(cmd.Context(), application.Logger)
+		from := migrateFrom
+		if from == "" {
+			from = application.Config.StorageType
+		}
+		for _, storageType := range []string{from, migrateTo} {
+			if !slices.Contains(fileStorageTypes, storageType) {
+				return fmt.Errorf("cannot migrate %s storage: must be one of %s", storageType, strings.Join(fileStorageTypes, ", "))
+			}
+		}
+		if from == migrateTo {
+			return fmt.Errorf("the aliases are already in %s storage", from)
+		}
+
+		fm := application.FileManager
+		fromFile, toFile := fm.GetStoreFilePath(from), fm.GetStoreFilePath(migrateTo)
+		decrypted := from == "json" && encrypted(application.Config.Encrypt, fromFile)
+		if decrypted && !migrateDecrypt {
+			return fmt.Errorf("%s is encrypted and %s storage is not; use --decrypt to migrate the aliases in plaintext", fromFile, migrateTo)
+		}
+		application.Logger.Info("migrating aliases", "from", fromFile, "to", toFile)
+
+		src, err := app.OpenStore(application.Config, fm, application.Trust, from)
+		if err != nil {
+			return err
+		}
+		defer repository.Close(src)
+		dst, err := app.OpenStore(application.Config, fm, application.Trust, migrateTo)
+		if err != nil {
+			return err
+		}
+		defer repository.Close(dst)
+
+		aliases, err := src.List(ctx)
+		if err != nil {
+			application.Logger.Error("failed to read aliases", "error", err)
+			return fmt.Errorf("failed to read aliases from %s: %w", fromFile, err)
+		}
+		existing, err := dst.List(ctx)
+		if err != nil {
+			application.Logger.Error("failed to read aliases", "error", err)
+			return fmt.Errorf("failed to read aliases from %s: %w", toFile, err)
+		}
+		if len(existing) > 0 && !migrateForce {
+			return fmt.Errorf("%s already holds %d aliases; use --force to replace them", toFile, len(existing))
+		}
+
+		if err := dst.Replace(ctx, aliases); err != nil {
+			application.Logger.Error("failed to write aliases", "error", err)
+			return fmt.Errorf("failed to write aliases to %s: %w", toFile, err)
+		}
+
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "Migrated %d aliases from %s to %s\n", len(aliases), fromFile, toFile)
+		if application.Config.StorageType != migrateTo {
+			fmt.Fprintf(out, "Set storage_type: %s in the config file to use them\n", migrateTo)
+		}
+		if decrypted {
+			fmt.Fprintf(out, "The aliases in %s are not encrypted\n", toFile)
+		}
+		return nil
+	},
+}
+
+// encrypted reports whether the json alias file at file is encrypted, as
+// configured with encrypt or as found on disk.
+func encrypted(encrypt bool, file string) bool {
+	if encrypt {
+		return true
+	}
+	data, err := os.ReadFile(file)
+	return err == nil && crypt.IsSealed(data)
+}
+
+func init() {
+	storeCmd.AddCommand(storeMigrateCmd)
+	storeMigrateCmd.Flags().StringVar(&migrateTo, "to", "", "Storage type to copy the aliases to: "+strings.Join(fileStorageTypes, ", "))
+	storeMigrateCmd.Flags().StringVar(&migrateFrom, "from", "", "Storage type to copy the aliases from (default is the configured one)")
+	storeMigrateCmd.Flags().BoolVar(&migrateForce, "force", false, "Replace the aliases already in the target store")
+	storeMigrateCmd.Flags().BoolVar(&migrateDecrypt, "decrypt", false, "Migrate an encrypted alias file to plaintext storage")
+	storeMigrateCmd.MarkFlagRequired("to")
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/msaglietto/mantrid/internal/app"
+	"github.com/msaglietto/mantrid/internal/crypt"
+	"github.com/msaglietto/mantrid/internal/paths"
+	"github.com/msaglietto/mantrid/internal/trust"
+	"github.com/msaglietto/mantrid/repository/sqlite"
+	"github.com/msaglietto/mantrid/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreMigrateCommand(t *testing.T) {
+	application := setupTestApp(t)
+	dir := t.TempDir()
+	application.Config.StorageType = "json"
+	application.Config.AliasFile = filepath.Join(dir, "aliases.json")
+	application.Config.DatabaseFile = filepath.Join(dir, "aliases.db")
+	application.FileManager = paths.NewFileManager(application.Config)
+	db, err := trust.Open(filepath.Join(dir, "trusted.json"))
+	require.NoError(t, err)
+	application.Trust = db
+
+	require.NoError(t, os.WriteFile(application.Config.AliasFile, []byte(`[
+  {"name": "gs", "command": "git status", "created_at": "2024-01-01T00:00:00Z", "updated_at": "2024-01-02T00:00:00Z"},
+  {"name": "k8s/logs", "command": "kubectl logs $1", "description": "Pod logs", "created_at": "2024-01-01T00:00:00Z", "updated_at": "2024-01-01T00:00:00Z"}
+]`), 0600))
+
+	output, err := runCommand(t, "store", "migrate", "--to", "sqlite")
+	require.NoError(t, err)
+	assert.Contains(t, output, "Migrated 2 aliases from "+application.Config.AliasFile+" to "+application.Config.DatabaseFile)
+	assert.Contains(t, output, "Set storage_type: sqlite in the config file")
+
+	aliases, err := sqlite.NewAliasRepository(application.Config.DatabaseFile).List(context.Background())
+	require.NoError(t, err)
+	require.Len(t, aliases, 2)
+	assert.Equal(t, "gs", aliases[0].Name)
+	assert.Equal(t, "Pod logs", aliases[1].Description)
+
+	_, err = runCommand(t, "store", "migrate", "--to", "sqlite")
+	assert.ErrorContains(t, err, "already holds 2 aliases; use --force")
+	_, err = runCommand(t, "store", "migrate", "--to", "sqlite", "--force")
+	assert.NoError(t, err)
+
+	output, err = runCommand(t, "store", "migrate", "--to", "yaml")
+	require.NoError(t, err)
+	assert.Contains(t, output, "to "+filepath.Join(dir, "aliases.yaml"))
+	data, err := os.ReadFile(filepath.Join(dir, "aliases.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "- name: gs\n")
+
+	_, err = runCommand(t, "store", "migrate", "--to", "json")
+	assert.ErrorContains(t, err, "already in json storage")
+	_, err = runCommand(t, "store", "migrate", "--to", "memory")
+	assert.ErrorContains(t, err, "cannot migrate memory storage")
+}
+
+func TestStoreMigrateCommand_Encrypted(t *testing.T) {
+	application := setupTestApp(t)
+	dir := t.TempDir()
+	cfg := application.Config
+	cfg.StorageType = "json"
+	cfg.AliasFile = filepath.Join(dir, "aliases.json")
+	cfg.DatabaseFile = filepath.Join(dir, "aliases.db")
+	cfg.Encrypt = true
+	cfg.KeyFile = filepath.Join(dir, "aliases.key")
+	require.NoError(t, crypt.GenerateKeyFile(cfg.KeyFile))
+	application.FileManager = paths.NewFileManager(cfg)
+	db, err := trust.Open(filepath.Join(dir, "trusted.json"))
+	require.NoError(t, err)
+	application.Trust = db
+	store, err := app.OpenStore(cfg, application.FileManager, db, "json")
+	require.NoError(t, err)
+	application.AliasService = service.NewAliasService(store)
+
+	_, err = runCommand(t, "alias", "add", "db", "psql postgres://app:secret123@db")
+	require.NoError(t, err)
+
+	yamlFile := filepath.Join(dir, "aliases.yaml")
+	for _, to := range []string{"yaml", "sqlite"} {
+		_, err = runCommand(t, "store", "migrate", "--to", to)
+		assert.ErrorContains(t, err, "is encrypted and "+to+" storage is not; use --decrypt")
+	}
+	assert.NoFileExists(t, yamlFile)
+	assert.NoFileExists(t, cfg.DatabaseFile)
+
+	output, err := runCommand(t, "store", "migrate", "--to", "yaml", "--decrypt")
+	require.NoError(t, err)
+	assert.Contains(t, output, "Migrated 1 aliases")
+	assert.Contains(t, output, "The aliases in "+yamlFile+" are not encrypted")
+	data, err := os.ReadFile(yamlFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "secret123")
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/msaglietto/mantrid/service"
+)
+
+// didYouMean returns a " Did you mean ...?" hint listing stored aliases similar
+// to name, or an empty string when there is nothing worth suggesting.
+func didYouMean(ctx context.Context, svc service.AliasService, name string) string {
+	suggestions, err := svc.SuggestAliases(ctx, name)
+	if err != nil || len(suggestions) == 0 {
+		return ""
+	}
+
+	quoted := make([]string, len(suggestions))
+	for i, s := range suggestions {
+		quoted[i] = fmt.Sprintf("'%s'", s)
+	}
+
+	if len(quoted) == 1 {
+		return fmt.Sprintf(" Did you mean %s?", quoted[0])
+	}
+	last := len(quoted) - 1
+	return fmt.Sprintf(" Did you mean %s or %s?", strings.Join(quoted[:last], ", "), quoted[last])
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/msaglietto/mantrid/internal/paths"
+	"github.com/msaglietto/mantrid/internal/trust"
+	"github.com/spf13/cobra"
+)
+
+// trustFactory opens the trust database. It can be overridden in tests.
+var trustFactory = func() (*trust.DB, error) {
+	return trust.Open(paths.NewFileManager(nil).GetTrustFilePath())
+}
+
+var trustCmd = &cobra.Command{
+	Use:   "trust",
+	Short: "Manage trusted alias files",
+	Long: `Anyone who can write an alias file can make 'mantrid do' run commands.
+Alias files that are not owned by you, or that your group or others can
+write, are therefore refused until you trust them. This covers the alias
+file set with --config, MANTRID_CONFIG or alias_file. Project alias files,
+which may come with any cloned repository, are refused until you trust
+them whoever owns them.
+
+Trust is given to the contents of a file: when someone else changes it, it
+is refused again until you review it and trust it anew. Changes made through
+mantrid keep the file trusted.`,
+}
+
+var trustAllowCmd = &cobra.Command{
+	Use:   "allow <path>",
+	Short: "Trust the current contents of an alias file",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, err := trustFactory()
+		if err != nil {
+			return fmt.Errorf("failed to open trust database: %w", err)
+		}
+
+		entry, err := db.Allow(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to trust %s: %w", args[0], err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Trusted %s (sha256 %s)\n", entry.Path, entry.Hash[:12])
+		return nil
+	},
+}
+
+var trustListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List trusted alias files",
+	Long: `List the trusted alias files with their status: "ok" when the file
+still has the trusted contents, "changed" when it no longer does and will be
+refused, and "missing" when it is gone.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, err := trustFactory()
+		if err != nil {
+			return fmt.Errorf("failed to open trust database: %w", err)
+		}
+
+		entries := db.List()
+		if len(entries) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "No trusted files")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PATH\tSTATUS\tTRUSTED\t")
+		fmt.Fprintln(w, "----\t------\t-------\t")
+		for _, e := range entries {
+			fmt.Fprintf(w, "%s\t%s\t%s\t\n", e.Path, trustStatus(e), formatTime(e.TrustedAt))
+		}
+		return w.Flush()
+	},
+}
+
+var trustRevokeCmd = &cobra.Command{
+	Use:   "revoke <path>",
+	Short: "Stop trusting an alias file",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, err := trustFactory()
+		if err != nil {
+			return fmt.Errorf("failed to open trust database: %w", err)
+		}
+
+		if err := db.Revoke(args[0]); err != nil {
+			return fmt.Errorf("failed to revoke trust: %w", err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Revoked trust in %s\n", args[0])
+		return nil
+	},
+}
+
+// trustStatus describes whether the file of e still has the trusted
+// contents.
+func trustStatus(e trust.Entry) string {
+	switch {
+	case trust.Matches(e):
+		return "ok"
+	case fileExists(e.Path):
+		return "changed"
+	default:
+		return "missing"
+	}
+}
+
+// fileExists reports whether anything exists at path.
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func init() {
+	rootCmd.AddCommand(trustCmd)
+	trustCmd.AddCommand(trustAllowCmd, trustListCmd, trustRevokeCmd)
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/msaglietto/mantrid/internal/trust"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrustCommands(t *testing.T) {
+	dir := t.TempDir()
+	original := trustFactory
+	t.Cleanup(func() { trustFactory = original })
+	trustFactory = func() (*trust.DB, error) {
+		return trust.Open(filepath.Join(dir, "trusted.json"))
+	}
+
+	path := filepath.Join(dir, "aliases.json")
+	require.NoError(t, os.WriteFile(path, []byte("[]"), 0600))
+
+	output, err := runCommand(t, "trust", "list")
+	require.NoError(t, err)
+	assert.Contains(t, output, "No trusted files")
+
+	output, err = runCommand(t, "trust", "allow", path)
+	require.NoError(t, err)
+	assert.Contains(t, output, "Trusted "+path)
+
+	output, err = runCommand(t, "trust", "list")
+	require.NoError(t, err)
+	assert.Regexp(t, path+`\s+ok`, output)
+
+	require.NoError(t, os.WriteFile(path, []byte(`[{"name":"x","command":"rm -rf ~"}]`), 0600))
+	output, err = runCommand(t, "trust", "list")
+	require.NoError(t, err)
+	assert.Regexp(t, path+`\s+changed`, output)
+
+	output, err = runCommand(t, "trust", "revoke", path)
+	require.NoError(t, err)
+	assert.Contains(t, output, "Revoked trust in "+path)
+
+	_, err = runCommand(t, "trust", "revoke", path)
+	assert.ErrorContains(t, err, "path is not trusted")
+
+	_, err = runCommand(t, "trust", "allow", filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+}
@@ -3,57 +3,110 @@ package domain
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
 	"regexp"
 	"time"
 )
 
 var (
-	ErrEmptyAliasName    = errors.New("alias name cannot be empty")
-	ErrEmptyAliasCommand = errors.New("alias command cannot be empty")
-	ErrAliasNotFound     = errors.New("alias not found")
-	ErrAliasExists       = errors.New("alias already exists")
-	ErrInvalidAliasName  = errors.New("alias name must contain only alphanumeric characters, hyphens, and underscores")
-	ErrNameTooLong       = errors.New("alias name must be 64 characters or fewer")
-	ErrCommandTooLong    = errors.New("alias command must be 4096 characters or fewer")
+	ErrEmptyAliasName     = errors.New("alias name cannot be empty")
+	ErrEmptyAliasCommand  = errors.New("alias command cannot be empty")
+	ErrAliasNotFound      = errors.New("alias not found")
+	ErrAliasExists        = errors.New("alias already exists")
+	ErrAmbiguousAlias     = errors.New("alias name is ambiguous")
+	ErrInvalidAliasName   = errors.New("alias name must contain only alphanumeric characters, hyphens, and underscores, optionally namespaced with '/' or '.'")
+	ErrNameTooLong        = errors.New("alias name must be 64 characters or fewer")
+	ErrCommandTooLong     = errors.New("alias command must be 4096 characters or fewer")
+	ErrDescriptionTooLong = errors.New("alias description must be 256 characters or fewer")
+	ErrRelativeWorkDir    = errors.New("alias working directory must be an absolute path")
+	ErrConflict           = errors.New("alias was changed by someone else")
 )
 
 const (
-	maxNameLength    = 64
-	maxCommandLength = 4096
+	maxNameLength        = 64
+	maxCommandLength     = 4096
+	maxDescriptionLength = 256
 )
 
-var aliasNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+// NamespaceSeparators lists the characters that may separate the segments of
+// a namespaced alias name such as "k8s/prod/logs" or "k8s.prod.logs".
+const NamespaceSeparators = "/."
+
+var aliasNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+([/.][a-zA-Z0-9_-]+)*$`)
 
 type Alias struct {
-	Name      string    `json:"name"`
-	Command   string    `json:"command"`
+	Name        string `json:"name"`
+	Command     string `json:"command"`
+	Description string `json:"description,omitempty"`
+	Completion  string `json:"completion,omitempty"`
+	// WorkDir is the directory the command runs in; empty means the current
+	// directory.
+	WorkDir string `json:"workdir,omitempty"`
+	// Source identifies the import that created and maintains the alias, as
+	// in "make:/src/app/Makefile". It is empty for aliases made by hand.
+	Source    string    `json:"source,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	// Revision identifies the stored contents of the alias. Repositories set
+	// it on every change, from the attributes of the alias, so that a change
+	// based on an outdated copy can be detected; zero means unknown.
+	Revision int64 `json:"revision,omitempty"`
+	// Layer is the alias file the alias was read from, when aliases are
+	// layered. It is not stored.
+	Layer *Layer `json:"-"`
+}
+
+// AliasOption sets an optional attribute on an alias being created.
+type AliasOption func(*Alias)
+
+// WithDescription sets a short human-readable description of the alias.
+func WithDescription(description string) AliasOption {
+	return func(a *Alias) {
+		a.Description = description
+	}
+}
+
+// WithCompletion sets how the parameters of the alias are completed, in the
+// compact form parsed by ParseCompletion.
+func WithCompletion(spec string) AliasOption {
+	return func(a *Alias) {
+		a.Completion = spec
+	}
+}
+
+// WithWorkDir sets the absolute directory the command of the alias runs in.
+func WithWorkDir(dir string) AliasOption {
+	return func(a *Alias) {
+		a.WorkDir = dir
+	}
 }
 
-func NewAlias(name, command string) (*Alias, error) {
+func NewAlias(name, command string, opts ...AliasOption) (*Alias, error) {
 	if err := validateAlias(name, command); err != nil {
 		return nil, err
 	}
 
 	now := time.Now()
-	return &Alias{
+	alias := &Alias{
 		Name:      name,
 		Command:   command,
 		CreatedAt: now,
 		UpdatedAt: now,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(alias)
+	}
+
+	if err := alias.validateAttributes(); err != nil {
+		return nil, err
+	}
+
+	return alias, nil
 }
 
 func validateAlias(name, command string) error {
-	if name == "" {
-		return ErrEmptyAliasName
-	}
-	if len(name) > maxNameLength {
-		return ErrNameTooLong
-	}
-	if !aliasNamePattern.MatchString(name) {
-		return fmt.Errorf("%w: %q", ErrInvalidAliasName, name)
+	if err := ValidateName(name); err != nil {
+		return err
 	}
 	if command == "" {
 		return ErrEmptyAliasCommand
@@ -64,6 +117,31 @@ func validateAlias(name, command string) error {
 	return nil
 }
 
+// Validate checks that the alias has a valid name, command and optional
+// attributes.
+func (a *Alias) Validate() error {
+	if err := validateAlias(a.Name, a.Command); err != nil {
+		return err
+	}
+	return a.validateAttributes()
+}
+
+// validateAttributes checks the optional attributes of the alias.
+func (a *Alias) validateAttributes() error {
+	if len(a.Description) > maxDescriptionLength {
+		return ErrDescriptionTooLong
+	}
+	if a.Completion != "" {
+		if _, err := ParseCompletion(a.Completion); err != nil {
+			return err
+		}
+	}
+	if a.WorkDir != "" && !filepath.IsAbs(a.WorkDir) {
+		return fmt.Errorf("%w: %q", ErrRelativeWorkDir, a.WorkDir)
+	}
+	return nil
+}
+
 // UpdateCommand updates the command and timestamp of an alias
 func (a *Alias) UpdateCommand(newCommand string) error {
 	if newCommand == "" {
@@ -76,3 +154,17 @@ func (a *Alias) UpdateCommand(newCommand string) error {
 	a.UpdatedAt = time.Now()
 	return nil
 }
+
+// ValidateName checks that name is usable as an alias name.
+func ValidateName(name string) error {
+	if name == "" {
+		return ErrEmptyAliasName
+	}
+	if len(name) > maxNameLength {
+		return ErrNameTooLong
+	}
+	if !aliasNamePattern.MatchString(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidAliasName, name)
+	}
+	return nil
+}
@@ -71,8 +71,32 @@ func TestNewAlias(t *testing.T) {
 			expectedErr: domain.ErrInvalidAliasName,
 		},
 		{
-			name:        "name with dot",
-			aliasName:   "my.alias",
+			name:        "namespaced with dots",
+			aliasName:   "k8s.prod.logs",
+			command:     "echo test",
+			expectedErr: nil,
+		},
+		{
+			name:        "namespaced with slashes",
+			aliasName:   "k8s/prod/logs",
+			command:     "echo test",
+			expectedErr: nil,
+		},
+		{
+			name:        "empty namespace segment",
+			aliasName:   "k8s//logs",
+			command:     "echo test",
+			expectedErr: domain.ErrInvalidAliasName,
+		},
+		{
+			name:        "trailing namespace separator",
+			aliasName:   "k8s/",
+			command:     "echo test",
+			expectedErr: domain.ErrInvalidAliasName,
+		},
+		{
+			name:        "leading namespace separator",
+			aliasName:   "/k8s",
 			command:     "echo test",
 			expectedErr: domain.ErrInvalidAliasName,
 		},
@@ -174,3 +198,22 @@ func TestUpdateCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestNewAliasWithDescription(t *testing.T) {
+	alias, err := domain.NewAlias("k", "kubectl", domain.WithDescription("Run kubectl"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Run kubectl", alias.Description)
+
+	_, err = domain.NewAlias("k", "kubectl", domain.WithDescription(strings.Repeat("a", 257)))
+	assert.Equal(t, domain.ErrDescriptionTooLong, err)
+}
+
+func TestNewAliasWithWorkDir(t *testing.T) {
+	dir := t.TempDir()
+	alias, err := domain.NewAlias("build", "make build", domain.WithWorkDir(dir))
+	assert.NoError(t, err)
+	assert.Equal(t, dir, alias.WorkDir)
+
+	_, err = domain.NewAlias("build", "make build", domain.WithWorkDir("src/app"))
+	assert.ErrorIs(t, err, domain.ErrRelativeWorkDir)
+}
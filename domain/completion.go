@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Completion kinds for the parameters of an alias.
+const (
+	CompleteFiles   = "files"
+	CompleteDirs    = "dirs"
+	CompleteWords   = "words"
+	CompleteCommand = "command"
+)
+
+var ErrInvalidCompletion = errors.New("completion must be one of files[:ext,...], dirs, words:word,... or command:<command>")
+
+// CompletionSpec describes how the parameters of an alias are completed.
+// It is stored on the alias in its compact form, such as "files:yaml,yml",
+// "dirs", "words:dev,staging,prod" or "command:kubectl get ns -o name".
+type CompletionSpec struct {
+	Kind string
+	// Values holds the file extensions for CompleteFiles and the candidates
+	// for CompleteWords.
+	Values []string
+	// Command is the shell command whose output lines are the candidates
+	// for CompleteCommand.
+	Command string
+}
+
+// ParseCompletion parses the compact form of a completion spec.
+func ParseCompletion(spec string) (CompletionSpec, error) {
+	kind, value, hasValue := strings.Cut(spec, ":")
+	value = strings.TrimSpace(value)
+
+	switch kind {
+	case CompleteFiles:
+		return CompletionSpec{Kind: kind, Values: splitList(value)}, nil
+	case CompleteDirs:
+		if hasValue {
+			return CompletionSpec{}, fmt.Errorf("%w: dirs takes no value", ErrInvalidCompletion)
+		}
+		return CompletionSpec{Kind: kind}, nil
+	case CompleteWords:
+		words := splitList(value)
+		if len(words) == 0 {
+			return CompletionSpec{}, fmt.Errorf("%w: words needs at least one word", ErrInvalidCompletion)
+		}
+		return CompletionSpec{Kind: kind, Values: words}, nil
+	case CompleteCommand:
+		if value == "" {
+			return CompletionSpec{}, fmt.Errorf("%w: command needs a command to run", ErrInvalidCompletion)
+		}
+		return CompletionSpec{Kind: kind, Command: value}, nil
+	default:
+		return CompletionSpec{}, fmt.Errorf("%w: got %q", ErrInvalidCompletion, spec)
+	}
+}
+
+// String returns the compact form of the spec.
+func (c CompletionSpec) String() string {
+	switch {
+	case c.Kind == CompleteCommand:
+		return c.Kind + ":" + c.Command
+	case len(c.Values) > 0:
+		return c.Kind + ":" + strings.Join(c.Values, ",")
+	default:
+		return c.Kind
+	}
+}
+
+// splitList splits a comma-separated list, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,55 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCompletion(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected domain.CompletionSpec
+		wantErr  bool
+	}{
+		{spec: "files", expected: domain.CompletionSpec{Kind: domain.CompleteFiles}},
+		{spec: "files:yaml, yml", expected: domain.CompletionSpec{Kind: domain.CompleteFiles, Values: []string{"yaml", "yml"}}},
+		{spec: "dirs", expected: domain.CompletionSpec{Kind: domain.CompleteDirs}},
+		{spec: "words:dev,staging,,prod", expected: domain.CompletionSpec{Kind: domain.CompleteWords, Values: []string{"dev", "staging", "prod"}}},
+		{spec: "command:kubectl get ns -o name", expected: domain.CompletionSpec{Kind: domain.CompleteCommand, Command: "kubectl get ns -o name"}},
+		{spec: "dirs:src", wantErr: true},
+		{spec: "words:", wantErr: true},
+		{spec: "command", wantErr: true},
+		{spec: "hosts", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			spec, err := domain.ParseCompletion(tt.spec)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, domain.ErrInvalidCompletion)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, spec)
+
+			// The compact form round-trips
+			again, err := domain.ParseCompletion(spec.String())
+			assert.NoError(t, err)
+			assert.Equal(t, spec, again)
+		})
+	}
+}
+
+func TestNewAliasWithCompletion(t *testing.T) {
+	alias, err := domain.NewAlias("deploy", "./deploy.sh $1", domain.WithCompletion("words:dev,prod"))
+	assert.NoError(t, err)
+	assert.Equal(t, "words:dev,prod", alias.Completion)
+
+	_, err = domain.NewAlias("deploy", "./deploy.sh $1", domain.WithCompletion("hosts"))
+	assert.ErrorIs(t, err, domain.ErrInvalidCompletion)
+
+	alias.Completion = "words:"
+	assert.ErrorIs(t, alias.Validate(), domain.ErrInvalidCompletion)
+}
@@ -0,0 +1,30 @@
+package domain
+
+// GlobalLayerName is the name of the layer of the user's own alias store.
+const GlobalLayerName = "global"
+
+// Layer identifies the alias file an alias was read from when project alias
+// files are layered over the global store. It is attached to aliases when
+// they are read and never stored.
+type Layer struct {
+	// Name is shown to the user: GlobalLayerName, or the path of a project
+	// alias file.
+	Name string
+	// File is the path of the alias file.
+	File string
+	// Root is the directory of the project, or empty for the global store.
+	Root string
+}
+
+// IsProject reports whether the layer is a project alias file.
+func (l *Layer) IsProject() bool {
+	return l != nil && l.Root != ""
+}
+
+// WithLayer sets the layer a new alias is stored in. Without it aliases go
+// to the global store.
+func WithLayer(layer *Layer) AliasOption {
+	return func(a *Alias) {
+		a.Layer = layer
+	}
+}
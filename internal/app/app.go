@@ -2,15 +2,29 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"os"
+	"path/filepath"
 
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/internal/backup"
 	"github.com/msaglietto/mantrid/internal/config"
+	"github.com/msaglietto/mantrid/internal/crypt"
 	"github.com/msaglietto/mantrid/internal/logging"
 	"github.com/msaglietto/mantrid/internal/paths"
+	"github.com/msaglietto/mantrid/internal/project"
+	"github.com/msaglietto/mantrid/internal/trust"
 	"github.com/msaglietto/mantrid/repository"
+	"github.com/msaglietto/mantrid/repository/filestore"
+	gitrepo "github.com/msaglietto/mantrid/repository/git"
 	jsonrepo "github.com/msaglietto/mantrid/repository/json"
+	"github.com/msaglietto/mantrid/repository/layered"
 	"github.com/msaglietto/mantrid/repository/memory"
+	sqliterepo "github.com/msaglietto/mantrid/repository/sqlite"
+	tomlrepo "github.com/msaglietto/mantrid/repository/toml"
+	yamlrepo "github.com/msaglietto/mantrid/repository/yaml"
 	"github.com/msaglietto/mantrid/service"
 )
 
@@ -20,6 +34,20 @@ type App struct {
 	Logger       *slog.Logger
 	FileManager  *paths.FileManager
 	AliasService service.AliasService
+	// GlobalAliases serves the global store alone, without the project
+	// alias files layered over it, for what must not depend on the
+	// working directory.
+	GlobalAliases service.AliasService
+	Trust         *trust.DB
+	// Layers are the project alias files layered over the global store,
+	// nearest first. It is empty outside of projects.
+	Layers []*domain.Layer
+	// History is the git repository versioning the alias file, or nil when
+	// git history is off.
+	History *gitrepo.Store
+
+	// repo is the store AliasService serves, closed by Close
+	repo repository.AliasRepository
 }
 
 // New creates a new App instance with all dependencies initialized.
@@ -40,26 +68,226 @@ func New(ctx context.Context, configFilePath ...string) (*App, error) {
 		return nil, fmt.Errorf("failed to create directories: %w", err)
 	}
 
-	// Initialize repository based on config
-	repo := newRepository(cfg, fm)
+	// Alias files other users can write are refused unless trusted, before
+	// any of their aliases can run
+	trusted, err := trust.Open(fm.GetTrustFilePath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open trust database: %w", err)
+	}
+
+	// Initialize repository based on config, with the alias files of the
+	// projects around the working directory layered over it
+	repo, err := OpenStore(cfg, fm, trusted, cfg.StorageType)
+	if err != nil {
+		return nil, err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+	layers, err := project.Discover(cwd, fm.GetAliasFilePath(), fm.GetBaseDir())
+	if err != nil {
+		return nil, fmt.Errorf("failed to find project alias files: %w", err)
+	}
+	layers, stores, err := projectRepositories(cfg, trusted, layers, logger)
+	if err != nil {
+		return nil, err
+	}
+	global := service.NewAliasService(repo, service.WithNamespaceSeparator(cfg.NamespaceSeparator))
+	if len(layers) > 0 {
+		logger.Debug("layering project alias files", "count", len(layers))
+		repo = layerRepository(repo, fm.GetStoreFilePath(cfg.StorageType), layers, stores)
+	}
 
 	// Initialize service
-	svc := service.NewAliasService(repo)
+	svc := service.NewAliasService(repo, service.WithNamespaceSeparator(cfg.NamespaceSeparator))
 
 	return &App{
-		Config:       cfg,
-		Logger:       logger,
-		FileManager:  fm,
-		AliasService: svc,
+		Config:        cfg,
+		Logger:        logger,
+		FileManager:   fm,
+		AliasService:  svc,
+		GlobalAliases: global,
+		Trust:         trusted,
+		Layers:        layers,
+		History:       history(cfg, fm, cfg.StorageType),
+		repo:          repo,
 	}, nil
 }
 
-// newRepository creates the appropriate repository based on config.
-func newRepository(cfg *config.Config, fm *paths.FileManager) repository.AliasRepository {
-	switch cfg.StorageType {
+// Close closes the alias stores of the application, such as the database
+// of the sqlite store.
+func (a *App) Close() error {
+	return repository.Close(a.repo)
+}
+
+// OpenStore opens the global alias store of storageType, refusing it
+// unless its file is safe or trusted.
+func OpenStore(cfg *config.Config, fm *paths.FileManager, trusted *trust.DB, storageType string) (repository.AliasRepository, error) {
+	file := fm.GetStoreFilePath(storageType)
+	var opts []filestore.Option
+	if file != "" {
+		entry, err := trusted.Check(file)
+		if err != nil {
+			return nil, fmt.Errorf("refusing alias file: %w", err)
+		}
+		if entry != nil && storageType == "sqlite" {
+			// Its contents cannot be checked before each change, or recorded
+			// after, the way those of an alias file are
+			return nil, fmt.Errorf("refusing alias file: %s is a database others can write, which cannot be kept trusted; make it yours and private", file)
+		}
+		if entry != nil {
+			opts = append(opts, filestore.WithHook(trust.NewGuard(trusted, entry)))
+		}
+	}
+
+	store := history(cfg, fm, storageType)
+	if store != nil {
+		opts = append(opts, filestore.WithHook(store))
+	}
+	repo := newRepository(cfg, fm, storageType, opts...)
+	if store != nil {
+		repo = gitrepo.NewAliasRepository(repo)
+	}
+	return repo, nil
+}
+
+// newRepository creates the repository of storageType. Stores in a single
+// file are opened with opts.
+func newRepository(cfg *config.Config, fm *paths.FileManager, storageType string, opts ...filestore.Option) repository.AliasRepository {
+	switch storageType {
 	case "memory":
 		return memory.NewAliasRepository()
+	case "sqlite":
+		return sqliterepo.NewAliasRepository(fm.GetDatabaseFilePath(), sqliterepo.WithBusyTimeout(cfg.LockTimeout))
+	}
+
+	if cfg.BackupCount > 0 {
+		opts = append(opts, filestore.WithSnapshots(Snapshots(cfg, fm, storageType)))
+	}
+	return fileRepository(cfg, storageType, fm.GetStoreFilePath(storageType), opts...)
+}
+
+// Snapshots returns the snapshots of the alias file of storageType, or nil
+// for storage that is not a single file.
+func Snapshots(cfg *config.Config, fm *paths.FileManager, storageType string) *backup.Snapshots {
+	switch storageType {
+	case "json", "yaml", "toml":
+		return backup.New(fm.GetStoreFilePath(storageType), fm.GetBackupDir(), cfg.BackupCount, cfg.BackupMaxAge)
+	}
+	return nil
+}
+
+// SnapshotRepository opens the snapshot at path of the alias file of
+// storageType, to read the aliases in it.
+func SnapshotRepository(cfg *config.Config, storageType, path string) repository.AliasRepository {
+	return fileRepository(cfg, storageType, path)
+}
+
+// DecodeContents returns the aliases in data, contents of the alias file
+// at file, kept in storageType storage, such as an earlier version of it
+// about to be restored. They are decrypted with the configured key.
+func DecodeContents(cfg *config.Config, storageType, file string, data []byte) ([]*domain.Alias, error) {
+	store, ok := fileRepository(cfg, storageType, file).(*filestore.Store)
+	if !ok {
+		return nil, fmt.Errorf("%s storage is not an alias file", storageType)
+	}
+	return store.Decode(data)
+}
+
+// fileRepository opens the alias file at file, kept in storageType
+// storage, with opts.
+func fileRepository(cfg *config.Config, storageType, file string, opts ...filestore.Option) repository.AliasRepository {
+	opts = append([]filestore.Option{filestore.WithLockTimeout(cfg.LockTimeout)}, opts...)
+
+	switch storageType {
+	case "yaml":
+		return yamlrepo.NewAliasRepository(file, opts...)
+	case "toml":
+		return tomlrepo.NewAliasRepository(file, opts...)
 	default:
-		return jsonrepo.NewAliasRepository(fm.GetAliasFilePath())
+		if cfg.Encrypt {
+			opts = append(opts, filestore.WithKey(EncryptionKey(cfg)))
+		}
+		return jsonrepo.NewAliasRepository(file, opts...)
+	}
+}
+
+// PassphraseEnv is the environment variable holding the passphrase of an
+// encrypted alias file, which is asked for on the terminal otherwise.
+const PassphraseEnv = "MANTRID_PASSPHRASE"
+
+// EncryptionKey returns the configured key of the encrypted alias file:
+// the one in the key file, or else one derived from the passphrase in
+// PassphraseEnv or typed on the terminal.
+func EncryptionKey(cfg *config.Config) *crypt.Key {
+	if cfg.KeyFile != "" {
+		return crypt.KeyFile(cfg.KeyFile)
+	}
+	return crypt.Passphrase(crypt.ReadPassphrase(PassphraseEnv, "Passphrase: ", false))
+}
+
+// history returns the git repository versioning the alias file of
+// storageType, or nil when git history is off or the store is not a file
+// that can be versioned.
+func history(cfg *config.Config, fm *paths.FileManager, storageType string) *gitrepo.Store {
+	switch storageType {
+	case "json", "yaml", "toml":
+		if cfg.GitHistory {
+			return gitrepo.NewStore(fm.GetStoreFilePath(storageType))
+		}
+	}
+	return nil
+}
+
+// projectRepositories opens the project alias files of layers. Project
+// files come with the code around them, so each must be allowed with its
+// current contents, whoever owns it; the others are left out with a
+// warning. The layers of the allowed files are returned along with their
+// stores.
+func projectRepositories(cfg *config.Config, db *trust.DB, layers []*domain.Layer, logger *slog.Logger) ([]*domain.Layer, []repository.AliasRepository, error) {
+	var kept []*domain.Layer
+	var stores []repository.AliasRepository
+	for _, layer := range layers {
+		entry, err := db.CheckProject(layer.File)
+		var untrusted *trust.UntrustedError
+		if errors.As(err, &untrusted) {
+			logger.Warn("ignoring project alias file", "error", err)
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to check project alias file: %w", err)
+		}
+		var opts []filestore.Option
+		if entry != nil {
+			opts = append(opts, filestore.WithHook(trust.NewGuard(db, entry)))
+		}
+		kept = append(kept, layer)
+		stores = append(stores, projectRepository(cfg, layer.File, opts...))
+	}
+	return kept, stores, nil
+}
+
+// layerRepository puts the project alias stores of layers in front of
+// global.
+func layerRepository(global repository.AliasRepository, globalFile string, layers []*domain.Layer, repos []repository.AliasRepository) repository.AliasRepository {
+	stores := make([]layered.Layer, 0, len(layers)+1)
+	for i, layer := range layers {
+		stores = append(stores, layered.Layer{Layer: layer, Repo: repos[i]})
+	}
+	stores = append(stores, layered.Layer{
+		Layer: &domain.Layer{Name: domain.GlobalLayerName, File: globalFile},
+		Repo:  global,
+	})
+	return layered.NewAliasRepository(stores...)
+}
+
+// projectRepository opens a project alias file according to its
+// extension, with opts.
+func projectRepository(cfg *config.Config, file string, opts ...filestore.Option) repository.AliasRepository {
+	opts = append([]filestore.Option{filestore.WithLockTimeout(cfg.LockTimeout)}, opts...)
+	if filepath.Ext(file) == ".yaml" {
+		return yamlrepo.NewAliasRepository(file, opts...)
 	}
+	return jsonrepo.NewAliasRepository(file, opts...)
 }
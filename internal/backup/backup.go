@@ -0,0 +1,229 @@
+// Package backup keeps rotating snapshots of an alias file, taken before
+// each change so that a bad bulk edit can be undone.
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/msaglietto/mantrid/internal/fsutil"
+)
+
+// stampFormat is the UTC time in snapshot file names, which is also the ID
+// of a snapshot.
+const stampFormat = "20060102T150405.000000Z"
+
+// ErrNotFound is returned by Find for unknown snapshots.
+var ErrNotFound = errors.New("snapshot not found")
+
+// Snapshot is a copy of the alias file.
+type Snapshot struct {
+	ID      string
+	Path    string
+	Created time.Time
+	Size    int64
+}
+
+// Snapshots are the copies of an alias file kept in a directory, named
+// after the file and the time they were taken: aliases.json is copied to
+// aliases-20240102T150405.000000Z.json.
+type Snapshots struct {
+	file   string
+	dir    string
+	keep   int
+	maxAge time.Duration
+}
+
+// New returns the snapshots of the alias file file in dir. Taking a
+// snapshot removes all but the keep newest ones, and the ones older than
+// maxAge; zero turns either limit off.
+func New(file, dir string, keep int, maxAge time.Duration) *Snapshots {
+	return &Snapshots{file: file, dir: dir, keep: keep, maxAge: maxAge}
+}
+
+// Dir returns the directory holding the snapshots.
+func (s *Snapshots) Dir() string {
+	return s.dir
+}
+
+// Save copies the alias file to a new snapshot and rotates the old ones.
+// It returns nil when there is no alias file yet. The caller holds the
+// store lock, as the repositories do when they change the file.
+func (s *Snapshots) Save() (*Snapshot, error) {
+	data, err := os.ReadFile(s.file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	perm := os.FileMode(0600)
+	if info, err := os.Stat(s.file); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	id := time.Now().UTC().Format(stampFormat)
+	path := filepath.Join(s.dir, s.prefix()+id+filepath.Ext(s.file))
+	if err := fsutil.WriteFileAtomic(path, data, perm); err != nil {
+		return nil, err
+	}
+	if err := s.rotate(); err != nil {
+		return nil, fmt.Errorf("failed to remove old snapshots: %w", err)
+	}
+	return s.snapshot(path)
+}
+
+// Create takes a snapshot under the store lock, waiting up to timeout for
+// other processes changing the aliases.
+func (s *Snapshots) Create(timeout time.Duration) (*Snapshot, error) {
+	lock, err := fsutil.Lock(s.file+".lock", timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	snapshot, err := s.Save()
+	if err == nil && snapshot == nil {
+		err = fmt.Errorf("%s does not exist", s.file)
+	}
+	return snapshot, err
+}
+
+// CopyTo copies the alias file to path under the store lock, waiting up to
+// timeout for other processes changing the aliases.
+func (s *Snapshots) CopyTo(path string, timeout time.Duration) error {
+	lock, err := fsutil.Lock(s.file+".lock", timeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	data, err := os.ReadFile(s.file)
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(path, data, 0600)
+}
+
+// Rewrite replaces the contents of every snapshot with what fn returns for
+// them, such as when the alias file is encrypted with another key, and
+// returns how many it rewrote. The caller holds the store lock. Snapshots
+// fn fails for are left as they are and reported in the error, once the
+// others are rewritten.
+func (s *Snapshots) Rewrite(fn func(data []byte) ([]byte, error)) (int, error) {
+	snapshots, err := s.List()
+	if err != nil {
+		return 0, err
+	}
+
+	var errs []error
+	rewritten := 0
+	for _, snapshot := range snapshots {
+		if err := rewrite(snapshot.Path, fn); err != nil {
+			errs = append(errs, fmt.Errorf("snapshot %s: %w", snapshot.ID, err))
+			continue
+		}
+		rewritten++
+	}
+	return rewritten, errors.Join(errs...)
+}
+
+// rewrite replaces the contents of the file at path with what fn returns
+// for them, keeping its permissions.
+func rewrite(path string, fn func(data []byte) ([]byte, error)) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if data, err = fn(data); err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(path, data, info.Mode().Perm())
+}
+
+// List returns the snapshots, newest first.
+func (s *Snapshots) List() ([]Snapshot, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, s.prefix()+"*"+filepath.Ext(s.file)))
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []Snapshot
+	for _, path := range paths {
+		snapshot, err := s.snapshot(path)
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, *snapshot)
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Created.After(snapshots[j].Created)
+	})
+	return snapshots, nil
+}
+
+// Find returns the snapshot named by ref: its ID, file name or path, or
+// "latest" for the newest one.
+func (s *Snapshots) Find(ref string) (*Snapshot, error) {
+	snapshots, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	for i, snapshot := range snapshots {
+		if ref == snapshot.ID || ref == filepath.Base(snapshot.Path) || ref == snapshot.Path ||
+			(ref == "latest" && i == 0) {
+			return &snapshot, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNotFound, ref)
+}
+
+// rotate removes the snapshots beyond the count and age limits.
+func (s *Snapshots) rotate() error {
+	snapshots, err := s.List()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i, snapshot := range snapshots {
+		tooMany := s.keep > 0 && i >= s.keep
+		tooOld := s.maxAge > 0 && now.Sub(snapshot.Created) > s.maxAge
+		if !tooMany && !tooOld {
+			continue
+		}
+		if err := os.Remove(snapshot.Path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshot describes the snapshot file at path.
+func (s *Snapshots) snapshot(path string) (*Snapshot, error) {
+	id := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), s.prefix()), filepath.Ext(s.file))
+	created, err := time.Parse(stampFormat, id)
+	if err != nil {
+		return nil, fmt.Errorf("not a snapshot: %s", path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{ID: id, Path: path, Created: created, Size: info.Size()}, nil
+}
+
+// prefix is the start of the snapshot file names of the alias file.
+func (s *Snapshots) prefix() string {
+	base := filepath.Base(s.file)
+	return strings.TrimSuffix(base, filepath.Ext(base)) + "-"
+}
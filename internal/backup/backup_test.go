@@ -0,0 +1,82 @@
+package backup_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/msaglietto/mantrid/internal/backup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "aliases.json")
+	backups := filepath.Join(dir, "backups")
+
+	t.Run("no alias file yet", func(t *testing.T) {
+		snapshot, err := backup.New(file, backups, 3, 0).Save()
+		require.NoError(t, err)
+		assert.Nil(t, snapshot)
+	})
+
+	t.Run("keeps the newest snapshots", func(t *testing.T) {
+		snapshots := backup.New(file, backups, 3, 0)
+		for _, contents := range []string{"1", "2", "3", "4"} {
+			require.NoError(t, os.WriteFile(file, []byte(contents), 0600))
+			_, err := snapshots.Save()
+			require.NoError(t, err)
+		}
+
+		list, err := snapshots.List()
+		require.NoError(t, err)
+		require.Len(t, list, 3)
+		data, err := os.ReadFile(list[0].Path)
+		require.NoError(t, err)
+		assert.Equal(t, "4", string(data))
+		data, err = os.ReadFile(list[2].Path)
+		require.NoError(t, err)
+		assert.Equal(t, "2", string(data))
+
+		latest, err := snapshots.Find("latest")
+		require.NoError(t, err)
+		assert.Equal(t, list[0], *latest)
+		found, err := snapshots.Find(list[1].ID)
+		require.NoError(t, err)
+		assert.Equal(t, list[1].Path, found.Path)
+		found, err = snapshots.Find(filepath.Base(list[2].Path))
+		require.NoError(t, err)
+		assert.Equal(t, list[2].ID, found.ID)
+
+		_, err = snapshots.Find("20000101T000000.000000Z")
+		assert.True(t, errors.Is(err, backup.ErrNotFound))
+	})
+
+	t.Run("removes old snapshots", func(t *testing.T) {
+		old := filepath.Join(backups, "aliases-20200101T000000.000000Z.json")
+		require.NoError(t, os.WriteFile(old, []byte("old"), 0600))
+		other := filepath.Join(backups, "aliases-20200101T000000.000000Z.yaml")
+		require.NoError(t, os.WriteFile(other, []byte("yaml"), 0600))
+
+		_, err := backup.New(file, backups, 0, 24*time.Hour).Save()
+		require.NoError(t, err)
+
+		assert.NoFileExists(t, old)
+		assert.FileExists(t, other, "snapshots of other alias files are kept")
+		list, err := backup.New(file, backups, 0, 0).List()
+		require.NoError(t, err)
+		assert.Len(t, list, 4)
+	})
+
+	t.Run("copy", func(t *testing.T) {
+		output := filepath.Join(t.TempDir(), "copy.json")
+		require.NoError(t, backup.New(file, backups, 3, 0).CopyTo(output, time.Second))
+
+		data, err := os.ReadFile(output)
+		require.NoError(t, err)
+		assert.Equal(t, "4", string(data))
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -12,8 +13,28 @@ import (
 // Config holds all configuration values for the application
 type Config struct {
 	// Storage configuration
-	AliasFile   string `mapstructure:"alias_file"`
-	StorageType string `mapstructure:"storage_type"`
+	AliasFile string `mapstructure:"alias_file"`
+	// DatabaseFile is where the sqlite storage type keeps aliases
+	DatabaseFile string `mapstructure:"database_file"`
+	StorageType  string `mapstructure:"storage_type"`
+	// LockTimeout is how long a change waits for another mantrid process
+	// changing the alias file to finish
+	LockTimeout time.Duration `mapstructure:"lock_timeout"`
+	// GitHistory commits every change to the alias file to a git repository
+	// in its directory
+	GitHistory bool `mapstructure:"git_history"`
+	// Encrypt keeps the json alias file encrypted, with the key in KeyFile
+	// or derived from a passphrase when KeyFile is empty
+	Encrypt bool   `mapstructure:"encrypt"`
+	KeyFile string `mapstructure:"key_file"`
+	// BackupCount snapshots of the alias file are kept, taken before each
+	// change, for at most BackupMaxAge; zero turns either limit off
+	BackupCount  int           `mapstructure:"backup_count"`
+	BackupMaxAge time.Duration `mapstructure:"backup_max_age"`
+
+	// Alias resolution configuration
+	ResolvePrefix      bool   `mapstructure:"resolve_prefix"`
+	NamespaceSeparator string `mapstructure:"namespace_separator"`
 
 	// Logging configuration
 	LogLevel  string `mapstructure:"log_level"`
@@ -22,9 +43,14 @@ type Config struct {
 
 // defaultConfig provides default values for all configuration options
 var defaultConfig = Config{
-	StorageType: "json",
-	LogLevel:    "info",
-	LogFormat:   "json",
+	StorageType:        "json",
+	LockTimeout:        5 * time.Second,
+	BackupCount:        10,
+	BackupMaxAge:       30 * 24 * time.Hour,
+	ResolvePrefix:      true,
+	NamespaceSeparator: "/",
+	LogLevel:           "info",
+	LogFormat:          "json",
 }
 
 // Load reads the configuration from multiple sources in the following order:
@@ -45,12 +71,20 @@ func Load(configFilePath ...string) (*Config, error) {
 
 	// Set default values
 	v.SetDefault("storage_type", defaultConfig.StorageType)
+	v.SetDefault("lock_timeout", defaultConfig.LockTimeout)
+	v.SetDefault("git_history", defaultConfig.GitHistory)
+	v.SetDefault("encrypt", defaultConfig.Encrypt)
+	v.SetDefault("key_file", "")
+	v.SetDefault("backup_count", defaultConfig.BackupCount)
+	v.SetDefault("backup_max_age", defaultConfig.BackupMaxAge)
+	v.SetDefault("resolve_prefix", defaultConfig.ResolvePrefix)
+	v.SetDefault("namespace_separator", defaultConfig.NamespaceSeparator)
 	v.SetDefault("log_level", defaultConfig.LogLevel)
 	v.SetDefault("log_format", defaultConfig.LogFormat)
 
-	// Set up the default alias file path
-	defaultAliasFile := filepath.Join(getConfigDir(), "aliases.json")
-	v.SetDefault("alias_file", defaultAliasFile)
+	// The default alias file depends on the storage type, see below
+	v.SetDefault("alias_file", "")
+	v.SetDefault("database_file", filepath.Join(getConfigDir(), "aliases.db"))
 
 	// Determine config file path: explicit > env var > default
 	configFile := ""
@@ -86,9 +120,25 @@ func Load(configFilePath ...string) (*Config, error) {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
+	if cfg.AliasFile == "" {
+		cfg.AliasFile = filepath.Join(getConfigDir(), "aliases"+AliasFileExtension(cfg.StorageType))
+	}
+
 	return &cfg, nil
 }
 
+// AliasFileExtension returns the extension of the alias file of
+// storageType: ".yaml" and ".toml" for those storage types, ".json"
+// otherwise.
+func AliasFileExtension(storageType string) string {
+	switch storageType {
+	case "yaml", "toml":
+		return "." + storageType
+	default:
+		return ".json"
+	}
+}
+
 // getConfigDir returns the path to the configuration directory
 func getConfigDir() string {
 	homeDir, err := os.UserHomeDir()
@@ -114,12 +164,52 @@ func validateConfig(cfg *Config) error {
 	// Validate storage type
 	validStorageTypes := map[string]bool{
 		"json":   true,
+		"yaml":   true,
+		"toml":   true,
+		"sqlite": true,
 		"memory": true,
 	}
 	if !validStorageTypes[cfg.StorageType] {
 		return fmt.Errorf("invalid storage type: %s", cfg.StorageType)
 	}
 
+	// Validate lock timeout
+	if cfg.LockTimeout < 0 {
+		return fmt.Errorf("invalid lock timeout: %s", cfg.LockTimeout)
+	}
+
+	// Validate git history, which versions a single alias file
+	gitStorageTypes := map[string]bool{
+		"json": true,
+		"yaml": true,
+		"toml": true,
+	}
+	if cfg.GitHistory && !gitStorageTypes[cfg.StorageType] {
+		return fmt.Errorf("git history needs json, yaml or toml storage, not %s", cfg.StorageType)
+	}
+
+	// Validate backup rotation
+	if cfg.BackupCount < 0 {
+		return fmt.Errorf("invalid backup count: %d", cfg.BackupCount)
+	}
+	if cfg.BackupMaxAge < 0 {
+		return fmt.Errorf("invalid backup max age: %s", cfg.BackupMaxAge)
+	}
+
+	// Validate encryption, which wraps the json store
+	if cfg.Encrypt && cfg.StorageType != "json" {
+		return fmt.Errorf("encryption needs json storage, not %s", cfg.StorageType)
+	}
+
+	// Validate namespace separator
+	validNamespaceSeparators := map[string]bool{
+		"/": true,
+		".": true,
+	}
+	if !validNamespaceSeparators[cfg.NamespaceSeparator] {
+		return fmt.Errorf("invalid namespace separator: %s", cfg.NamespaceSeparator)
+	}
+
 	// Validate log format
 	validLogFormats := map[string]bool{
 		"json": true,
@@ -136,7 +226,28 @@ func validateConfig(cfg *Config) error {
 func ExampleConfig() string {
 	return `# Storage configuration
 alias_file: "~/.mantrid/aliases.json"
+# "json", "yaml" or "toml" for a file to read and review by hand (the
+# default alias_file then ends in .yaml or .toml), or "sqlite" for large
+# stores, kept in database_file
 storage_type: "json"
+database_file: "~/.mantrid/aliases.db"
+# How long a change waits while another mantrid process changes the alias file
+lock_timeout: "5s"
+# Commit every change to the alias file to a git repository in its directory
+git_history: false
+# Encrypt the alias file (json storage only), with the key in key_file or a
+# passphrase from MANTRID_PASSPHRASE or the terminal
+encrypt: false
+key_file: ""
+# Snapshots of the alias file taken before each change, in backups/ next to
+# it: how many to keep (0 turns them off) and for how long (0 for ever)
+backup_count: 10
+backup_max_age: "720h"
+
+# Alias resolution: run an alias from a unique prefix of its name
+resolve_prefix: true
+# Separator for namespaced alias names such as "k8s/prod/logs" ("/" or ".")
+namespace_separator: "/"
 
 # Logging configuration
 log_level: "info"
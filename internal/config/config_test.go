@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/msaglietto/mantrid/internal/config"
 	"github.com/stretchr/testify/assert"
@@ -20,6 +21,12 @@ func TestConfig(t *testing.T) {
 		assert.Equal(t, "json", cfg.StorageType)
 		assert.Equal(t, "info", cfg.LogLevel)
 		assert.Equal(t, "json", cfg.LogFormat)
+		assert.True(t, cfg.ResolvePrefix)
+		assert.Equal(t, "/", cfg.NamespaceSeparator)
+		assert.Equal(t, 5*time.Second, cfg.LockTimeout)
+		assert.False(t, cfg.GitHistory)
+		assert.Equal(t, 10, cfg.BackupCount)
+		assert.Equal(t, 720*time.Hour, cfg.BackupMaxAge)
 	})
 
 	t.Run("configuration from file", func(t *testing.T) {
@@ -31,6 +38,7 @@ func TestConfig(t *testing.T) {
 		configContent := []byte(`storage_type: "memory"
 log_level: "debug"
 log_format: "text"
+lock_timeout: "250ms"
 `)
 		err := os.WriteFile(configPath, configContent, 0644)
 		require.NoError(t, err)
@@ -47,6 +55,7 @@ log_format: "text"
 		assert.Equal(t, "memory", cfg.StorageType)
 		assert.Equal(t, "debug", cfg.LogLevel)
 		assert.Equal(t, "text", cfg.LogFormat)
+		assert.Equal(t, 250*time.Millisecond, cfg.LockTimeout)
 	})
 
 	t.Run("configuration from environment variables", func(t *testing.T) {
@@ -67,6 +76,31 @@ log_format: "text"
 		assert.Equal(t, "memory", cfg.StorageType)
 	})
 
+	t.Run("sqlite storage", func(t *testing.T) {
+		os.Setenv("MANTRID_STORAGE_TYPE", "sqlite")
+		defer os.Unsetenv("MANTRID_STORAGE_TYPE")
+
+		cfg, err := config.Load()
+		require.NoError(t, err)
+		assert.Equal(t, "sqlite", cfg.StorageType)
+		assert.Equal(t, "aliases.db", filepath.Base(cfg.DatabaseFile))
+	})
+
+	t.Run("alias file follows the storage type", func(t *testing.T) {
+		os.Setenv("MANTRID_STORAGE_TYPE", "toml")
+		defer os.Unsetenv("MANTRID_STORAGE_TYPE")
+
+		cfg, err := config.Load()
+		require.NoError(t, err)
+		assert.Equal(t, "aliases.toml", filepath.Base(cfg.AliasFile))
+
+		os.Setenv("MANTRID_ALIAS_FILE", "/dotfiles/aliases.toml")
+		defer os.Unsetenv("MANTRID_ALIAS_FILE")
+		cfg, err = config.Load()
+		require.NoError(t, err)
+		assert.Equal(t, "/dotfiles/aliases.toml", cfg.AliasFile)
+	})
+
 	t.Run("invalid configuration", func(t *testing.T) {
 		os.Setenv("MANTRID_LOG_LEVEL", "invalid")
 		defer os.Unsetenv("MANTRID_LOG_LEVEL")
@@ -76,6 +110,61 @@ log_format: "text"
 		assert.Contains(t, err.Error(), "invalid log level")
 	})
 
+	t.Run("invalid namespace separator", func(t *testing.T) {
+		os.Setenv("MANTRID_NAMESPACE_SEPARATOR", ":")
+		defer os.Unsetenv("MANTRID_NAMESPACE_SEPARATOR")
+
+		_, err := config.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid namespace separator")
+	})
+
+	t.Run("invalid lock timeout", func(t *testing.T) {
+		os.Setenv("MANTRID_LOCK_TIMEOUT", "-1s")
+		defer os.Unsetenv("MANTRID_LOCK_TIMEOUT")
+
+		_, err := config.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid lock timeout")
+	})
+
+	t.Run("git history needs an alias file", func(t *testing.T) {
+		os.Setenv("MANTRID_GIT_HISTORY", "true")
+		os.Setenv("MANTRID_STORAGE_TYPE", "sqlite")
+		defer func() {
+			os.Unsetenv("MANTRID_GIT_HISTORY")
+			os.Unsetenv("MANTRID_STORAGE_TYPE")
+		}()
+
+		_, err := config.Load()
+		assert.ErrorContains(t, err, "git history needs json, yaml or toml storage")
+
+		os.Setenv("MANTRID_STORAGE_TYPE", "yaml")
+		cfg, err := config.Load()
+		require.NoError(t, err)
+		assert.True(t, cfg.GitHistory)
+	})
+
+	t.Run("encryption needs json storage", func(t *testing.T) {
+		os.Setenv("MANTRID_ENCRYPT", "true")
+		os.Setenv("MANTRID_STORAGE_TYPE", "sqlite")
+		defer func() {
+			os.Unsetenv("MANTRID_ENCRYPT")
+			os.Unsetenv("MANTRID_STORAGE_TYPE")
+		}()
+
+		_, err := config.Load()
+		assert.ErrorContains(t, err, "encryption needs json storage")
+	})
+
+	t.Run("invalid backup count", func(t *testing.T) {
+		os.Setenv("MANTRID_BACKUP_COUNT", "-1")
+		defer os.Unsetenv("MANTRID_BACKUP_COUNT")
+
+		_, err := config.Load()
+		assert.ErrorContains(t, err, "invalid backup count")
+	})
+
 	t.Run("invalid log format", func(t *testing.T) {
 		os.Setenv("MANTRID_LOG_FORMAT", "xml")
 		defer os.Unsetenv("MANTRID_LOG_FORMAT")
@@ -0,0 +1,264 @@
+// Package crypt encrypts alias files at rest with AES-256-GCM, keyed by a
+// key file or by a passphrase stretched with scrypt.
+//
+// An encrypted file is a JSON envelope naming how its key is obtained, so
+// that opening it with the wrong kind of key is reported as such. The
+// envelope header is authenticated along with the aliases.
+package crypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// format marks the envelope of an encrypted alias file.
+const format = "mantrid-encrypted"
+
+// The ways the key of a file is obtained.
+const (
+	kdfScrypt  = "scrypt"
+	kdfKeyFile = "key-file"
+)
+
+// scrypt parameters of new passphrase-encrypted files.
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// keySize is the size of AES-256 keys and of the keys in key files.
+const keySize = 32
+
+var (
+	// ErrSealed is returned when reading an encrypted alias file without
+	// a key.
+	ErrSealed = errors.New("alias file is encrypted and no key is configured")
+	// ErrNotSealed is returned when an alias file expected to be encrypted
+	// is plaintext.
+	ErrNotSealed = errors.New("alias file is not encrypted")
+	// ErrWrongKey is returned when a file cannot be decrypted with the key,
+	// because the key is wrong or the file was tampered with.
+	ErrWrongKey = errors.New("wrong key or passphrase, or the alias file was tampered with")
+)
+
+// envelope is the layout of an encrypted alias file.
+type envelope struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+	Cipher  string `json:"cipher"`
+	KDF     string `json:"kdf"`
+	Salt    []byte `json:"salt,omitempty"`
+	N       int    `json:"n,omitempty"`
+	R       int    `json:"r,omitempty"`
+	P       int    `json:"p,omitempty"`
+	Nonce   []byte `json:"nonce,omitempty"`
+	Data    []byte `json:"data,omitempty"`
+}
+
+// header returns the authenticated part of e: everything but the nonce and
+// the ciphertext.
+func (e envelope) header() []byte {
+	e.Nonce, e.Data = nil, nil
+	data, _ := json.Marshal(e)
+	return data
+}
+
+// IsSealed reports whether data is an encrypted alias file.
+func IsSealed(data []byte) bool {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return false
+	}
+	var e envelope
+	return json.Unmarshal(data, &e) == nil && e.Format == format
+}
+
+// Key encrypts and decrypts alias files. Its secret is only read when
+// first needed, so that commands not touching the aliases never prompt
+// for a passphrase.
+type Key struct {
+	kdf    string
+	secret func() ([]byte, error)
+
+	once      sync.Once
+	value     []byte
+	secretErr error
+
+	mu sync.Mutex
+	// derived caches the last key derived from the passphrase, with the
+	// salt it was derived with
+	salt    []byte
+	derived []byte
+}
+
+// KeyFile returns the key read from the key file at path.
+func KeyFile(path string) *Key {
+	return &Key{kdf: kdfKeyFile, secret: func() ([]byte, error) {
+		return readKeyFile(path)
+	}}
+}
+
+// Passphrase returns the key derived from the passphrase returned by
+// source.
+func Passphrase(source func() ([]byte, error)) *Key {
+	return &Key{kdf: kdfScrypt, secret: source}
+}
+
+// Describe says how k is obtained, for messages.
+func (k *Key) Describe() string {
+	return describe(k.kdf)
+}
+
+// Seal encrypts plaintext into an envelope.
+func (k *Key) Seal(plaintext []byte) ([]byte, error) {
+	e := envelope{Format: format, Version: 1, Cipher: "aes-256-gcm", KDF: k.kdf}
+	if k.kdf == kdfScrypt {
+		e.N, e.R, e.P = scryptN, scryptR, scryptP
+	}
+
+	k.mu.Lock()
+	e.Salt = k.salt
+	k.mu.Unlock()
+	if k.kdf == kdfScrypt && e.Salt == nil {
+		e.Salt = make([]byte, 16)
+		if _, err := rand.Read(e.Salt); err != nil {
+			return nil, err
+		}
+	}
+
+	aead, err := k.aead(e)
+	if err != nil {
+		return nil, err
+	}
+	e.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(e.Nonce); err != nil {
+		return nil, err
+	}
+	e.Data = aead.Seal(nil, e.Nonce, plaintext, e.header())
+
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Open decrypts the envelope in data.
+func (k *Key) Open(data []byte) ([]byte, error) {
+	var e envelope
+	if err := json.Unmarshal(data, &e); err != nil || e.Format != format {
+		return nil, ErrNotSealed
+	}
+	if e.Version != 1 || e.Cipher != "aes-256-gcm" {
+		return nil, fmt.Errorf("unsupported encryption: version %d, cipher %s", e.Version, e.Cipher)
+	}
+	if e.KDF != k.kdf {
+		return nil, fmt.Errorf("alias file is encrypted with a %s, not a %s", describe(e.KDF), k.Describe())
+	}
+
+	aead, err := k.aead(e)
+	if err != nil {
+		return nil, err
+	}
+	if len(e.Nonce) != aead.NonceSize() {
+		return nil, ErrWrongKey
+	}
+	plaintext, err := aead.Open(nil, e.Nonce, e.Data, e.header())
+	if err != nil {
+		return nil, ErrWrongKey
+	}
+	return plaintext, nil
+}
+
+// aead returns the cipher for the envelope e, deriving its key from the
+// passphrase when needed.
+func (k *Key) aead(e envelope) (cipher.AEAD, error) {
+	k.once.Do(func() {
+		k.value, k.secretErr = k.secret()
+	})
+	if k.secretErr != nil {
+		return nil, k.secretErr
+	}
+
+	key := k.value
+	if k.kdf == kdfScrypt {
+		var err error
+		if key, err = k.derive(e); err != nil {
+			return nil, err
+		}
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// derive stretches the passphrase with the scrypt parameters of e. The
+// last derived key is reused, as derivation is slow on purpose.
+func (k *Key) derive(e envelope) ([]byte, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.derived != nil && bytes.Equal(k.salt, e.Salt) {
+		return k.derived, nil
+	}
+	key, err := scrypt.Key(k.value, e.Salt, e.N, e.R, e.P, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scrypt parameters: %w", err)
+	}
+	k.salt, k.derived = e.Salt, key
+	return key, nil
+}
+
+// describe names the kind of key of kdf.
+func describe(kdf string) string {
+	if kdf == kdfKeyFile {
+		return "key file"
+	}
+	return "passphrase"
+}
+
+// GenerateKeyFile writes a new random key to path, readable only by the
+// current user. An existing file is left alone.
+func GenerateKeyFile(path string) error {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(f, base64.StdEncoding.EncodeToString(key)); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readKeyFile reads the base64 key in the key file at path.
+func readKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != keySize {
+		return nil, fmt.Errorf("invalid key file %s: want %d base64-encoded bytes", path, keySize)
+	}
+	return key, nil
+}
@@ -0,0 +1,85 @@
+package crypt_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/msaglietto/mantrid/internal/crypt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func passphrase(p string) *crypt.Key {
+	return crypt.Passphrase(func() ([]byte, error) { return []byte(p), nil })
+}
+
+func TestKey(t *testing.T) {
+	plaintext := []byte(`[{"name":"db","command":"psql -h db.internal"}]`)
+
+	t.Run("passphrase", func(t *testing.T) {
+		sealed, err := passphrase("correct horse").Seal(plaintext)
+		require.NoError(t, err)
+		assert.True(t, crypt.IsSealed(sealed))
+		assert.NotContains(t, string(sealed), "db.internal")
+
+		opened, err := passphrase("correct horse").Open(sealed)
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, opened)
+
+		_, err = passphrase("wrong").Open(sealed)
+		assert.ErrorIs(t, err, crypt.ErrWrongKey)
+	})
+
+	t.Run("key file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "aliases.key")
+		require.NoError(t, crypt.GenerateKeyFile(path))
+		assert.Error(t, crypt.GenerateKeyFile(path), "existing key files are kept")
+
+		sealed, err := crypt.KeyFile(path).Seal(plaintext)
+		require.NoError(t, err)
+		opened, err := crypt.KeyFile(path).Open(sealed)
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, opened)
+
+		_, err = passphrase("correct horse").Open(sealed)
+		assert.ErrorContains(t, err, "encrypted with a key file, not a passphrase")
+
+		other := filepath.Join(t.TempDir(), "other.key")
+		require.NoError(t, crypt.GenerateKeyFile(other))
+		_, err = crypt.KeyFile(other).Open(sealed)
+		assert.ErrorIs(t, err, crypt.ErrWrongKey)
+	})
+
+	t.Run("invalid key file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "aliases.key")
+		require.NoError(t, os.WriteFile(path, []byte("too short\n"), 0600))
+
+		_, err := crypt.KeyFile(path).Seal(plaintext)
+		assert.ErrorContains(t, err, "invalid key file")
+	})
+
+	t.Run("tampered header", func(t *testing.T) {
+		key := passphrase("correct horse")
+		sealed, err := key.Seal(plaintext)
+		require.NoError(t, err)
+
+		var envelope map[string]any
+		require.NoError(t, json.Unmarshal(sealed, &envelope))
+		envelope["p"] = 2
+		tampered, err := json.Marshal(envelope)
+		require.NoError(t, err)
+
+		_, err = key.Open(tampered)
+		assert.ErrorIs(t, err, crypt.ErrWrongKey)
+	})
+
+	t.Run("plaintext", func(t *testing.T) {
+		assert.False(t, crypt.IsSealed(plaintext))
+		assert.False(t, crypt.IsSealed([]byte(`{"aliases":[]}`)))
+
+		_, err := passphrase("correct horse").Open(plaintext)
+		assert.ErrorIs(t, err, crypt.ErrNotSealed)
+	})
+}
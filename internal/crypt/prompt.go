@@ -0,0 +1,57 @@
+package crypt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// ReadPassphrase returns a passphrase source for Passphrase: the value of
+// the environment variable env, or else a passphrase typed on the
+// terminal after prompt. With confirm, a typed passphrase is asked for
+// twice, as when encrypting with a new one.
+func ReadPassphrase(env, prompt string, confirm bool) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		if value := os.Getenv(env); value != "" {
+			return []byte(value), nil
+		}
+
+		fd := int(os.Stdin.Fd())
+		if !term.IsTerminal(fd) {
+			return nil, fmt.Errorf("no passphrase: set %s or key_file in the config file", env)
+		}
+
+		passphrase, err := readTerminal(fd, prompt)
+		if err != nil {
+			return nil, err
+		}
+		if len(passphrase) == 0 {
+			return nil, errors.New("empty passphrase")
+		}
+		if confirm {
+			again, err := readTerminal(fd, "Repeat the passphrase: ")
+			if err != nil {
+				return nil, err
+			}
+			if !bytes.Equal(passphrase, again) {
+				return nil, errors.New("passphrases do not match")
+			}
+		}
+		return passphrase, nil
+	}
+}
+
+// readTerminal reads a line from the terminal fd without echoing it.
+func readTerminal(fd int, prompt string) ([]byte, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	defer fmt.Fprintln(os.Stderr)
+
+	passphrase, err := term.ReadPassword(fd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return passphrase, nil
+}
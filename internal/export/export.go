@@ -0,0 +1,148 @@
+// Package export writes aliases as native definitions of shells and task
+// runners, so that they can be used without mantrid.
+package export
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/internal/shell"
+)
+
+// Supported export formats besides the shells.
+const (
+	Make        = "make"
+	Just        = "just"
+	VSCodeTasks = "vscode-tasks"
+)
+
+// Formats lists the supported export formats.
+var Formats = []string{shell.Bash, shell.Zsh, shell.Fish, shell.PowerShell, Make, Just, VSCodeTasks}
+
+// Warning reports an alias that could not be exported, or not exactly.
+type Warning struct {
+	Alias   string
+	Message string
+	// Skipped is set when the alias was left out of the export.
+	Skipped bool
+}
+
+func (w Warning) String() string {
+	if w.Skipped {
+		return fmt.Sprintf("%s: skipped: %s", w.Alias, w.Message)
+	}
+	return fmt.Sprintf("%s: %s", w.Alias, w.Message)
+}
+
+// warnings collects the warnings of an export.
+type warnings []Warning
+
+func (ws *warnings) add(alias *domain.Alias, format string, args ...any) {
+	*ws = append(*ws, Warning{Alias: alias.Name, Message: fmt.Sprintf(format, args...)})
+}
+
+func (ws *warnings) skip(alias *domain.Alias, format string, args ...any) {
+	*ws = append(*ws, Warning{Alias: alias.Name, Message: fmt.Sprintf(format, args...), Skipped: true})
+}
+
+// Export writes aliases to w in format and returns what could not be
+// represented in it. Aliases that cannot be exported at all are left out.
+func Export(w io.Writer, format string, aliases []*domain.Alias) ([]Warning, error) {
+	var ws warnings
+	var err error
+	switch format {
+	case shell.Bash, shell.Zsh:
+		err = writePosix(w, format, aliases, &ws)
+	case shell.Fish:
+		err = writeFish(w, aliases, &ws)
+	case shell.PowerShell:
+		err = writePowerShell(w, aliases, &ws)
+	case Make:
+		err = writeMakefile(w, aliases, &ws)
+	case Just:
+		err = writeJustfile(w, aliases, &ws)
+	case VSCodeTasks:
+		err = writeVSCodeTasks(w, aliases, &ws)
+	default:
+		return nil, fmt.Errorf("unsupported export format %q: must be one of %s", format, strings.Join(Formats, ", "))
+	}
+	return ws, err
+}
+
+// placeholderRe matches the placeholders mantrid substitutes: $N, $@ and $*.
+var placeholderRe = regexp.MustCompile(`\$([0-9]+)|\$(@)|\$(\*)`)
+
+// placeholder is a parameter reference in an alias command: a positional
+// parameter counted from 1, or 0 for all of them.
+type placeholder int
+
+const allParams placeholder = 0
+
+// syntax describes how a format refers to parameters.
+type syntax struct {
+	// param returns the reference to a parameter in place of a placeholder.
+	param func(placeholder) string
+	// rest is appended to commands without placeholders, to which mantrid
+	// appends the parameters.
+	rest string
+	// escape, if set, escapes the text between placeholders.
+	escape func(string) string
+}
+
+// translate rewrites the placeholders of command in s. $0 is not a mantrid
+// placeholder and is kept as it is.
+func (s syntax) translate(command string) string {
+	escape := s.escape
+	if escape == nil {
+		escape = func(text string) string { return text }
+	}
+
+	var b strings.Builder
+	found := false
+	last := 0
+	for _, loc := range placeholderRe.FindAllStringIndex(command, -1) {
+		match := command[loc[0]:loc[1]]
+		p := allParams
+		if match != "$@" && match != "$*" {
+			n, err := strconv.Atoi(match[1:])
+			if err != nil || n == 0 {
+				continue
+			}
+			p = placeholder(n)
+		}
+		found = true
+		b.WriteString(escape(command[last:loc[0]]))
+		b.WriteString(s.param(p))
+		last = loc[1]
+	}
+	b.WriteString(escape(command[last:]))
+	if !found {
+		b.WriteString(s.rest)
+	}
+	return b.String()
+}
+
+// maxPlaceholder returns the highest positional placeholder in command.
+func maxPlaceholder(command string) int {
+	highest := 0
+	for _, m := range placeholderRe.FindAllStringSubmatch(command, -1) {
+		if n, err := strconv.Atoi(m[1]); err == nil && n > highest {
+			highest = n
+		}
+	}
+	return highest
+}
+
+// hasPlaceholders reports whether command refers to its parameters.
+func hasPlaceholders(command string) bool {
+	return maxPlaceholder(command) > 0 || strings.Contains(command, "$@") || strings.Contains(command, "$*")
+}
+
+// header is the comment line opening generated files.
+func header(format string) string {
+	return "Generated by mantrid alias export --to " + format
+}
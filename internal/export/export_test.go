@@ -0,0 +1,183 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/internal/export"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testAliases() []*domain.Alias {
+	return []*domain.Alias{
+		{Name: "greet", Command: `echo "Hello, $1!"`, Description: "Greet someone"},
+		{Name: "k8s/get", Command: "kubectl get"},
+		{Name: "ll", Command: "ls -la"},
+		{Name: "top", Command: "echo $@ $10", WorkDir: "/srv/app"},
+	}
+}
+
+func exportString(t *testing.T, format string, aliases []*domain.Alias) (string, []export.Warning) {
+	t.Helper()
+	var buf bytes.Buffer
+	warnings, err := export.Export(&buf, format, aliases)
+	require.NoError(t, err)
+	return buf.String(), warnings
+}
+
+func TestExportBash(t *testing.T) {
+	out, warnings := exportString(t, "bash", testAliases())
+
+	assert.Contains(t, out, "# Greet someone\ngreet() {\n    echo \"Hello, $1!\"\n}\n")
+	assert.Contains(t, out, "alias ll='ls -la'\n")
+	assert.Contains(t, out, "top() {\n    (cd '/srv/app' && echo $@ ${10})\n}\n")
+	assert.NotContains(t, out, "k8s")
+	require.Len(t, warnings, 1)
+	assert.True(t, warnings[0].Skipped)
+	assert.Equal(t, `k8s/get: skipped: "k8s/get" is not a valid bash function name`, warnings[0].String())
+}
+
+func TestExportBashRuns(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+	out, _ := exportString(t, "bash", []*domain.Alias{
+		{Name: "greet", Command: `echo "Hello, $1!"`},
+		{Name: "say", Command: "echo"},
+	})
+
+	script := out + "greet World\nsay a  b\n"
+	got, err := exec.Command(bash, "-O", "expand_aliases", "-c", script).CombinedOutput()
+	require.NoError(t, err, string(got))
+	assert.Equal(t, "Hello, World!\na b\n", string(got))
+}
+
+func TestExportFish(t *testing.T) {
+	aliases := append(testAliases(), &domain.Alias{Name: "now", Command: "echo `date`"})
+	out, warnings := exportString(t, "fish", aliases)
+
+	assert.Contains(t, out, "function greet --description 'Greet someone'\n    echo \"Hello, $argv[1]!\"\nend\n")
+	assert.Contains(t, out, "function ll\n    ls -la $argv\nend\n")
+	assert.Contains(t, out, "    pushd '/srv/app'; or return\n    echo $argv $argv[10]\n")
+	require.Len(t, warnings, 2)
+	assert.Equal(t, "now: fish does not support backtick command substitution; exported as is", warnings[1].String())
+}
+
+func TestExportPowerShell(t *testing.T) {
+	out, _ := exportString(t, "pwsh", testAliases())
+
+	assert.Contains(t, out, "function greet {\n    echo \"Hello, $($args[0])!\"\n}\n")
+	assert.Contains(t, out, "function ll {\n    ls -la @args\n}\n")
+	assert.Contains(t, out, "Push-Location '/srv/app'\n    try {\n        echo $args $($args[9])\n    } finally {")
+}
+
+func TestExportMakefile(t *testing.T) {
+	aliases := append(testAliases(), &domain.Alias{Name: "home", Command: "echo $HOME\necho done"})
+	out, warnings := exportString(t, "make", aliases)
+
+	assert.Contains(t, out, ".PHONY: greet k8s/get ll top home\n")
+	assert.Contains(t, out, "greet: ## Greet someone\n\t@echo \"Hello, $(ARG1)!\"\n")
+	assert.Contains(t, out, "k8s/get:\n\t@kubectl get $(ARGS)\n")
+	assert.Contains(t, out, "top:\n\t@cd '/srv/app' && echo $(ARGS) $(ARG10)\n")
+	assert.Contains(t, out, "home:\n\t@echo $$HOME\n\t@echo done $(ARGS)\n")
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0].String(), "home: the command spans several lines")
+	assert.False(t, warnings[0].Skipped)
+}
+
+func TestExportMakefileRuns(t *testing.T) {
+	makePath, err := exec.LookPath("make")
+	if err != nil {
+		t.Skip("make not available")
+	}
+	out, _ := exportString(t, "make", []*domain.Alias{
+		{Name: "greet", Command: `echo "Hello, $1!" $$`},
+		{Name: "say", Command: "echo"},
+	})
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Makefile"), []byte(out), 0600))
+
+	got, err := exec.Command(makePath, "-s", "-C", dir, "greet", "ARG1=World").CombinedOutput()
+	require.NoError(t, err, string(got))
+	assert.Regexp(t, `^Hello, World! \d+\n$`, string(got))
+
+	got, err = exec.Command(makePath, "-s", "-C", dir, "say", "ARGS=a b").CombinedOutput()
+	require.NoError(t, err, string(got))
+	assert.Equal(t, "a b\n", string(got))
+}
+
+func TestExportJustfile(t *testing.T) {
+	aliases := append(testAliases(),
+		&domain.Alias{Name: "k8s.get", Command: "kubectl get"},
+		&domain.Alias{Name: "tpl", Command: "echo {{x}}"},
+		&domain.Alias{Name: "set", Command: "echo set"},
+	)
+	out, warnings := exportString(t, "just", aliases)
+
+	assert.Contains(t, out, "set positional-arguments\n")
+	assert.Contains(t, out, "# Greet someone\ngreet *args:\n    @echo \"Hello, $1!\"\n")
+	assert.Contains(t, out, "k8s-get *args:\n    @kubectl get \"$@\"\n")
+	assert.Contains(t, out, "tpl *args:\n    @echo {{{{x}} \"$@\"\n")
+	assert.NotContains(t, out, "\nset *args")
+
+	messages := []string{}
+	for _, w := range warnings {
+		messages = append(messages, w.String())
+	}
+	assert.Equal(t, []string{
+		`k8s/get: exported as recipe "k8s-get"`,
+		`k8s.get: skipped: recipe "k8s-get" is already exported for k8s/get`,
+		`set: skipped: "set" is not a valid just recipe name`,
+	}, messages)
+}
+
+func TestExportVSCodeTasks(t *testing.T) {
+	aliases := append(testAliases(), &domain.Alias{Name: "ws", Command: "code ${workspaceFolder}"})
+	out, warnings := exportString(t, "vscode-tasks", aliases)
+
+	var file struct {
+		Version string `json:"version"`
+		Tasks   []struct {
+			Label   string `json:"label"`
+			Type    string `json:"type"`
+			Command string `json:"command"`
+			Detail  string `json:"detail"`
+			Options struct {
+				Cwd string `json:"cwd"`
+			} `json:"options"`
+		} `json:"tasks"`
+		Inputs []struct {
+			ID string `json:"id"`
+		} `json:"inputs"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &file))
+	assert.Equal(t, "2.0.0", file.Version)
+	require.Len(t, file.Tasks, 5)
+	assert.Equal(t, `echo "Hello, ${input:greet-1}!"`, file.Tasks[0].Command)
+	assert.Equal(t, "Greet someone", file.Tasks[0].Detail)
+	assert.Equal(t, "shell", file.Tasks[0].Type)
+	assert.Equal(t, "kubectl get", file.Tasks[1].Command)
+	assert.Equal(t, "echo ${input:top-args} ${input:top-10}", file.Tasks[3].Command)
+	assert.Equal(t, "/srv/app", file.Tasks[3].Options.Cwd)
+
+	ids := []string{}
+	for _, input := range file.Inputs {
+		ids = append(ids, input.ID)
+	}
+	assert.Equal(t, []string{"greet-1", "top-args", "top-10"}, ids)
+
+	require.Len(t, warnings, 1)
+	assert.Equal(t, "ws", warnings[0].Alias)
+}
+
+func TestExportUnsupportedFormat(t *testing.T) {
+	_, err := export.Export(&bytes.Buffer{}, "cmd", nil)
+	assert.ErrorContains(t, err, `unsupported export format "cmd"`)
+}
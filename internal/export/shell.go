@@ -0,0 +1,179 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/internal/shell"
+)
+
+// posixParam refers to a parameter in bash and zsh.
+func posixParam(p placeholder) string {
+	switch {
+	case p == allParams:
+		return "$@"
+	case p > 9:
+		return fmt.Sprintf("${%d}", p)
+	}
+	return fmt.Sprintf("$%d", p)
+}
+
+// writePosix writes aliases as bash or zsh definitions: a plain alias when
+// that is enough, a function otherwise.
+func writePosix(w io.Writer, sh string, aliases []*domain.Alias, ws *warnings) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", header(sh))
+
+	syn := syntax{param: posixParam, rest: ` "$@"`}
+	for _, alias := range aliases {
+		if err := shell.ValidFunctionName(sh, alias.Name); err != nil {
+			ws.skip(alias, "%v", err)
+			continue
+		}
+
+		b.WriteString("\n")
+		writeComment(&b, "", alias.Description)
+		if !hasPlaceholders(alias.Command) && alias.WorkDir == "" {
+			fmt.Fprintf(&b, "alias %s=%s\n", alias.Name, shell.Quote(sh, alias.Command))
+			continue
+		}
+
+		body := syn.translate(alias.Command)
+		if alias.WorkDir != "" {
+			body = fmt.Sprintf("(cd %s && %s)", shell.Quote(sh, alias.WorkDir), body)
+		}
+		fmt.Fprintf(&b, "%s() {\n%s\n}\n", alias.Name, indent(body, "    "))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeFish writes aliases as fish functions.
+func writeFish(w io.Writer, aliases []*domain.Alias, ws *warnings) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", header(shell.Fish))
+
+	syn := syntax{
+		param: func(p placeholder) string {
+			if p == allParams {
+				return "$argv"
+			}
+			return fmt.Sprintf("$argv[%d]", p)
+		},
+		rest: " $argv",
+	}
+	for _, alias := range aliases {
+		if err := shell.ValidFunctionName(shell.Fish, alias.Name); err != nil {
+			ws.skip(alias, "%v", err)
+			continue
+		}
+		warnUnsupported(alias, "fish", fishUnsupported, ws)
+
+		b.WriteString("\nfunction " + alias.Name)
+		if alias.Description != "" {
+			b.WriteString(" --description " + shell.Quote(shell.Fish, alias.Description))
+		}
+		b.WriteString("\n")
+
+		body := syn.translate(alias.Command)
+		if alias.WorkDir != "" {
+			body = fmt.Sprintf("pushd %s; or return\n%s\nset -l ret $status\npopd\nreturn $ret",
+				shell.Quote(shell.Fish, alias.WorkDir), body)
+		}
+		b.WriteString(indent(body, "    ") + "\nend\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writePowerShell writes aliases as PowerShell functions. Commands are kept
+// as they are apart from their placeholders, so they must be valid
+// PowerShell already.
+func writePowerShell(w io.Writer, aliases []*domain.Alias, ws *warnings) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", header(shell.PowerShell))
+
+	syn := syntax{
+		param: func(p placeholder) string {
+			if p == allParams {
+				return "$args"
+			}
+			// $(...) so that the index also applies inside strings
+			return fmt.Sprintf("$($args[%d])", p-1)
+		},
+		rest: " @args",
+	}
+	for _, alias := range aliases {
+		if err := shell.ValidFunctionName(shell.PowerShell, alias.Name); err != nil {
+			ws.skip(alias, "%v", err)
+			continue
+		}
+		warnUnsupported(alias, "PowerShell", powerShellUnsupported, ws)
+
+		b.WriteString("\n")
+		writeComment(&b, "", alias.Description)
+		body := syn.translate(alias.Command)
+		if alias.WorkDir != "" {
+			body = fmt.Sprintf("Push-Location %s\ntry {\n%s\n} finally {\n    Pop-Location\n}",
+				shell.Quote(shell.PowerShell, alias.WorkDir), indent(body, "    "))
+		}
+		fmt.Fprintf(&b, "function %s {\n%s\n}\n", alias.Name, indent(body, "    "))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// construct is a piece of POSIX shell syntax other shells do not share.
+type construct struct {
+	pattern     *regexp.Regexp
+	description string
+}
+
+var (
+	backticks    = construct{regexp.MustCompile("`"), "backtick command substitution"}
+	braceExpand  = construct{regexp.MustCompile(`\$\{`), "${...} parameter expansion"}
+	arithmetic   = construct{regexp.MustCompile(`\$\(\(`), "$((...)) arithmetic"}
+	heredoc      = construct{regexp.MustCompile(`<<`), "here-documents"}
+	testCommand  = construct{regexp.MustCompile(`\[\[`), "[[ ... ]] tests"}
+	envPrefix    = construct{regexp.MustCompile(`(?:^|[;&|]\s*)[A-Za-z_][A-Za-z0-9_]*=\S`), "VAR=value command prefixes"}
+	envReference = construct{regexp.MustCompile(`\$[A-Za-z_][A-Za-z0-9_]*`), "$VAR environment references (PowerShell uses $env:VAR)"}
+
+	fishUnsupported       = []construct{backticks, braceExpand, arithmetic, heredoc, testCommand}
+	powerShellUnsupported = []construct{backticks, braceExpand, arithmetic, heredoc, testCommand, envPrefix, envReference}
+)
+
+// warnUnsupported adds a warning for each construct of the alias command
+// that the target shell reads differently from sh.
+func warnUnsupported(alias *domain.Alias, target string, constructs []construct, ws *warnings) {
+	var found []string
+	for _, c := range constructs {
+		if c.pattern.MatchString(alias.Command) {
+			found = append(found, c.description)
+		}
+	}
+	if len(found) > 0 {
+		ws.add(alias, "%s does not support %s; exported as is", target, strings.Join(found, ", "))
+	}
+}
+
+// writeComment writes text as "#" comment lines, each line prefixed with
+// prefix.
+func writeComment(b *strings.Builder, prefix, text string) {
+	if text == "" {
+		return
+	}
+	for _, line := range strings.Split(text, "\n") {
+		fmt.Fprintf(b, "%s# %s\n", prefix, line)
+	}
+}
+
+// indent prefixes each line of text with prefix.
+func indent(text, prefix string) string {
+	return prefix + strings.ReplaceAll(text, "\n", "\n"+prefix)
+}
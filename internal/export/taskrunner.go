@@ -0,0 +1,211 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/internal/shell"
+)
+
+// writeMakefile writes aliases as phony make targets. Make has no
+// positional parameters, so placeholders become variables given on the
+// command line: $1 becomes $(ARG1) and $@ becomes $(ARGS).
+func writeMakefile(w io.Writer, aliases []*domain.Alias, ws *warnings) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", header(Make))
+	b.WriteString("# Pass parameters as variables: make <target> ARG1=... ARG2=... or ARGS=\"...\"\n")
+
+	names := make([]string, len(aliases))
+	for i, alias := range aliases {
+		names[i] = alias.Name
+	}
+	if len(names) > 0 {
+		fmt.Fprintf(&b, "\n.PHONY: %s\n", strings.Join(names, " "))
+	}
+
+	syn := syntax{
+		param: func(p placeholder) string {
+			if p == allParams {
+				return "$(ARGS)"
+			}
+			return fmt.Sprintf("$(ARG%d)", p)
+		},
+		rest:   " $(ARGS)",
+		escape: func(text string) string { return strings.ReplaceAll(text, "$", "$$") },
+	}
+	for _, alias := range aliases {
+		warnMultiLine(alias, "make", ws)
+
+		b.WriteString("\n" + alias.Name + ":")
+		if alias.Description != "" {
+			b.WriteString(" ## " + firstLine(alias.Description))
+		}
+		b.WriteString("\n")
+		fmt.Fprintf(&b, "\t@%s\n", recipe(alias, syn.translate(alias.Command), "\n\t@"))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// justRecipeName matches the names just accepts for recipes.
+var justRecipeName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// writeJustfile writes aliases as just recipes. With positional-arguments
+// set, recipe lines see their arguments as $1, $2 and $@, just like mantrid
+// commands, so only "{{" needs escaping.
+func writeJustfile(w io.Writer, aliases []*domain.Alias, ws *warnings) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\nset positional-arguments\n", header(Just))
+
+	syn := syntax{
+		param:  posixParam,
+		rest:   ` "$@"`,
+		escape: func(text string) string { return strings.ReplaceAll(text, "{{", "{{{{") },
+	}
+	taken := map[string]string{}
+	for _, alias := range aliases {
+		name := alias.Name
+		if strings.ContainsAny(name, domain.NamespaceSeparators) {
+			name = strings.Map(func(r rune) rune {
+				if strings.ContainsRune(domain.NamespaceSeparators, r) {
+					return '-'
+				}
+				return r
+			}, name)
+		}
+		switch {
+		case !justRecipeName.MatchString(name) || isJustKeyword(name):
+			ws.skip(alias, "%q is not a valid just recipe name", name)
+			continue
+		case taken[name] != "":
+			ws.skip(alias, "recipe %q is already exported for %s", name, taken[name])
+			continue
+		case name != alias.Name:
+			ws.add(alias, "exported as recipe %q", name)
+		}
+		taken[name] = alias.Name
+		warnMultiLine(alias, "just", ws)
+
+		b.WriteString("\n")
+		writeComment(&b, "", firstLine(alias.Description))
+		fmt.Fprintf(&b, "%s *args:\n", name)
+		fmt.Fprintf(&b, "    @%s\n", recipe(alias, syn.translate(alias.Command), "\n    @"))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// isJustKeyword reports whether a justfile line starting with name is a
+// statement rather than a recipe.
+func isJustKeyword(name string) bool {
+	switch name {
+	case "set", "alias", "export", "import", "mod":
+		return true
+	}
+	return false
+}
+
+// recipe returns the recipe lines running command for alias, joined with
+// sep, changing to the alias directory first.
+func recipe(alias *domain.Alias, command, sep string) string {
+	if alias.WorkDir != "" {
+		command = "cd " + shell.Quote(shell.Bash, alias.WorkDir) + " && " + command
+	}
+	return strings.ReplaceAll(command, "\n", sep)
+}
+
+// warnMultiLine warns that each line of a multi-line command runs in its own
+// shell in a task runner recipe.
+func warnMultiLine(alias *domain.Alias, target string, ws *warnings) {
+	if strings.Contains(alias.Command, "\n") {
+		ws.add(alias, "the command spans several lines, which %s runs in separate shells", target)
+	}
+}
+
+// vscodeTasks is the layout of .vscode/tasks.json.
+type vscodeTasks struct {
+	Version string        `json:"version"`
+	Tasks   []vscodeTask  `json:"tasks"`
+	Inputs  []vscodeInput `json:"inputs,omitempty"`
+}
+
+type vscodeTask struct {
+	Label          string         `json:"label"`
+	Type           string         `json:"type"`
+	Command        string         `json:"command"`
+	Detail         string         `json:"detail,omitempty"`
+	Options        *vscodeOptions `json:"options,omitempty"`
+	ProblemMatcher []string       `json:"problemMatcher"`
+}
+
+type vscodeOptions struct {
+	Cwd string `json:"cwd"`
+}
+
+type vscodeInput struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+// vscodeInputID turns characters VS Code does not expect in input ids into
+// "-".
+var vscodeInputID = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// writeVSCodeTasks writes aliases as VS Code shell tasks. Placeholders
+// become inputs VS Code prompts for when the task runs; aliases without
+// placeholders run without parameters.
+func writeVSCodeTasks(w io.Writer, aliases []*domain.Alias, ws *warnings) error {
+	file := vscodeTasks{Version: "2.0.0", Tasks: []vscodeTask{}}
+
+	for _, alias := range aliases {
+		if strings.Contains(alias.Command, "${") {
+			ws.add(alias, "VS Code expands ${...} in the command as its own variables")
+		}
+
+		id := vscodeInputID.ReplaceAllString(alias.Name, "-")
+		prompted := map[placeholder]bool{}
+		syn := syntax{param: func(p placeholder) string {
+			input := fmt.Sprintf("%s-%d", id, p)
+			description := fmt.Sprintf("Parameter %d of %s", p, alias.Name)
+			if p == allParams {
+				input = id + "-args"
+				description = "Parameters of " + alias.Name
+			}
+			if !prompted[p] {
+				prompted[p] = true
+				file.Inputs = append(file.Inputs, vscodeInput{ID: input, Type: "promptString", Description: description})
+			}
+			return "${input:" + input + "}"
+		}}
+
+		task := vscodeTask{
+			Label:          alias.Name,
+			Type:           "shell",
+			Command:        syn.translate(alias.Command),
+			Detail:         alias.Description,
+			ProblemMatcher: []string{},
+		}
+		if alias.WorkDir != "" {
+			task.Options = &vscodeOptions{Cwd: alias.WorkDir}
+		}
+		file.Tasks = append(file.Tasks, task)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(file)
+}
+
+// firstLine returns the first line of text.
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(text, "\n")
+	return line
+}
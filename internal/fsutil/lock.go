@@ -0,0 +1,101 @@
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrLocked is matched by the errors of Lock when another process holds
+// the lock.
+var ErrLocked = errors.New("store is locked")
+
+// LockedError reports that another process held a lock for longer than
+// Lock was willing to wait.
+type LockedError struct {
+	Path string
+	// PID is the process holding the lock, or 0 when it is not known.
+	PID     int
+	Timeout time.Duration
+}
+
+func (e *LockedError) Error() string {
+	holder := "another process"
+	if e.PID != 0 {
+		holder = fmt.Sprintf("PID %d", e.PID)
+	}
+	return fmt.Sprintf("store is locked by %s (waited %s for %s)", holder, e.Timeout, e.Path)
+}
+
+func (e *LockedError) Is(target error) bool {
+	return target == ErrLocked
+}
+
+// lockRetryInterval is how often Lock retries a held lock.
+const lockRetryInterval = 20 * time.Millisecond
+
+// FileLock is an advisory lock on a lock file, held until Unlock.
+type FileLock struct {
+	f *os.File
+}
+
+// Lock takes an exclusive advisory lock on the lock file at path, creating
+// it if needed, and records the current PID in it. While another process
+// holds the lock it retries for up to timeout before returning a
+// LockedError. The operating system releases the lock when the holder
+// exits, so a crashed process never leaves the store locked.
+func Lock(path string, timeout time.Duration) (*FileLock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		locked, err := tryLock(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		if locked {
+			break
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, &LockedError{Path: path, PID: lockHolder(path), Timeout: timeout}
+		}
+		time.Sleep(lockRetryInterval)
+	}
+
+	if err := f.Truncate(0); err == nil {
+		f.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0)
+	}
+	return &FileLock{f: f}, nil
+}
+
+// Unlock releases the lock.
+func (l *FileLock) Unlock() error {
+	l.f.Truncate(0)
+	if err := unlock(l.f); err != nil {
+		l.f.Close()
+		return err
+	}
+	return l.f.Close()
+}
+
+// lockHolder returns the PID recorded in the lock file at path, or 0.
+func lockHolder(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	return pid
+}
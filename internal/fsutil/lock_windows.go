@@ -0,0 +1,30 @@
+package fsutil
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockRegion returns the byte range locked in lock files. Windows locks
+// are mandatory, so it lies far past the recorded PID, which other
+// processes must still be able to read.
+func lockRegion() *windows.Overlapped {
+	return &windows.Overlapped{OffsetHigh: 1}
+}
+
+// tryLock takes an exclusive lock on f without waiting. It reports false
+// when another process holds it.
+func tryLock(f *os.File) (bool, error) {
+	err := windows.LockFileEx(windows.Handle(f.Fd()),
+		windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, lockRegion())
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func unlock(f *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, lockRegion())
+}
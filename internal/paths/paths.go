@@ -3,6 +3,7 @@ package paths
 import (
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/msaglietto/mantrid/internal/config"
 )
@@ -25,16 +26,91 @@ func (fm *FileManager) GetAliasFilePath() string {
 	}
 
 	// Otherwise, use default path in user's home directory
+	name := "aliases.json"
+	if fm.config != nil {
+		name = "aliases" + config.AliasFileExtension(fm.config.StorageType)
+	}
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		// Fallback to current directory if can't get home directory
-		return filepath.Join(".", ".mantrid", "aliases.json")
+		return filepath.Join(".", ".mantrid", name)
+	}
+
+	return filepath.Join(homeDir, ".mantrid", name)
+}
+
+// GetDatabaseFilePath returns the path of the database of the sqlite
+// storage type.
+func (fm *FileManager) GetDatabaseFilePath() string {
+	if fm.config != nil && fm.config.DatabaseFile != "" {
+		return fm.config.DatabaseFile
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".", ".mantrid", "aliases.db")
 	}
 
-	return filepath.Join(homeDir, ".mantrid", "aliases.json")
+	return filepath.Join(homeDir, ".mantrid", "aliases.db")
+}
+
+// GetStoreFilePath returns the file the given storage type keeps the
+// global aliases in, or "" for storage that is not a file. The JSON, YAML
+// and TOML storage types share the alias file path: for a type other than
+// the configured one, its extension is that of the type.
+func (fm *FileManager) GetStoreFilePath(storageType string) string {
+	switch storageType {
+	case "memory":
+		return ""
+	case "sqlite":
+		return fm.GetDatabaseFilePath()
+	}
+
+	path := fm.GetAliasFilePath()
+	if fm.config == nil || fm.config.StorageType != storageType {
+		path = strings.TrimSuffix(path, filepath.Ext(path)) + config.AliasFileExtension(storageType)
+	}
+	return path
 }
 
 func (fm *FileManager) EnsureDirectories() error {
-	dir := filepath.Dir(fm.GetAliasFilePath())
-	return os.MkdirAll(dir, 0755)
+	dirs := []string{filepath.Dir(fm.GetAliasFilePath())}
+	if fm.config != nil && fm.config.StorageType == "sqlite" {
+		dirs = append(dirs, filepath.Dir(fm.GetDatabaseFilePath()))
+	}
+	for _, dir := range dirs {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetShellCacheDir returns the directory holding the generated shell
+// integration scripts, next to the alias file.
+func (fm *FileManager) GetShellCacheDir() string {
+	return filepath.Join(filepath.Dir(fm.GetAliasFilePath()), "shell")
+}
+
+// GetBackupDir returns the directory holding the snapshots of the alias
+// file, next to it.
+func (fm *FileManager) GetBackupDir() string {
+	return filepath.Join(filepath.Dir(fm.GetAliasFilePath()), "backups")
+}
+
+// GetBaseDir returns the directory of mantrid's own files, such as the
+// trust database and, unless configured elsewhere, the alias stores.
+func (fm *FileManager) GetBaseDir() string {
+	return filepath.Dir(fm.GetTrustFilePath())
+}
+
+// GetTrustFilePath returns the path of the trust database. Unlike the alias
+// file it is not configurable, as a config file pointing at another
+// database could trust anything.
+func (fm *FileManager) GetTrustFilePath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".", ".mantrid", "trusted.json")
+	}
+	return filepath.Join(homeDir, ".mantrid", "trusted.json")
 }
@@ -16,6 +16,7 @@ func TestFileManager(t *testing.T) {
 		homeDir, _ := os.UserHomeDir()
 		expected := filepath.Join(homeDir, ".mantrid", "aliases.json")
 		assert.Equal(t, expected, fm.GetAliasFilePath())
+		assert.Equal(t, filepath.Join(homeDir, ".mantrid"), fm.GetBaseDir())
 	})
 
 	t.Run("configured path", func(t *testing.T) {
@@ -42,4 +43,30 @@ func TestFileManager(t *testing.T) {
 		assert.NoError(t, err)
 		assert.True(t, dirInfo.IsDir())
 	})
+
+	t.Run("shell cache dir", func(t *testing.T) {
+		cfg := &config.Config{
+			AliasFile: "/custom/path/aliases.json",
+		}
+		fm := paths.NewFileManager(cfg)
+		assert.Equal(t, filepath.Join("/custom/path", "shell"), fm.GetShellCacheDir())
+		assert.Equal(t, filepath.Join("/custom/path", "backups"), fm.GetBackupDir())
+	})
+
+	t.Run("store file path", func(t *testing.T) {
+		cfg := &config.Config{
+			AliasFile:    "/custom/path/aliases.json",
+			DatabaseFile: "/custom/path/aliases.db",
+		}
+		fm := paths.NewFileManager(cfg)
+		assert.Equal(t, "/custom/path/aliases.json", fm.GetStoreFilePath("json"))
+		assert.Equal(t, "/custom/path/aliases.db", fm.GetStoreFilePath("sqlite"))
+		assert.Empty(t, fm.GetStoreFilePath("memory"))
+		assert.Equal(t, "/custom/path/aliases.yaml", fm.GetStoreFilePath("yaml"))
+
+		cfg.StorageType = "toml"
+		cfg.AliasFile = "/dotfiles/mantrid.conf"
+		assert.Equal(t, "/dotfiles/mantrid.conf", fm.GetStoreFilePath("toml"))
+		assert.Equal(t, "/dotfiles/mantrid.json", fm.GetStoreFilePath("json"))
+	})
 }
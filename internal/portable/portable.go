@@ -0,0 +1,181 @@
+// Package portable reads and writes aliases as documents meant to be shared
+// between machines, in JSON, YAML or TOML.
+package portable
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/msaglietto/mantrid/domain"
+	jsonrepo "github.com/msaglietto/mantrid/repository/json"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Supported document formats.
+const (
+	JSON = "json"
+	YAML = "yaml"
+	TOML = "toml"
+)
+
+// Formats lists the supported document formats.
+var Formats = []string{JSON, YAML, TOML}
+
+// Version is the version of the document layout written by Encode. Decode
+// refuses documents of later versions, except for JSON ones, which are laid
+// out as alias files.
+const Version = 1
+
+// document is the layout of a portable document.
+type document struct {
+	Version int     `json:"version" yaml:"version" toml:"version"`
+	Aliases []entry `json:"aliases" yaml:"aliases" toml:"aliases"`
+}
+
+// entry is an alias in a portable document.
+type entry struct {
+	Name        string    `json:"name" yaml:"name" toml:"name"`
+	Command     string    `json:"command" yaml:"command" toml:"command"`
+	Description string    `json:"description,omitempty" yaml:"description,omitempty" toml:"description,omitempty"`
+	Completion  string    `json:"completion,omitempty" yaml:"completion,omitempty" toml:"completion,omitempty"`
+	WorkDir     string    `json:"workdir,omitempty" yaml:"workdir,omitempty" toml:"workdir,omitempty"`
+	Source      string    `json:"source,omitempty" yaml:"source,omitempty" toml:"source,omitempty"`
+	CreatedAt   time.Time `json:"created_at" yaml:"created_at" toml:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" yaml:"updated_at" toml:"updated_at"`
+}
+
+// FormatOf returns the format of a document named path, judging by its
+// extension.
+func FormatOf(path string) (string, bool) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return JSON, true
+	case ".yaml", ".yml":
+		return YAML, true
+	case ".toml":
+		return TOML, true
+	}
+	return "", false
+}
+
+// Encode writes aliases to w as a document in format.
+func Encode(w io.Writer, format string, aliases []*domain.Alias) error {
+	doc := document{Version: Version, Aliases: make([]entry, len(aliases))}
+	for i, a := range aliases {
+		doc.Aliases[i] = entry{
+			Name:        a.Name,
+			Command:     a.Command,
+			Description: a.Description,
+			Completion:  a.Completion,
+			WorkDir:     a.WorkDir,
+			Source:      a.Source,
+			CreatedAt:   a.CreatedAt,
+			UpdatedAt:   a.UpdatedAt,
+		}
+	}
+
+	switch format {
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(doc)
+	case YAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(doc); err != nil {
+			return err
+		}
+		return enc.Close()
+	case TOML:
+		return toml.NewEncoder(w).Encode(doc)
+	}
+	return unsupported(format)
+}
+
+// Decode reads the aliases of a document in format. The alias file of the
+// json storage is accepted too, in any of its format versions: a JSON array
+// of aliases, or a document of a version later than Version, which is what
+// tells the two layouts apart. Its aliases are read without their revisions.
+func Decode(r io.Reader, format string) ([]*domain.Alias, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc document
+	switch format {
+	case JSON:
+		if isAliasFile(data) {
+			return decodeAliasFile(data)
+		}
+		err = json.Unmarshal(data, &doc)
+	case YAML:
+		err = yaml.Unmarshal(data, &doc)
+	case TOML:
+		err = toml.Unmarshal(data, &doc)
+	default:
+		return nil, unsupported(format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s document: %w", format, err)
+	}
+
+	if doc.Version > Version {
+		return nil, fmt.Errorf("document version %d is newer than the supported version %d; upgrade mantrid to read it", doc.Version, Version)
+	}
+	if doc.Version < 1 {
+		return nil, fmt.Errorf("invalid %s document: missing version", format)
+	}
+
+	aliases := make([]*domain.Alias, len(doc.Aliases))
+	for i, e := range doc.Aliases {
+		aliases[i] = &domain.Alias{
+			Name:        e.Name,
+			Command:     e.Command,
+			Description: e.Description,
+			Completion:  e.Completion,
+			WorkDir:     e.WorkDir,
+			Source:      e.Source,
+			CreatedAt:   e.CreatedAt,
+			UpdatedAt:   e.UpdatedAt,
+		}
+	}
+	return aliases, nil
+}
+
+// isAliasFile reports whether data, a JSON document, is laid out as the
+// alias file of the json storage rather than as a portable document.
+func isAliasFile(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		return true
+	}
+	var doc struct {
+		Version int `json:"version"`
+	}
+	return json.Unmarshal(trimmed, &doc) == nil && doc.Version > Version
+}
+
+// decodeAliasFile reads the aliases of an alias file of the json storage,
+// upgraded from its format version.
+func decodeAliasFile(data []byte) ([]*domain.Alias, error) {
+	aliases, err := jsonrepo.DecodeFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid alias file: %w", err)
+	}
+	for _, a := range aliases {
+		a.Revision = 0
+	}
+	return aliases, nil
+}
+
+func unsupported(format string) error {
+	return fmt.Errorf("unsupported format %q: must be one of %s", format, strings.Join(Formats, ", "))
+}
@@ -0,0 +1,109 @@
+package portable_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/internal/portable"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundTrip(t *testing.T) {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	aliases := []*domain.Alias{
+		{Name: "gs", Command: "git status", CreatedAt: created, UpdatedAt: created},
+		{
+			Name:        "k8s/logs",
+			Command:     "kubectl logs -f $1 \\\n  --tail 100 # \"quoted\"",
+			Description: "Follow logs",
+			Completion:  "command:kubectl get pods -o name",
+			WorkDir:     "/srv/app",
+			CreatedAt:   created,
+			UpdatedAt:   created.Add(time.Hour),
+		},
+	}
+
+	for _, format := range portable.Formats {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, portable.Encode(&buf, format, aliases))
+			assert.Contains(t, buf.String(), "version")
+
+			decoded, err := portable.Decode(&buf, format)
+			require.NoError(t, err)
+			require.Len(t, decoded, 2)
+			for i := range aliases {
+				assert.Equal(t, aliases[i].Name, decoded[i].Name)
+				assert.Equal(t, aliases[i].Command, decoded[i].Command)
+				assert.Equal(t, aliases[i].Description, decoded[i].Description)
+				assert.Equal(t, aliases[i].Completion, decoded[i].Completion)
+				assert.Equal(t, aliases[i].WorkDir, decoded[i].WorkDir)
+				assert.True(t, aliases[i].CreatedAt.Equal(decoded[i].CreatedAt))
+				assert.True(t, aliases[i].UpdatedAt.Equal(decoded[i].UpdatedAt))
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	t.Run("alias file array", func(t *testing.T) {
+		aliases, err := portable.Decode(strings.NewReader(`[{"name": "ll", "command": "ls -la"}]`), portable.JSON)
+		require.NoError(t, err)
+		require.Len(t, aliases, 1)
+		assert.Equal(t, "ll", aliases[0].Name)
+	})
+
+	t.Run("alias file document", func(t *testing.T) {
+		aliases, err := portable.Decode(strings.NewReader(`{"version": 2, "aliases": [
+  {"name": "ll", "command": "ls -la", "created_at": "2024-01-01T00:00:00Z", "updated_at": "2024-01-01T00:00:00Z", "revision": 3}
+]}`), portable.JSON)
+		require.NoError(t, err)
+		require.Len(t, aliases, 1)
+		assert.Equal(t, "ls -la", aliases[0].Command)
+		assert.Zero(t, aliases[0].Revision)
+	})
+
+	t.Run("alias file of a newer version refused", func(t *testing.T) {
+		_, err := portable.Decode(strings.NewReader(`{"version": 99, "aliases": []}`), portable.JSON)
+		assert.ErrorContains(t, err, "upgrade mantrid")
+	})
+
+	t.Run("newer version refused", func(t *testing.T) {
+		_, err := portable.Decode(strings.NewReader("version: 2\naliases: []\n"), portable.YAML)
+		assert.ErrorContains(t, err, "document version 2 is newer than the supported version 1")
+	})
+
+	t.Run("missing version", func(t *testing.T) {
+		_, err := portable.Decode(strings.NewReader(`aliases = []`), portable.TOML)
+		assert.ErrorContains(t, err, "missing version")
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		_, err := portable.Decode(strings.NewReader(`{"version": 1,`), portable.JSON)
+		assert.ErrorContains(t, err, "invalid json document")
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		_, err := portable.Decode(strings.NewReader(""), "xml")
+		assert.ErrorContains(t, err, `unsupported format "xml"`)
+	})
+}
+
+func TestFormatOf(t *testing.T) {
+	for path, want := range map[string]string{
+		"aliases.json": portable.JSON,
+		"a.YML":        portable.YAML,
+		"a.yaml":       portable.YAML,
+		"a.toml":       portable.TOML,
+	} {
+		got, ok := portable.FormatOf(path)
+		assert.True(t, ok, path)
+		assert.Equal(t, want, got, path)
+	}
+	_, ok := portable.FormatOf("aliases.txt")
+	assert.False(t, ok)
+}
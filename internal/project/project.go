@@ -0,0 +1,72 @@
+// Package project finds the alias files projects keep next to their code.
+package project
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/msaglietto/mantrid/domain"
+)
+
+// Alias file names, relative to a project directory, in order of
+// preference: a directory holds at most one project alias file.
+var (
+	YAMLFile = ".mantrid.yaml"
+	JSONFile = filepath.Join(".mantrid", "aliases.json")
+)
+
+// Discover returns a layer for each project alias file in dir and its
+// ancestors, nearest first. The file at global, the user's own alias store,
+// is never a project file even when it sits in one of those directories,
+// and neither is any file in base, the directory of mantrid's own files,
+// such as an alias file left behind by a migration to another storage type.
+// Layers are named after their path relative to dir.
+func Discover(dir, global, base string) ([]*domain.Layer, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range []*string{&global, &base} {
+		if *path == "" {
+			continue
+		}
+		if *path, err = filepath.Abs(*path); err != nil {
+			return nil, err
+		}
+	}
+
+	var layers []*domain.Layer
+	for current := dir; ; current = filepath.Dir(current) {
+		for _, name := range []string{YAMLFile, JSONFile} {
+			file := filepath.Join(current, name)
+			if file == global || filepath.Dir(file) == base || !isFile(file) {
+				continue
+			}
+			layers = append(layers, &domain.Layer{
+				Name: relative(dir, file),
+				File: file,
+				Root: current,
+			})
+			break
+		}
+
+		if filepath.Dir(current) == current {
+			return layers, nil
+		}
+	}
+}
+
+// isFile reports whether path exists and is a regular file.
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// relative returns file relative to dir, or file itself when that fails.
+func relative(dir, file string) string {
+	rel, err := filepath.Rel(dir, file)
+	if err != nil {
+		return file
+	}
+	return rel
+}
@@ -0,0 +1,61 @@
+package project_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/msaglietto/mantrid/internal/project"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscover(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "app", "cmd")
+	require.NoError(t, os.MkdirAll(nested, 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".mantrid"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "app", ".mantrid"), 0755))
+
+	write := func(path string) {
+		require.NoError(t, os.WriteFile(path, []byte("aliases: []\n"), 0644))
+	}
+	write(filepath.Join(root, "app", ".mantrid.yaml"))
+	write(filepath.Join(root, "app", ".mantrid", "aliases.json"))
+	write(filepath.Join(root, ".mantrid", "aliases.json"))
+
+	t.Run("nearest first", func(t *testing.T) {
+		layers, err := project.Discover(nested, "", "")
+		require.NoError(t, err)
+		require.Len(t, layers, 2)
+
+		assert.Equal(t, filepath.Join("..", ".mantrid.yaml"), layers[0].Name)
+		assert.Equal(t, filepath.Join(root, "app", ".mantrid.yaml"), layers[0].File)
+		assert.Equal(t, filepath.Join(root, "app"), layers[0].Root)
+		assert.True(t, layers[0].IsProject())
+
+		assert.Equal(t, filepath.Join("..", "..", ".mantrid", "aliases.json"), layers[1].Name)
+		assert.Equal(t, root, layers[1].Root)
+	})
+
+	t.Run("skips the global alias file", func(t *testing.T) {
+		layers, err := project.Discover(nested, filepath.Join(root, ".mantrid", "aliases.json"), "")
+		require.NoError(t, err)
+		require.Len(t, layers, 1)
+		assert.Equal(t, filepath.Join(root, "app"), layers[0].Root)
+	})
+
+	t.Run("skips the files of mantrid", func(t *testing.T) {
+		// The global store is in yaml, and an old json one was left behind
+		layers, err := project.Discover(nested, filepath.Join(root, ".mantrid", "aliases.yaml"), filepath.Join(root, ".mantrid"))
+		require.NoError(t, err)
+		require.Len(t, layers, 1)
+		assert.Equal(t, filepath.Join(root, "app"), layers[0].Root)
+	})
+
+	t.Run("no project files", func(t *testing.T) {
+		layers, err := project.Discover(t.TempDir(), "", "")
+		require.NoError(t, err)
+		assert.Empty(t, layers)
+	})
+}
@@ -0,0 +1,220 @@
+package shell
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Function is a shell function that runs the alias of the same name.
+type Function struct {
+	Name        string
+	Description string
+}
+
+// stampPrefix starts the first line of a functions script. Shells compare
+// that line with the one of the script they loaded last to tell whether the
+// cache has been regenerated since.
+const stampPrefix = "# mantrid-stamp "
+
+// Functions returns a script for shell that defines one function per entry
+// of functions, each running `mantrid do <name> -- <args>`. command is the
+// mantrid invocation to use, such as ["mantrid", "--config", "x.yaml"].
+// Functions defined by a previously loaded script are removed first, so
+// deleted aliases disappear when the script is loaded again.
+func Functions(shell string, command []string, functions []Function) (string, error) {
+	var body strings.Builder
+	names := make([]string, len(functions))
+	for i, f := range functions {
+		names[i] = f.Name
+	}
+
+	switch shell {
+	case Bash, Zsh:
+		split := "$__mantrid_functions"
+		if shell == Zsh {
+			split = "${=__mantrid_functions}"
+		}
+		fmt.Fprintf(&body, "for __mantrid_f in %s; do unset -f \"$__mantrid_f\"; done\n", split)
+		fmt.Fprintf(&body, "unset __mantrid_f\n")
+		fmt.Fprintf(&body, "__mantrid_functions=%s\n", Quote(shell, strings.Join(names, " ")))
+		for _, f := range functions {
+			// The function keyword keeps an existing alias of the same name
+			// from being expanded in the definition
+			fmt.Fprintf(&body, "function %s { command %s do %s -- \"$@\"; }\n",
+				f.Name, invocation(shell, command), Quote(shell, f.Name))
+		}
+	case Fish:
+		fmt.Fprintf(&body, "for f in $__mantrid_functions; functions --erase $f; end\n")
+		fmt.Fprintf(&body, "set -g __mantrid_functions%s\n", quoteList(shell, names, " ", " "))
+		for _, f := range functions {
+			fmt.Fprintf(&body, "function %s", f.Name)
+			if f.Description != "" {
+				fmt.Fprintf(&body, " --description %s", Quote(shell, f.Description))
+			}
+			fmt.Fprintf(&body, "\n    command %s do %s -- $argv\nend\n", invocation(shell, command), Quote(shell, f.Name))
+		}
+	case PowerShell:
+		fmt.Fprintf(&body, "foreach ($f in $global:__mantridFunctions) { Remove-Item -LiteralPath \"Function:\\$f\" -ErrorAction SilentlyContinue }\n")
+		fmt.Fprintf(&body, "$global:__mantridFunctions = @(%s)\n", quoteList(shell, names, "", ", "))
+		for _, f := range functions {
+			// A quoted '--' reaches mantrid instead of being taken as
+			// PowerShell's end of parameters
+			fmt.Fprintf(&body, "function global:%s { & %s do %s '--' @args }\n",
+				f.Name, invocation(shell, command), Quote(shell, f.Name))
+		}
+	default:
+		return "", fmt.Errorf("unsupported shell %q", shell)
+	}
+
+	sum := sha256.Sum256([]byte(body.String()))
+	stamp := stampPrefix + hex.EncodeToString(sum[:8])
+
+	var script strings.Builder
+	script.WriteString(stamp + "\n")
+	switch shell {
+	case Fish:
+		fmt.Fprintf(&script, "set -g __mantrid_stamp %s\n", Quote(shell, stamp))
+	case PowerShell:
+		fmt.Fprintf(&script, "$global:__mantridStamp = %s\n", Quote(shell, stamp))
+	default:
+		fmt.Fprintf(&script, "__mantrid_stamp=%s\n", Quote(shell, stamp))
+	}
+	script.WriteString(body.String())
+	return script.String(), nil
+}
+
+// InitOptions configures the script returned by Init.
+type InitOptions struct {
+	// Command is the mantrid invocation, such as ["mantrid", "--config", "x.yaml"].
+	Command []string
+	// Store is the alias store whose changes trigger a refresh.
+	Store string
+	// Cache is where the functions script is kept between refreshes.
+	Cache string
+}
+
+var initTemplates = map[string]string{
+	Bash: `# mantrid shell integration for bash. Load it from ~/.bashrc with:
+#   eval "$(mantrid shell init bash)"
+__mantrid_store={{.Store}}
+__mantrid_cache={{.Cache}}
+__mantrid_refresh() {
+  if [ ! -f "$__mantrid_cache" ] || [ "$__mantrid_store" -nt "$__mantrid_cache" ]; then
+    command {{.Command}} shell init bash --functions --output "$__mantrid_cache" || return
+  fi
+  local stamp
+  IFS= read -r stamp < "$__mantrid_cache"
+  if [ "$stamp" != "$__mantrid_stamp" ]; then
+    . "$__mantrid_cache"
+  fi
+}
+__mantrid_refresh
+case ";${PROMPT_COMMAND:-};" in
+  *";__mantrid_refresh;"*) ;;
+  *) PROMPT_COMMAND="__mantrid_refresh${PROMPT_COMMAND:+;$PROMPT_COMMAND}" ;;
+esac
+`,
+	Zsh: `# mantrid shell integration for zsh. Load it from ~/.zshrc with:
+#   eval "$(mantrid shell init zsh)"
+__mantrid_store={{.Store}}
+__mantrid_cache={{.Cache}}
+__mantrid_refresh() {
+  if [ ! -f "$__mantrid_cache" ] || [ "$__mantrid_store" -nt "$__mantrid_cache" ]; then
+    command {{.Command}} shell init zsh --functions --output "$__mantrid_cache" || return
+  fi
+  local stamp
+  IFS= read -r stamp < "$__mantrid_cache"
+  if [ "$stamp" != "$__mantrid_stamp" ]; then
+    . "$__mantrid_cache"
+  fi
+}
+__mantrid_refresh
+autoload -Uz add-zsh-hook
+add-zsh-hook precmd __mantrid_refresh
+`,
+	Fish: `# mantrid shell integration for fish. Load it from ~/.config/fish/config.fish with:
+#   mantrid shell init fish | source
+set -g __mantrid_store {{.Store}}
+set -g __mantrid_cache {{.Cache}}
+function __mantrid_refresh --on-event fish_prompt
+    set -l store_mtime (path mtime -- $__mantrid_store); or set store_mtime 0
+    set -l cache_mtime (path mtime -- $__mantrid_cache); or set cache_mtime -1
+    if test $store_mtime -ge $cache_mtime
+        command {{.Command}} shell init fish --functions --output $__mantrid_cache; or return
+    end
+    read -l stamp < $__mantrid_cache
+    if test "$stamp" != "$__mantrid_stamp"
+        source $__mantrid_cache
+    end
+end
+__mantrid_refresh
+`,
+	PowerShell: `# mantrid shell integration for PowerShell. Load it from $PROFILE with:
+#   Invoke-Expression (& mantrid shell init pwsh | Out-String)
+$global:__mantridStore = {{.Store}}
+$global:__mantridCache = {{.Cache}}
+function global:__mantridRefresh {
+    $store = Get-Item -LiteralPath $global:__mantridStore -ErrorAction SilentlyContinue
+    $cache = Get-Item -LiteralPath $global:__mantridCache -ErrorAction SilentlyContinue
+    if (-not $cache -or ($store -and $store.LastWriteTimeUtc -gt $cache.LastWriteTimeUtc)) {
+        & {{.Command}} shell init pwsh --functions --output $global:__mantridCache
+        if ($LASTEXITCODE -ne 0) { return }
+    }
+    $stamp = Get-Content -LiteralPath $global:__mantridCache -TotalCount 1
+    if ($stamp -ne $global:__mantridStamp) { . $global:__mantridCache }
+}
+__mantridRefresh
+if (-not $global:__mantridPrompt) {
+    $global:__mantridPrompt = $function:prompt
+    function global:prompt { __mantridRefresh; & $global:__mantridPrompt }
+}
+`,
+}
+
+// Init returns the script users evaluate from their shell's startup file.
+// It keeps the functions script at opts.Cache, regenerates it with
+// `mantrid shell init <shell> --functions` whenever opts.Store is newer, and
+// reloads it before each prompt when it has changed.
+func Init(shell string, opts InitOptions) (string, error) {
+	text, ok := initTemplates[shell]
+	if !ok {
+		return "", fmt.Errorf("unsupported shell %q", shell)
+	}
+
+	tmpl := template.Must(template.New(shell).Parse(text))
+	var script strings.Builder
+	err := tmpl.Execute(&script, map[string]string{
+		"Command": invocation(shell, opts.Command),
+		"Store":   Quote(shell, opts.Store),
+		"Cache":   Quote(shell, opts.Cache),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to render %s script: %w", shell, err)
+	}
+	return script.String(), nil
+}
+
+// invocation renders command with its program name bare, so that it is
+// looked up on PATH, and every argument quoted.
+func invocation(shell string, command []string) string {
+	if len(command) == 0 {
+		return "mantrid"
+	}
+	return command[0] + quoteList(shell, command[1:], " ", " ")
+}
+
+// quoteList quotes each of words, joining them with sep and putting lead
+// before the first one.
+func quoteList(shell string, words []string, lead, sep string) string {
+	if len(words) == 0 {
+		return ""
+	}
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = Quote(shell, w)
+	}
+	return lead + strings.Join(quoted, sep)
+}
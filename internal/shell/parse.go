@@ -0,0 +1,407 @@
+package shell
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Definition is an alias or one-line function found in a shell script.
+type Definition struct {
+	Name        string
+	Command     string
+	Description string
+	// Line is the 1-based line of the script the definition is on.
+	Line int
+}
+
+// LineError reports a line of a shell script that looks like a definition
+// but could not be imported.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	errUnterminatedQuote = errors.New("unterminated quote; multi-line definitions are not supported")
+	errMultiLineFunction = errors.New("only one-line functions can be imported")
+)
+
+var (
+	// name() { body; }  and  function name [()] { body; }
+	posixFunctionLine  = regexp.MustCompile(`^\s*(?:function\s+([^\s(){}]+)\s*(?:\(\s*\))?|([^\s(){}=]+)\s*\(\s*\))\s*\{\s*(.*?)\s*;?\s*\}\s*;?\s*(?:#.*)?$`)
+	posixFunctionStart = regexp.MustCompile(`^\s*(?:function\s+[^\s(){}]+|[^\s(){}=]+\s*\(\s*\))`)
+	// function name [options]; body; end
+	fishFunctionLine  = regexp.MustCompile(`^\s*function\s+([^;]+?)\s*;\s*(.*?)\s*;?\s*end\s*$`)
+	fishFunctionStart = regexp.MustCompile(`^\s*function\s`)
+	fishArgvIndex     = regexp.MustCompile(`\$argv\[(\d+)\]`)
+)
+
+// ParseDefinitions reads the aliases and one-line functions defined in a bash,
+// zsh or fish script. Other statements are ignored; definitions that cannot
+// be imported, such as multi-line functions, are reported as LineErrors.
+func ParseDefinitions(shell string, r io.Reader) ([]Definition, []error, error) {
+	var parseLine func(line string, n int) ([]Definition, error)
+	switch shell {
+	case Bash, Zsh:
+		parseLine = parsePosixLine
+	case Fish:
+		parseLine = parseFishLine
+	default:
+		return nil, nil, fmt.Errorf("cannot import from %q: must be one of bash, zsh, fish", shell)
+	}
+
+	var defs []Definition
+	var problems []error
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		found, err := parseLine(scanner.Text(), n)
+		if err != nil {
+			problems = append(problems, &LineError{Line: n, Err: err})
+		}
+		defs = append(defs, found...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return defs, problems, nil
+}
+
+// parsePosixLine parses the alias and function definitions of a bash or zsh
+// line.
+func parsePosixLine(line string, n int) ([]Definition, error) {
+	if m := posixFunctionLine.FindStringSubmatch(line); m != nil {
+		name := m[1] + m[2]
+		if name == "alias" {
+			return nil, nil
+		}
+		return []Definition{{Name: name, Command: m[3], Line: n}}, nil
+	}
+
+	var defs []Definition
+	rest := line
+	for rest != "" {
+		words, next, err := splitWords(rest, posixQuoting)
+		if err != nil {
+			return defs, err
+		}
+		rest = next
+
+		if len(words) == 0 {
+			continue
+		}
+		if words[0] != "alias" {
+			if len(defs) == 0 && posixFunctionStart.MatchString(line) && strings.Contains(line, "{") {
+				return nil, errMultiLineFunction
+			}
+			continue
+		}
+
+		found, err := parsePosixAlias(words[1:], n)
+		if err != nil {
+			return defs, err
+		}
+		defs = append(defs, found...)
+	}
+	return defs, nil
+}
+
+// parsePosixAlias parses the arguments of a bash or zsh alias builtin.
+func parsePosixAlias(args []string, n int) ([]Definition, error) {
+	var defs []Definition
+	options := true
+	for _, arg := range args {
+		if options && strings.HasPrefix(arg, "-") {
+			switch arg {
+			case "--":
+				options = false
+			case "-g":
+				return nil, errors.New("zsh global aliases are not supported")
+			case "-s":
+				return nil, errors.New("zsh suffix aliases are not supported")
+			}
+			continue
+		}
+		options = false
+
+		name, command, ok := strings.Cut(arg, "=")
+		if !ok {
+			// "alias name" prints the alias
+			continue
+		}
+		defs = append(defs, Definition{Name: name, Command: command, Line: n})
+	}
+	return defs, nil
+}
+
+// parseFishLine parses the alias, abbr and function definitions of a fish line.
+func parseFishLine(line string, n int) ([]Definition, error) {
+	if m := fishFunctionLine.FindStringSubmatch(line); m != nil {
+		header, _, err := splitWords(m[1], fishQuoting)
+		if err != nil || len(header) == 0 {
+			return nil, err
+		}
+		def := Definition{Name: header[0], Command: fishArgs(m[2]), Line: n}
+		for i := 1; i+1 < len(header); i++ {
+			if header[i] == "-d" || header[i] == "--description" {
+				def.Description = header[i+1]
+			}
+		}
+		return []Definition{def}, nil
+	}
+	if fishFunctionStart.MatchString(line) {
+		return nil, errMultiLineFunction
+	}
+
+	var defs []Definition
+	rest := line
+	for rest != "" {
+		words, next, err := splitWords(rest, fishQuoting)
+		if err != nil {
+			return defs, err
+		}
+		rest = next
+
+		if len(words) == 0 {
+			continue
+		}
+		var def *Definition
+		switch words[0] {
+		case "alias":
+			def, err = parseFishAlias(words[1:])
+		case "abbr":
+			def, err = parseFishAbbr(words[1:])
+		default:
+			continue
+		}
+		if err != nil {
+			return defs, err
+		}
+		if def != nil {
+			def.Line = n
+			defs = append(defs, *def)
+		}
+	}
+	return defs, nil
+}
+
+// parseFishAlias parses the arguments of the fish alias function.
+func parseFishAlias(args []string) (*Definition, error) {
+	def := &Definition{}
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "-d" || arg == "--description":
+			if i+1 < len(args) {
+				def.Description = args[i+1]
+				i++
+			}
+		case strings.HasPrefix(arg, "--description="):
+			def.Description = strings.TrimPrefix(arg, "--description=")
+		case len(positional) == 0 && strings.HasPrefix(arg, "-"):
+			// --save, --help and the like
+		default:
+			positional = append(positional, arg)
+		}
+	}
+
+	if len(positional) == 0 {
+		return nil, nil
+	}
+	if name, command, ok := strings.Cut(positional[0], "="); ok {
+		def.Name = name
+		def.Command = strings.Join(append([]string{command}, positional[1:]...), " ")
+		return def, nil
+	}
+	if len(positional) == 1 {
+		return nil, nil
+	}
+	def.Name = positional[0]
+	def.Command = strings.Join(positional[1:], " ")
+	return def, nil
+}
+
+// parseFishAbbr parses the arguments of the fish abbr builtin.
+func parseFishAbbr(args []string) (*Definition, error) {
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			positional = append(positional, args[i+1:]...)
+			i = len(args)
+		case arg == "-e" || arg == "--erase" || arg == "-l" || arg == "--list" ||
+			arg == "-s" || arg == "--show" || arg == "-q" || arg == "--query" ||
+			arg == "--rename" || arg == "-h" || arg == "--help":
+			return nil, nil
+		case arg == "-r" || arg == "--regex" || arg == "-f" || arg == "--function" ||
+			strings.HasPrefix(arg, "--regex=") || strings.HasPrefix(arg, "--function="):
+			return nil, errors.New("abbreviations with --regex or --function are not supported")
+		case arg == "-p" || arg == "--position" || arg == "-c" || arg == "--command":
+			i++
+		case strings.HasPrefix(arg, "-") && len(positional) < 2:
+			// -a, --add, -g, -U, --set-cursor and options given as --opt=value
+		default:
+			positional = append(positional, arg)
+		}
+	}
+
+	if len(positional) == 0 {
+		return nil, nil
+	}
+	// Old fish versions accepted "abbr -a name=expansion"
+	if name, command, ok := strings.Cut(positional[0], "="); ok && len(positional) == 1 {
+		return &Definition{Name: name, Command: command}, nil
+	}
+	if len(positional) == 1 {
+		return nil, nil
+	}
+	return &Definition{Name: positional[0], Command: strings.Join(positional[1:], " ")}, nil
+}
+
+// fishArgs translates fish argument references to mantrid placeholders.
+func fishArgs(body string) string {
+	body = fishArgvIndex.ReplaceAllString(body, "$$$1")
+	return strings.ReplaceAll(body, "$argv", "$@")
+}
+
+type quoting int
+
+const (
+	posixQuoting quoting = iota
+	fishQuoting
+)
+
+// splitWords splits the first statement of line into words, removing quotes
+// and escapes the way the shell does. It returns the text after the
+// statement's terminating ";" and stops at a comment.
+func splitWords(line string, q quoting) (words []string, rest string, err error) {
+	var word strings.Builder
+	inWord := false
+	flush := func() {
+		if inWord {
+			words = append(words, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == ' ' || c == '\t':
+			flush()
+		case c == ';':
+			flush()
+			return words, line[i+1:], nil
+		case c == '#' && !inWord:
+			flush()
+			return words, "", nil
+		case c == '\\':
+			inWord = true
+			if i+1 < len(line) {
+				i++
+				word.WriteByte(line[i])
+			}
+		case c == '\'':
+			inWord = true
+			end, err := singleQuoted(line, i+1, q, &word)
+			if err != nil {
+				return nil, "", err
+			}
+			i = end
+		case c == '"':
+			inWord = true
+			end, err := doubleQuoted(line, i+1, q, &word)
+			if err != nil {
+				return nil, "", err
+			}
+			i = end
+		case c == '$' && q == posixQuoting && i+1 < len(line) && line[i+1] == '\'':
+			inWord = true
+			end, err := ansiQuoted(line, i+2, &word)
+			if err != nil {
+				return nil, "", err
+			}
+			i = end
+		default:
+			inWord = true
+			word.WriteByte(c)
+		}
+	}
+	flush()
+	return words, "", nil
+}
+
+// singleQuoted copies the single-quoted text starting at start and returns
+// the index of the closing quote. Fish allows \' and \\ inside.
+func singleQuoted(line string, start int, q quoting, word *strings.Builder) (int, error) {
+	for i := start; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '\'':
+			return i, nil
+		case c == '\\' && q == fishQuoting && i+1 < len(line) && (line[i+1] == '\'' || line[i+1] == '\\'):
+			i++
+			word.WriteByte(line[i])
+		default:
+			word.WriteByte(c)
+		}
+	}
+	return 0, errUnterminatedQuote
+}
+
+// doubleQuoted copies the double-quoted text starting at start, keeping
+// expansions as they are, and returns the index of the closing quote.
+func doubleQuoted(line string, start int, q quoting, word *strings.Builder) (int, error) {
+	escapable := "$`\"\\"
+	if q == fishQuoting {
+		escapable = "$\"\\"
+	}
+	for i := start; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '"':
+			return i, nil
+		case c == '\\' && i+1 < len(line) && strings.IndexByte(escapable, line[i+1]) >= 0:
+			i++
+			word.WriteByte(line[i])
+		default:
+			word.WriteByte(c)
+		}
+	}
+	return 0, errUnterminatedQuote
+}
+
+// ansiQuoted copies the $'...' text starting at start, decoding the common
+// escapes, and returns the index of the closing quote.
+func ansiQuoted(line string, start int, word *strings.Builder) (int, error) {
+	escapes := map[byte]byte{'n': '\n', 't': '\t', '\\': '\\', '\'': '\'', '"': '"', 'e': 0x1b, 'a': 0x07}
+	for i := start; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\'':
+			return i, nil
+		case c == '\\' && i+1 < len(line):
+			if decoded, ok := escapes[line[i+1]]; ok {
+				i++
+				word.WriteByte(decoded)
+				continue
+			}
+			word.WriteByte(c)
+		default:
+			word.WriteByte(c)
+		}
+	}
+	return 0, errUnterminatedQuote
+}
@@ -0,0 +1,112 @@
+package shell_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/msaglietto/mantrid/internal/shell"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDefinitionsPosix(t *testing.T) {
+	script := `# ~/.bashrc
+export PATH="$HOME/bin:$PATH"
+alias ll='ls -la'
+alias gs="git status"   # status
+  alias k=kubectl
+alias say='echo it'\''s "fine"'
+alias dq="echo \"quoted\" \$HOME \d"
+alias tab=$'printf \'a\tb\''
+alias a1='one' a2='two'; alias a3=three
+alias -- -='cd -'
+alias -g G='| grep'
+alias ll
+gst() { git status "$@"; }
+function gco { git checkout "$1"; }
+function gl() { git log --oneline; }  # log
+mkcd() {
+  mkdir -p "$1" && cd "$1"
+}
+alias broken='missing quote
+if [ -f ~/.bash_aliases ]; then . ~/.bash_aliases; fi
+`
+
+	defs, problems, err := shell.ParseDefinitions(shell.Bash, strings.NewReader(script))
+	require.NoError(t, err)
+
+	got := map[string]string{}
+	for _, d := range defs {
+		got[d.Name] = d.Command
+	}
+	assert.Equal(t, map[string]string{
+		"ll":  "ls -la",
+		"gs":  "git status",
+		"k":   "kubectl",
+		"say": `echo it's "fine"`,
+		"dq":  `echo "quoted" $HOME \d`,
+		"tab": "printf 'a\tb'",
+		"a1":  "one",
+		"a2":  "two",
+		"a3":  "three",
+		"-":   "cd -",
+		"gst": `git status "$@"`,
+		"gco": `git checkout "$1"`,
+		"gl":  "git log --oneline",
+	}, got)
+	assert.Equal(t, 3, defs[0].Line)
+
+	require.Len(t, problems, 3)
+	assert.Contains(t, problems[0].Error(), "line 11: zsh global aliases are not supported")
+	assert.Contains(t, problems[1].Error(), "line 16: only one-line functions")
+	assert.Contains(t, problems[2].Error(), "line 19: unterminated quote")
+}
+
+func TestParseDefinitionsFish(t *testing.T) {
+	script := `# config.fish
+set -gx EDITOR vim
+alias ll 'ls -la'
+alias gs='git status'
+alias --save k kubectl
+alias -d 'Show disk usage' duh 'du -sh *'
+abbr -a gco git checkout
+abbr --add --position anywhere -- L '| less'
+abbr -a say 'echo it\'s'
+abbr g git
+abbr -e old
+abbr -a --regex '^\.\.+$' dots --function multicd
+function gp --description 'Push'; git push origin $argv[1] $argv; end
+function multi
+    echo $argv
+end
+`
+
+	defs, problems, err := shell.ParseDefinitions(shell.Fish, strings.NewReader(script))
+	require.NoError(t, err)
+
+	got := map[string]shell.Definition{}
+	for _, d := range defs {
+		got[d.Name] = d
+	}
+	assert.Len(t, got, 9)
+	assert.Equal(t, "ls -la", got["ll"].Command)
+	assert.Equal(t, "git status", got["gs"].Command)
+	assert.Equal(t, "kubectl", got["k"].Command)
+	assert.Equal(t, "du -sh *", got["duh"].Command)
+	assert.Equal(t, "Show disk usage", got["duh"].Description)
+	assert.Equal(t, "git checkout", got["gco"].Command)
+	assert.Equal(t, "| less", got["L"].Command)
+	assert.Equal(t, "echo it's", got["say"].Command)
+	assert.Equal(t, "git", got["g"].Command)
+	assert.Equal(t, "git push origin $1 $@", got["gp"].Command)
+	assert.Equal(t, "Push", got["gp"].Description)
+
+	require.Len(t, problems, 2)
+	assert.Contains(t, problems[0].Error(), "line 12: abbreviations with --regex")
+	assert.Contains(t, problems[1].Error(), "line 14: only one-line functions")
+}
+
+func TestParseDefinitionsUnsupportedShell(t *testing.T) {
+	_, _, err := shell.ParseDefinitions(shell.PowerShell, strings.NewReader(""))
+	assert.Error(t, err)
+}
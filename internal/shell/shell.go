@@ -0,0 +1,101 @@
+// Package shell generates shell code that integrates mantrid aliases with
+// bash, zsh, fish and PowerShell, and reads aliases defined in shell scripts.
+package shell
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Supported shells.
+const (
+	Bash       = "bash"
+	Zsh        = "zsh"
+	Fish       = "fish"
+	PowerShell = "pwsh"
+)
+
+// Supported lists the shells mantrid can generate code for.
+var Supported = []string{Bash, Zsh, Fish, PowerShell}
+
+// IsSupported reports whether name is one of Supported.
+func IsSupported(name string) bool {
+	for _, s := range Supported {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Extension returns the file extension of scripts for the given shell.
+func Extension(shell string) string {
+	if shell == PowerShell {
+		return "ps1"
+	}
+	return shell
+}
+
+var (
+	// A name containing "/" would be run as a path instead of a function
+	posixFunctionName = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+	fishFunctionName  = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+	pwshFunctionName  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+)
+
+// reservedWords are names each shell refuses or mis-parses as a function.
+var reservedWords = map[string][]string{
+	Bash: {"case", "coproc", "do", "done", "elif", "else", "esac", "fi", "for", "function",
+		"if", "in", "select", "then", "time", "until", "while"},
+	Zsh: {"case", "coproc", "do", "done", "elif", "else", "end", "esac", "fi", "for", "foreach",
+		"function", "if", "in", "nocorrect", "repeat", "select", "then", "time", "until", "while"},
+	Fish: {"and", "begin", "break", "builtin", "case", "command", "continue", "else", "end",
+		"eval", "exec", "for", "function", "if", "not", "or", "return", "set", "status",
+		"string", "switch", "test", "time", "while"},
+	PowerShell: {"begin", "break", "catch", "class", "continue", "data", "do", "dynamicparam",
+		"else", "elseif", "end", "enum", "exit", "filter", "finally", "for", "foreach", "from",
+		"function", "if", "in", "param", "process", "return", "switch", "throw", "trap", "try",
+		"until", "using", "var", "while"},
+}
+
+// ValidFunctionName reports why name cannot be defined and called as a
+// function in shell, or nil if it can.
+func ValidFunctionName(shell, name string) error {
+	var pattern *regexp.Regexp
+	switch shell {
+	case Bash, Zsh:
+		pattern = posixFunctionName
+	case Fish:
+		pattern = fishFunctionName
+	case PowerShell:
+		pattern = pwshFunctionName
+	default:
+		return fmt.Errorf("unsupported shell %q", shell)
+	}
+
+	if !pattern.MatchString(name) {
+		return fmt.Errorf("%q is not a valid %s function name", name, shell)
+	}
+	for _, word := range reservedWords[shell] {
+		if strings.EqualFold(word, name) && (shell == PowerShell || word == name) {
+			return fmt.Errorf("%q is a reserved word in %s", name, shell)
+		}
+	}
+	if name == "mantrid" {
+		return fmt.Errorf("%q would shadow mantrid itself", name)
+	}
+	return nil
+}
+
+// Quote returns s as a single literal word in shell.
+func Quote(shell, s string) string {
+	switch shell {
+	case Fish:
+		return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+	case PowerShell:
+		return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+	default:
+		return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+	}
+}
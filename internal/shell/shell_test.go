@@ -0,0 +1,130 @@
+package shell_test
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/msaglietto/mantrid/internal/shell"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidFunctionName(t *testing.T) {
+	tests := []struct {
+		shell string
+		name  string
+		valid bool
+	}{
+		{shell.Bash, "k", true},
+		{shell.Bash, "git-st", true},
+		{shell.Bash, "k8s.logs", true},
+		{shell.Bash, "k8s/logs", false},
+		{shell.Bash, "-x", false},
+		{shell.Bash, "if", false},
+		{shell.Zsh, "repeat", false},
+		{shell.Fish, "and", false},
+		{shell.Fish, "deploy", true},
+		{shell.PowerShell, "Deploy", true},
+		{shell.PowerShell, "2fa", false},
+		{shell.PowerShell, "ForEach", false},
+		{shell.Bash, "mantrid", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell+" "+tt.name, func(t *testing.T) {
+			err := shell.ValidFunctionName(tt.shell, tt.name)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+
+	assert.Error(t, shell.ValidFunctionName("csh", "k"))
+}
+
+func TestQuote(t *testing.T) {
+	assert.Equal(t, `'it'\''s'`, shell.Quote(shell.Bash, "it's"))
+	assert.Equal(t, `'it\'s \\'`, shell.Quote(shell.Fish, `it's \`))
+	assert.Equal(t, `'it''s'`, shell.Quote(shell.PowerShell, "it's"))
+}
+
+func TestFunctions(t *testing.T) {
+	functions := []shell.Function{{Name: "k", Description: "kubectl"}, {Name: "deploy"}}
+
+	for _, sh := range shell.Supported {
+		t.Run(sh, func(t *testing.T) {
+			script, err := shell.Functions(sh, []string{"mantrid", "--config", "my config.yaml"}, functions)
+			require.NoError(t, err)
+			assert.Regexp(t, `^# mantrid-stamp [0-9a-f]{16}\n`, script)
+			assert.Contains(t, script, "'--config' "+shell.Quote(sh, "my config.yaml")+" do 'k'")
+			assert.Contains(t, script, "do 'deploy'")
+		})
+	}
+
+	t.Run("stamp changes with the functions", func(t *testing.T) {
+		a, err := shell.Functions(shell.Bash, []string{"mantrid"}, functions)
+		require.NoError(t, err)
+		b, err := shell.Functions(shell.Bash, []string{"mantrid"}, functions[:1])
+		require.NoError(t, err)
+		assert.NotEqual(t, a[:32], b[:32])
+	})
+
+	t.Run("unsupported shell", func(t *testing.T) {
+		_, err := shell.Functions("csh", []string{"mantrid"}, functions)
+		assert.Error(t, err)
+	})
+}
+
+func TestFunctionsInBash(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+
+	// "echo" stands in for mantrid to show the arguments each function passes
+	first, err := shell.Functions(shell.Bash, []string{"echo"}, []shell.Function{{Name: "k"}, {Name: "old"}})
+	require.NoError(t, err)
+	second, err := shell.Functions(shell.Bash, []string{"echo"}, []shell.Function{{Name: "k"}})
+	require.NoError(t, err)
+
+	script := first + "k get 'two words'\n" + second + "if type old >/dev/null 2>&1; then echo old still defined; fi\n"
+	out, err := exec.Command(bash, "-c", script).CombinedOutput()
+	require.NoError(t, err, string(out))
+	assert.Equal(t, "do k -- get two words\n", string(out))
+}
+
+func TestInit(t *testing.T) {
+	opts := shell.InitOptions{
+		Command: []string{"mantrid"},
+		Store:   "/home/me/.mantrid/aliases.json",
+		Cache:   "/home/me/.mantrid/shell/init.sh",
+	}
+
+	for _, sh := range shell.Supported {
+		t.Run(sh, func(t *testing.T) {
+			script, err := shell.Init(sh, opts)
+			require.NoError(t, err)
+			assert.Contains(t, script, shell.Quote(sh, opts.Store))
+			assert.Contains(t, script, shell.Quote(sh, opts.Cache))
+			assert.Contains(t, script, "mantrid shell init "+sh+" --functions --output")
+		})
+	}
+
+	t.Run("bash syntax", func(t *testing.T) {
+		bash, err := exec.LookPath("bash")
+		if err != nil {
+			t.Skip("bash not available")
+		}
+		script, err := shell.Init(shell.Bash, opts)
+		require.NoError(t, err)
+		out, err := exec.Command(bash, "-n", "-c", script).CombinedOutput()
+		assert.NoError(t, err, string(out))
+	})
+
+	t.Run("unsupported shell", func(t *testing.T) {
+		_, err := shell.Init("csh", opts)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,222 @@
+package tasks
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// targets: prerequisites ## description
+	makeRule = regexp.MustCompile(`^([^\s:=#][^:=#]*?)\s*::?(?:[^:=]|$)`)
+	// ## description, the convention of self-documenting Makefiles
+	makeHelp = regexp.MustCompile(`##\s*(.*?)\s*$`)
+	// recipe parameters: dependencies
+	justRecipe = regexp.MustCompile(`^@?([A-Za-z_][A-Za-z0-9_-]*)(?:\s+[^:]*)?\s*:(?:[^=]|$)`)
+)
+
+// parseMakefile returns the explicit targets of a Makefile. Special targets
+// such as .PHONY, pattern rules and targets built from variables are left
+// out. A "## text" comment on the rule line describes the target.
+func parseMakefile(data, tool string) []Task {
+	var result []Task
+	seen := map[string]bool{}
+	for _, line := range logicalLines(data) {
+		if strings.HasPrefix(line, "\t") {
+			continue
+		}
+		m := makeRule.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		description := ""
+		if h := makeHelp.FindStringSubmatch(line); h != nil {
+			description = h[1]
+		}
+		for _, target := range strings.Fields(m[1]) {
+			if seen[target] || strings.HasPrefix(target, ".") || strings.ContainsAny(target, "%$()") {
+				continue
+			}
+			seen[target] = true
+			result = append(result, Task{Name: target, Command: tool + " " + quote(target), Description: summarize(description)})
+		}
+	}
+	return result
+}
+
+// logicalLines joins the backslash-continued lines of a Makefile.
+func logicalLines(data string) []string {
+	var lines []string
+	var current strings.Builder
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasSuffix(line, "\\") {
+			current.WriteString(strings.TrimSuffix(line, "\\"))
+			current.WriteByte(' ')
+			continue
+		}
+		current.WriteString(line)
+		lines = append(lines, current.String())
+		current.Reset()
+	}
+	if current.Len() > 0 {
+		lines = append(lines, current.String())
+	}
+	return lines
+}
+
+// parsePackageJSON returns the scripts of a package.json, run with manager.
+// Arguments after the script name are passed on to the script.
+func parsePackageJSON(data []byte, manager string) ([]Task, error) {
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(pkg.Scripts))
+	for name := range pkg.Scripts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var result []Task
+	for _, name := range names {
+		command := manager + " run " + quote(name)
+		if manager == "npm" {
+			// npm only hands the arguments after "--" to the script
+			command += " --"
+		}
+		result = append(result, Task{Name: name, Command: command, Description: summarize(pkg.Scripts[name])})
+	}
+	return result, nil
+}
+
+// packageManager returns the package manager a JavaScript project in dir
+// uses, judging by its lock file.
+func packageManager(dir string) string {
+	for _, lock := range []struct{ file, manager string }{
+		{"pnpm-lock.yaml", "pnpm"},
+		{"yarn.lock", "yarn"},
+		{"bun.lock", "bun"},
+		{"bun.lockb", "bun"},
+	} {
+		if _, err := os.Stat(filepath.Join(dir, lock.file)); err == nil {
+			return lock.manager
+		}
+	}
+	return "npm"
+}
+
+// parseJustfile returns the public recipes of a justfile. Recipes whose name
+// starts with "_" or marked [private] are left out; the comment line right
+// above a recipe describes it, as in "just --list".
+func parseJustfile(data, tool string) []Task {
+	var result []Task
+	comment := ""
+	private := false
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case line == "" || line[0] == ' ' || line[0] == '\t':
+			if trimmed == "" {
+				comment, private = "", false
+			}
+			continue
+		case strings.HasPrefix(line, "#"):
+			if !strings.HasPrefix(line, "#!") {
+				comment = strings.TrimSpace(strings.TrimPrefix(line, "#"))
+			}
+			continue
+		case strings.HasPrefix(line, "["):
+			if strings.Contains(line, "private") {
+				private = true
+			}
+			continue
+		}
+
+		if m := justRecipe.FindStringSubmatch(line); m != nil && !isJustKeyword(m[1]) {
+			if name := m[1]; !private && !strings.HasPrefix(name, "_") {
+				result = append(result, Task{Name: name, Command: tool + " " + name, Description: summarize(comment)})
+			}
+		}
+		comment, private = "", false
+	}
+	return result
+}
+
+// isJustKeyword reports whether a line starting with word is a justfile
+// statement rather than a recipe.
+func isJustKeyword(word string) bool {
+	switch word {
+	case "set", "alias", "export", "import", "mod":
+		return true
+	}
+	return false
+}
+
+// parseTaskfile returns the public tasks of a Taskfile. Tasks marked internal
+// are left out. Arguments after the task name are available to the task as
+// CLI_ARGS.
+func parseTaskfile(data []byte, tool string) ([]Task, error) {
+	var file struct {
+		Tasks yaml.Node `yaml:"tasks"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if file.Tasks.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	var result []Task
+	// Walk the node so that tasks keep the order of the file
+	for i := 0; i+1 < len(file.Tasks.Content); i += 2 {
+		name := file.Tasks.Content[i].Value
+		var task struct {
+			Desc     string `yaml:"desc"`
+			Summary  string `yaml:"summary"`
+			Internal bool   `yaml:"internal"`
+		}
+		// A task may also be just a command or a list of commands
+		if value := file.Tasks.Content[i+1]; value.Kind == yaml.MappingNode {
+			if err := value.Decode(&task); err != nil {
+				return nil, err
+			}
+		}
+		if task.Internal {
+			continue
+		}
+		description := task.Desc
+		if description == "" {
+			description = task.Summary
+		}
+		result = append(result, Task{Name: name, Command: tool + " " + quote(name) + " --", Description: summarize(description)})
+	}
+	return result, nil
+}
+
+// maxDescription bounds descriptions taken from task definitions, such as
+// the body of an npm script, so that they stay readable in listings.
+const maxDescription = 120
+
+// summarize shortens s to its first line and at most maxDescription runes.
+func summarize(s string) string {
+	s, _, _ = strings.Cut(strings.TrimSpace(s), "\n")
+	if r := []rune(s); len(r) > maxDescription {
+		return string(r[:maxDescription-3]) + "..."
+	}
+	return s
+}
@@ -0,0 +1,141 @@
+// Package tasks reads the tasks defined for the task runners of a project:
+// make targets, package.json scripts, just recipes and Taskfile tasks.
+package tasks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Supported task runners.
+const (
+	Make = "make"
+	NPM  = "npm"
+	Just = "just"
+	// Taskfile is Task, the task runner reading Taskfile.yml.
+	Taskfile = "task"
+)
+
+// Runners lists the supported task runners.
+var Runners = []string{Make, NPM, Just, Taskfile}
+
+// Task is a task of a project together with the command that runs it.
+type Task struct {
+	Name        string
+	Command     string
+	Description string
+}
+
+// Project is the set of tasks defined in one task file.
+type Project struct {
+	// File is the absolute path of the task file.
+	File string
+	// Dir is the directory tasks run in.
+	Dir   string
+	Tasks []Task
+}
+
+// Name returns the name of the project, the base name of its directory.
+func (p *Project) Name() string {
+	return filepath.Base(p.Dir)
+}
+
+// defaultFiles lists, per runner, the file names looked up in a directory,
+// in the order the runner itself looks for them.
+var defaultFiles = map[string][]string{
+	Make: {"GNUmakefile", "makefile", "Makefile"},
+	NPM:  {"package.json"},
+	Just: {"justfile", "Justfile", ".justfile"},
+	Taskfile: {"Taskfile.yml", "taskfile.yml", "Taskfile.yaml", "taskfile.yaml",
+		"Taskfile.dist.yml", "taskfile.dist.yml", "Taskfile.dist.yaml", "taskfile.dist.yaml"},
+}
+
+// Load reads the tasks of runner from path, which is either a task file or a
+// directory containing one under its default name.
+func Load(runner, path string) (*Project, error) {
+	names, ok := defaultFiles[runner]
+	if !ok {
+		return nil, fmt.Errorf("unsupported task runner %q: must be one of %s", runner, strings.Join(Runners, ", "))
+	}
+
+	file, err := findFile(path, names)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	project := &Project{File: file, Dir: filepath.Dir(file)}
+	// The runner finds its default file on its own; other names are passed
+	// explicitly.
+	explicit := !contains(names, filepath.Base(file))
+
+	switch runner {
+	case Make:
+		project.Tasks = parseMakefile(string(data), invocation("make", "-f", filepath.Base(file), explicit))
+	case NPM:
+		project.Tasks, err = parsePackageJSON(data, packageManager(project.Dir))
+	case Just:
+		project.Tasks = parseJustfile(string(data), invocation("just", "--justfile", filepath.Base(file), explicit))
+	case Taskfile:
+		project.Tasks, err = parseTaskfile(data, invocation("task", "--taskfile", filepath.Base(file), explicit))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+	return project, nil
+}
+
+// findFile resolves path to an absolute task file, looking up names when
+// path is a directory.
+func findFile(path string, names []string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return abs, nil
+	}
+
+	for _, name := range names {
+		file := filepath.Join(abs, name)
+		if _, err := os.Stat(file); err == nil {
+			return file, nil
+		}
+	}
+	return "", fmt.Errorf("no %s found in %s", strings.Join(names, ", "), abs)
+}
+
+// invocation returns the command prefix running tool, passing the task file
+// with flag when it does not have a default name.
+func invocation(tool, flag, file string, explicit bool) string {
+	if !explicit {
+		return tool
+	}
+	return tool + " " + flag + " " + quote(file)
+}
+
+// quote quotes s for sh when it contains anything but safe characters.
+func quote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:=@%+,") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+package tasks_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/msaglietto/mantrid/internal/tasks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoadMakefile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "Makefile", `VERSION := 1.0
+CC ::= gcc
+.PHONY: build test
+
+build: deps ## Build the binary
+	go build ./...
+
+test lint: ## Check the code
+	go test ./...
+
+%.o: %.c
+	$(CC) -c $<
+
+$(BIN): build
+
+install:: build
+deploy: \
+  build
+	./deploy.sh
+`)
+
+	project, err := tasks.Load(tasks.Make, dir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "Makefile"), project.File)
+	assert.Equal(t, dir, project.Dir)
+	assert.Equal(t, filepath.Base(dir), project.Name())
+	assert.Equal(t, []tasks.Task{
+		{Name: "build", Command: "make build", Description: "Build the binary"},
+		{Name: "test", Command: "make test", Description: "Check the code"},
+		{Name: "lint", Command: "make lint", Description: "Check the code"},
+		{Name: "install", Command: "make install"},
+		{Name: "deploy", Command: "make deploy"},
+	}, project.Tasks)
+}
+
+func TestLoadMakefileWithCustomName(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "build.mk", "all:\n\techo all\n")
+
+	project, err := tasks.Load(tasks.Make, path)
+	require.NoError(t, err)
+	assert.Equal(t, []tasks.Task{{Name: "all", Command: "make -f build.mk all"}}, project.Tasks)
+}
+
+func TestLoadPackageJSON(t *testing.T) {
+	content := `{"name": "web", "scripts": {"test:unit": "vitest run", "build": "vite build"}}`
+
+	t.Run("npm", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, dir, "package.json", content)
+
+		project, err := tasks.Load(tasks.NPM, dir)
+		require.NoError(t, err)
+		assert.Equal(t, []tasks.Task{
+			{Name: "build", Command: "npm run build --", Description: "vite build"},
+			{Name: "test:unit", Command: "npm run test:unit --", Description: "vitest run"},
+		}, project.Tasks)
+	})
+
+	t.Run("pnpm lock file", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, dir, "package.json", content)
+		writeFile(t, dir, "pnpm-lock.yaml", "")
+
+		project, err := tasks.Load(tasks.NPM, dir)
+		require.NoError(t, err)
+		assert.Equal(t, "pnpm run build", project.Tasks[0].Command)
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, dir, "package.json", "{")
+
+		_, err := tasks.Load(tasks.NPM, dir)
+		assert.ErrorContains(t, err, "failed to parse")
+	})
+}
+
+func TestLoadJustfile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "justfile", `set shell := ["bash", "-c"]
+version := "1.0"
+alias b := build
+
+# Build the binary
+build target='all': lint
+    go build ./...
+
+# Run the tests
+@test *args:
+    go test {{args}}
+
+_helper:
+    echo hidden
+
+[private]
+secret:
+    echo hidden
+
+[linux]
+deploy env:
+    ./deploy.sh {{env}}
+`)
+
+	project, err := tasks.Load(tasks.Just, dir)
+	require.NoError(t, err)
+	assert.Equal(t, []tasks.Task{
+		{Name: "build", Command: "just build", Description: "Build the binary"},
+		{Name: "test", Command: "just test", Description: "Run the tests"},
+		{Name: "deploy", Command: "just deploy"},
+	}, project.Tasks)
+}
+
+func TestLoadTaskfile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "Taskfile.yml", `version: '3'
+tasks:
+  build:
+    desc: Build the binary
+    cmds:
+      - go build ./...
+  docker:push:
+    summary: |
+      Push the image
+
+      Needs a registry login.
+  setup:
+    internal: true
+  fmt: gofmt -w .
+`)
+
+	project, err := tasks.Load(tasks.Taskfile, dir)
+	require.NoError(t, err)
+	assert.Equal(t, []tasks.Task{
+		{Name: "build", Command: "task build --", Description: "Build the binary"},
+		{Name: "docker:push", Command: "task docker:push --", Description: "Push the image"},
+		{Name: "fmt", Command: "task fmt --"},
+	}, project.Tasks)
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := tasks.Load("rake", dir)
+	assert.ErrorContains(t, err, "unsupported task runner")
+
+	_, err = tasks.Load(tasks.Just, dir)
+	assert.ErrorContains(t, err, "no justfile, Justfile, .justfile found")
+
+	_, err = tasks.Load(tasks.Make, filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
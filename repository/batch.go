@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/msaglietto/mantrid/domain"
+)
+
+// OpKind is the kind of change an Op makes.
+type OpKind string
+
+const (
+	OpCreate OpKind = "create"
+	OpUpdate OpKind = "update"
+	OpDelete OpKind = "delete"
+)
+
+// Op is one change of a batch: creating or updating Alias, or deleting the
+// alias named Name. Updates and deletions fail with domain.ErrConflict
+// unless the stored alias is at revision Expected, or Expected is
+// AnyRevision.
+type Op struct {
+	Kind     OpKind
+	Alias    *domain.Alias
+	Name     string
+	Expected int64
+}
+
+// CreateOp returns the operation creating alias.
+func CreateOp(alias *domain.Alias) Op {
+	return Op{Kind: OpCreate, Alias: alias}
+}
+
+// UpdateOp returns the operation replacing the alias of the same name as
+// alias.
+func UpdateOp(alias *domain.Alias) Op {
+	return Op{Kind: OpUpdate, Alias: alias}
+}
+
+// DeleteOp returns the operation deleting the alias called name.
+func DeleteOp(name string) Op {
+	return Op{Kind: OpDelete, Name: name}
+}
+
+// At returns op expecting the alias it changes to be at revision.
+func (op Op) At(revision int64) Op {
+	op.Expected = revision
+	return op
+}
+
+// Target returns the name of the alias op changes.
+func (op Op) Target() string {
+	if op.Kind != OpDelete && op.Alias != nil {
+		return op.Alias.Name
+	}
+	return op.Name
+}
+
+// Validate fails when op cannot be applied to any store: when it is of an
+// unknown kind, or creates or updates no alias.
+func (op Op) Validate() error {
+	switch op.Kind {
+	case OpCreate, OpUpdate:
+		if op.Alias == nil {
+			return fmt.Errorf("no alias to %s", op.Kind)
+		}
+	case OpDelete:
+	default:
+		return fmt.Errorf("unknown operation %q", op.Kind)
+	}
+	return nil
+}
+
+// BatchError reports the operation that made a batch fail, by its index in
+// the batch.
+type BatchError struct {
+	Index int
+	Op    Op
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("operation #%d (%s %q): %v", e.Index+1, e.Op.Kind, e.Op.Target(), e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// ApplyBatch returns aliases with ops applied in order, for use by
+// repository implementations of Batch. Each operation sees the changes of
+// the ones before it, so a batch may delete an alias and create another of
+// the same name. It fails with a BatchError, without modifying anything,
+// when an operation creates an alias that exists, updates or deletes one
+// that does not, or expects another revision. Created aliases are added
+// after the existing ones; created and updated aliases are revised, see
+// Revise.
+func ApplyBatch(aliases []*domain.Alias, ops []Op) ([]*domain.Alias, error) {
+	result := make([]*domain.Alias, len(aliases))
+	copy(result, aliases)
+	index := make(map[string]int, len(aliases))
+	for i, a := range aliases {
+		index[a.Name] = i
+	}
+
+	for n, op := range ops {
+		name := op.Target()
+		i, exists := index[name]
+		if err := op.Validate(); err != nil {
+			return nil, &BatchError{Index: n, Op: op, Err: err}
+		}
+
+		switch {
+		case op.Kind == OpCreate && exists:
+			return nil, &BatchError{Index: n, Op: op, Err: domain.ErrAliasExists}
+		case op.Kind != OpCreate && !exists:
+			return nil, &BatchError{Index: n, Op: op, Err: domain.ErrAliasNotFound}
+		case op.Kind != OpCreate:
+			if err := CheckRevision(result[i], op.Expected); err != nil {
+				return nil, &BatchError{Index: n, Op: op, Err: err}
+			}
+		}
+
+		switch op.Kind {
+		case OpCreate:
+			index[name] = len(result)
+			result = append(result, Revise(op.Alias))
+		case OpUpdate:
+			result[i] = Revise(op.Alias)
+		case OpDelete:
+			// Compacted below, so that the indexes stay valid
+			result[i] = nil
+			delete(index, name)
+		}
+	}
+
+	kept := result[:0]
+	for _, a := range result {
+		if a != nil {
+			kept = append(kept, a)
+		}
+	}
+	return kept, nil
+}
@@ -0,0 +1,238 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/repository"
+	"github.com/msaglietto/mantrid/repository/json"
+	"github.com/msaglietto/mantrid/repository/memory"
+	"github.com/msaglietto/mantrid/repository/sqlite"
+	"github.com/msaglietto/mantrid/repository/toml"
+	"github.com/msaglietto/mantrid/repository/yaml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// backends are the repositories that must behave alike, each created empty.
+var backends = map[string]func(t *testing.T) repository.AliasRepository{
+	"memory": func(t *testing.T) repository.AliasRepository {
+		return memory.NewAliasRepository()
+	},
+	"json": func(t *testing.T) repository.AliasRepository {
+		return json.NewAliasRepository(filepath.Join(t.TempDir(), "aliases.json"))
+	},
+	"yaml": func(t *testing.T) repository.AliasRepository {
+		return yaml.NewAliasRepository(filepath.Join(t.TempDir(), "aliases.yaml"))
+	},
+	"toml": func(t *testing.T) repository.AliasRepository {
+		return toml.NewAliasRepository(filepath.Join(t.TempDir(), "aliases.toml"))
+	},
+	"sqlite": func(t *testing.T) repository.AliasRepository {
+		repo := sqlite.NewAliasRepository(filepath.Join(t.TempDir(), "aliases.db"))
+		t.Cleanup(func() { repository.Close(repo) })
+		return repo
+	},
+}
+
+// seed returns a repository of backend holding aliases a and b.
+func seed(t *testing.T, backend string) repository.AliasRepository {
+	t.Helper()
+	repo := backends[backend](t)
+	for _, name := range []string{"a", "b"} {
+		alias, _ := domain.NewAlias(name, "echo "+name)
+		require.NoError(t, repo.Create(context.Background(), alias))
+	}
+	return repo
+}
+
+// commands returns the command of every alias of repo by name.
+func commands(t *testing.T, repo repository.AliasRepository) map[string]string {
+	t.Helper()
+	aliases, err := repo.List(context.Background())
+	require.NoError(t, err)
+
+	result := make(map[string]string, len(aliases))
+	for _, a := range aliases {
+		result[a.Name] = a.Command
+	}
+	return result
+}
+
+func TestBatchConformance(t *testing.T) {
+	ctx := context.Background()
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, backend := range names {
+		t.Run(backend, func(t *testing.T) {
+			t.Run("applies every operation", func(t *testing.T) {
+				repo := seed(t, backend)
+				c, _ := domain.NewAlias("c", "echo c")
+				a, _ := domain.NewAlias("a", "echo A")
+
+				err := repo.Batch(ctx, []repository.Op{
+					repository.CreateOp(c),
+					repository.UpdateOp(a),
+					repository.DeleteOp("b"),
+				})
+				require.NoError(t, err)
+				assert.Equal(t, map[string]string{"a": "echo A", "c": "echo c"}, commands(t, repo))
+			})
+
+			t.Run("keeps the order of the aliases", func(t *testing.T) {
+				repo := seed(t, backend)
+				c, _ := domain.NewAlias("c", "echo c")
+				a, _ := domain.NewAlias("a", "echo A")
+
+				err := repo.Batch(ctx, []repository.Op{repository.CreateOp(c), repository.UpdateOp(a)})
+				require.NoError(t, err)
+
+				aliases, err := repo.List(ctx)
+				require.NoError(t, err)
+				require.Len(t, aliases, 3)
+				assert.Equal(t, []string{"a", "b", "c"}, []string{aliases[0].Name, aliases[1].Name, aliases[2].Name})
+			})
+
+			t.Run("operations see the ones before them", func(t *testing.T) {
+				repo := seed(t, backend)
+				a, _ := domain.NewAlias("a", "echo new a")
+				x, _ := domain.NewAlias("x", "echo x")
+				x2, _ := domain.NewAlias("x", "echo x2")
+
+				err := repo.Batch(ctx, []repository.Op{
+					repository.DeleteOp("a"),
+					repository.CreateOp(a),
+					repository.CreateOp(x),
+					repository.UpdateOp(x2),
+				})
+				require.NoError(t, err)
+				assert.Equal(t, map[string]string{"a": "echo new a", "b": "echo b", "x": "echo x2"}, commands(t, repo))
+			})
+
+			t.Run("a failing operation changes nothing", func(t *testing.T) {
+				repo := seed(t, backend)
+				c, _ := domain.NewAlias("c", "echo c")
+
+				err := repo.Batch(ctx, []repository.Op{
+					repository.CreateOp(c),
+					repository.DeleteOp("a"),
+					repository.DeleteOp("missing"),
+				})
+				assert.ErrorIs(t, err, domain.ErrAliasNotFound)
+				var batchErr *repository.BatchError
+				require.True(t, errors.As(err, &batchErr))
+				assert.Equal(t, 2, batchErr.Index)
+				assert.Equal(t, map[string]string{"a": "echo a", "b": "echo b"}, commands(t, repo))
+			})
+
+			t.Run("creating an existing alias fails", func(t *testing.T) {
+				repo := seed(t, backend)
+				b, _ := domain.NewAlias("b", "echo other b")
+
+				err := repo.Batch(ctx, []repository.Op{repository.CreateOp(b)})
+				assert.ErrorIs(t, err, domain.ErrAliasExists)
+				assert.Equal(t, map[string]string{"a": "echo a", "b": "echo b"}, commands(t, repo))
+			})
+
+			t.Run("empty batch", func(t *testing.T) {
+				repo := seed(t, backend)
+				require.NoError(t, repo.Batch(ctx, nil))
+				assert.Len(t, commands(t, repo), 2)
+			})
+		})
+	}
+}
+
+func TestRevisionConformance(t *testing.T) {
+	ctx := context.Background()
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	revision := func(t *testing.T, repo repository.AliasRepository, name string) int64 {
+		t.Helper()
+		alias, err := repo.FindByName(ctx, name)
+		require.NoError(t, err)
+		return alias.Revision
+	}
+
+	for _, backend := range names {
+		t.Run(backend, func(t *testing.T) {
+			t.Run("writes change the revision", func(t *testing.T) {
+				repo := seed(t, backend)
+				first := revision(t, repo, "a")
+				assert.Greater(t, first, int64(1))
+
+				a, _ := domain.NewAlias("a", "echo A")
+				require.NoError(t, repo.Update(ctx, a, first))
+				second := revision(t, repo, "a")
+				assert.NotEqual(t, first, second)
+
+				require.NoError(t, repo.Rename(ctx, map[string]string{"a": "z"}, false))
+				z, err := repo.FindByName(ctx, "z")
+				require.NoError(t, err)
+				assert.NotEqual(t, second, z.Revision)
+				assert.Equal(t, repository.ContentRevision(z), z.Revision)
+
+				b, _ := domain.NewAlias("b", "echo B")
+				before := revision(t, repo, "b")
+				require.NoError(t, repo.Batch(ctx, []repository.Op{repository.UpdateOp(b).At(before)}))
+				assert.NotEqual(t, before, revision(t, repo, "b"))
+			})
+
+			t.Run("stale revisions conflict", func(t *testing.T) {
+				repo := seed(t, backend)
+				stale := revision(t, repo, "a")
+				a, _ := domain.NewAlias("a", "echo A")
+				require.NoError(t, repo.Update(ctx, a, stale))
+
+				assert.ErrorIs(t, repo.Update(ctx, a, stale), domain.ErrConflict)
+				assert.ErrorIs(t, repo.Delete(ctx, "a", stale), domain.ErrConflict)
+				err := repo.Batch(ctx, []repository.Op{repository.DeleteOp("b"), repository.DeleteOp("a").At(stale)})
+				assert.ErrorIs(t, err, domain.ErrConflict)
+				assert.Equal(t, map[string]string{"a": "echo A", "b": "echo b"}, commands(t, repo))
+
+				require.NoError(t, repo.Delete(ctx, "a", revision(t, repo, "a")))
+			})
+
+			t.Run("aliases added again do not take back their revisions", func(t *testing.T) {
+				repo := seed(t, backend)
+				a, _ := domain.NewAlias("a", "echo A")
+				require.NoError(t, repo.Update(ctx, a, repository.AnyRevision))
+				stale := revision(t, repo, "a")
+				require.NoError(t, repo.Delete(ctx, "a", stale))
+
+				// Created and changed again, as many writes as the copy saw
+				a, _ = domain.NewAlias("a", "echo a")
+				require.NoError(t, repo.Create(ctx, a))
+				a.Command = "echo A"
+				require.NoError(t, repo.Update(ctx, a, revision(t, repo, "a")))
+
+				a.Command = "echo stale"
+				assert.ErrorIs(t, repo.Update(ctx, a, stale), domain.ErrConflict)
+			})
+
+			t.Run("replace keeps the revision of unchanged aliases", func(t *testing.T) {
+				repo := seed(t, backend)
+				aliases, err := repo.List(ctx)
+				require.NoError(t, err)
+
+				changed := *aliases[1]
+				changed.Command = "echo B"
+				require.NoError(t, repo.Replace(ctx, []*domain.Alias{aliases[0], &changed}))
+				assert.Equal(t, aliases[0].Revision, revision(t, repo, "a"))
+				assert.NotEqual(t, aliases[1].Revision, revision(t, repo, "b"))
+			})
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package filestore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/internal/fsutil"
+)
+
+// Damage locates where an alias file stops being a valid list of aliases.
+type Damage struct {
+	Line   int
+	Column int
+	Err    error
+}
+
+func (d *Damage) Error() string {
+	return fmt.Sprintf("line %d, column %d: %v", d.Line, d.Column, d.Err)
+}
+
+func (d *Damage) Unwrap() error {
+	return d.Err
+}
+
+// Inspection is what could be read from an alias file.
+type Inspection struct {
+	// Aliases are the aliases in the file, or the ones that could be
+	// salvaged from a damaged file, in file order.
+	Aliases []*domain.Alias
+	// Damage is nil for files that are not damaged.
+	Damage *Damage
+}
+
+// Inspect reads the alias file, salvaging what it can when the file is
+// damaged. A missing file has no aliases.
+func (s *Store) Inspect() (*Inspection, error) {
+	_, data, err := s.readFile()
+	if err != nil {
+		return nil, err
+	}
+
+	aliases, err := s.decode(data)
+	var damage *Damage
+	if err != nil && !errors.As(err, &damage) {
+		return nil, err
+	}
+	return &Inspection{Aliases: aliases, Damage: damage}, nil
+}
+
+// Repair rewrites the alias file with the aliases fix returns for the
+// ones that can be read from it, under the store lock. The file is first
+// copied next to itself as a quarantined file, whose path is returned.
+func (s *Store) Repair(fix func([]*domain.Alias) []*domain.Alias) (string, error) {
+	ctx := context.Background()
+	var quarantine string
+	err := s.locked(func() error {
+		raw, data, err := s.readFile()
+		if err != nil {
+			return err
+		}
+		if raw == nil {
+			return fmt.Errorf("%s: %w", s.filePath, os.ErrNotExist)
+		}
+
+		aliases, err := s.decode(data)
+		var damage *Damage
+		if err != nil && !errors.As(err, &damage) {
+			return err
+		}
+		if quarantine, err = s.quarantine(raw); err != nil {
+			return err
+		}
+
+		return s.writeAliases(ctx, fix(aliases))
+	})
+	if err != nil {
+		return "", err
+	}
+	return quarantine, nil
+}
+
+// quarantine copies the contents raw of the alias file next to it, named
+// after their hash so that the same damage is kept once.
+func (s *Store) quarantine(raw []byte) (string, error) {
+	sum := sha256.Sum256(raw)
+	path := s.filePath + ".damaged-" + hex.EncodeToString(sum[:6])
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	if err := fsutil.WriteFileAtomic(path, raw, 0600); err != nil {
+		return "", fmt.Errorf("failed to quarantine %s: %w", s.filePath, err)
+	}
+	return path, nil
+}
@@ -0,0 +1,462 @@
+// Package filestore keeps aliases in a single file, such as the json, yaml
+// and toml alias files. It holds what those stores share: the lock that
+// serializes changes across processes, snapshots, encryption and the
+// repository.AliasRepository implementation, while a Codec converts
+// between the contents of the file and its aliases.
+package filestore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/internal/backup"
+	"github.com/msaglietto/mantrid/internal/crypt"
+	"github.com/msaglietto/mantrid/internal/fsutil"
+	"github.com/msaglietto/mantrid/internal/logging"
+	"github.com/msaglietto/mantrid/repository"
+)
+
+// DefaultLockTimeout is how long changes wait for another process holding
+// the store lock, unless configured otherwise.
+const DefaultLockTimeout = 5 * time.Second
+
+// ErrInvalid is matched by the errors for files that are not alias files
+// at all. Replace overwrites such files, where it refuses any other file
+// it cannot read.
+var ErrInvalid = errors.New("invalid alias file")
+
+// Codec converts between the plaintext contents of an alias file and its
+// aliases.
+type Codec interface {
+	// Decode returns the aliases in data, which is never empty. Data that
+	// is not an alias file gives an error matching ErrInvalid, or a
+	// *Damage along with the aliases that could still be read.
+	Decode(data []byte) ([]*domain.Alias, error)
+	// Encode returns the contents of an alias file holding aliases.
+	// previous is the plaintext of the file being replaced, if any, for
+	// codecs that carry over what people wrote in it.
+	Encode(aliases []*domain.Alias, previous []byte) ([]byte, error)
+}
+
+// Hook follows the writes of a store. It is called under the store lock,
+// so that no other process writes the file in between.
+type Hook interface {
+	// BeforeWrite is called with the contents of the file about to be
+	// replaced, nil when it does not exist. An error cancels the write.
+	BeforeWrite(ctx context.Context, current []byte) error
+	// AfterWrite is called with the contents just written. An error is
+	// returned by the write, which stays done.
+	AfterWrite(ctx context.Context, written []byte) error
+}
+
+// Store is a repository.AliasRepository kept in a single file.
+type Store struct {
+	filePath    string
+	codec       Codec
+	mode        os.FileMode
+	lockTimeout time.Duration
+	hooks       []Hook
+	// snapshots, when set, get a copy of the file before each change
+	snapshots *backup.Snapshots
+	// key encrypts the file; it is plaintext when nil
+	key *crypt.Key
+	// mu serializes the goroutines of this process; the lock file next to
+	// the store serializes processes.
+	mu sync.RWMutex
+}
+
+// Option configures optional behaviour of the store.
+type Option func(*Store)
+
+// WithLockTimeout sets how long changes wait for another process holding
+// the store lock before failing with fsutil.ErrLocked.
+func WithLockTimeout(timeout time.Duration) Option {
+	return func(s *Store) {
+		s.lockTimeout = timeout
+	}
+}
+
+// WithKey encrypts the alias file with key. A plaintext alias file is
+// refused with crypt.ErrNotSealed until it is encrypted with Reencrypt.
+func WithKey(key *crypt.Key) Option {
+	return func(s *Store) {
+		s.key = key
+	}
+}
+
+// WithSnapshots copies the alias file to snapshots before each change.
+func WithSnapshots(snapshots *backup.Snapshots) Option {
+	return func(s *Store) {
+		s.snapshots = snapshots
+	}
+}
+
+// WithHook has hook follow the writes of the store, after the hooks
+// added before it.
+func WithHook(hook Hook) Option {
+	return func(s *Store) {
+		s.hooks = append(s.hooks, hook)
+	}
+}
+
+// WithMode sets the permissions the alias file is written with, 0600
+// unless set.
+func WithMode(mode os.FileMode) Option {
+	return func(s *Store) {
+		s.mode = mode
+	}
+}
+
+// New returns the store of the alias file at filePath, in the format of
+// codec.
+func New(filePath string, codec Codec, opts ...Option) *Store {
+	s := &Store{
+		filePath:    filePath,
+		codec:       codec,
+		mode:        0600,
+		lockTimeout: DefaultLockTimeout,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// locked runs fn with the lock file next to the store held, so that the
+// read-modify-write of a change is not interleaved with another process.
+func (s *Store) locked(fn func() error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lock, err := fsutil.Lock(s.filePath+".lock", s.lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	return fn()
+}
+
+// change writes the aliases fn returns for the stored ones, under the
+// store lock.
+func (s *Store) change(ctx context.Context, fn func([]*domain.Alias) ([]*domain.Alias, error)) error {
+	return s.locked(func() error {
+		aliases, err := s.readAliases(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read aliases: %w", err)
+		}
+
+		changed, err := fn(aliases)
+		if err != nil {
+			return err
+		}
+
+		if err := s.writeAliases(ctx, changed); err != nil {
+			return fmt.Errorf("failed to write aliases: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *Store) Create(ctx context.Context, alias *domain.Alias) error {
+	return s.change(ctx, func(aliases []*domain.Alias) ([]*domain.Alias, error) {
+		for _, a := range aliases {
+			if a.Name == alias.Name {
+				return nil, domain.ErrAliasExists
+			}
+		}
+		return append(aliases, repository.Revise(alias)), nil
+	})
+}
+
+func (s *Store) FindByName(ctx context.Context, name string) (*domain.Alias, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	aliases, err := s.readAliases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, alias := range aliases {
+		if alias.Name == name {
+			return alias, nil
+		}
+	}
+
+	return nil, domain.ErrAliasNotFound
+}
+
+func (s *Store) List(ctx context.Context) ([]*domain.Alias, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	aliases, err := s.readAliases(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read aliases: %w", err)
+	}
+
+	return aliases, nil
+}
+
+func (s *Store) Update(ctx context.Context, alias *domain.Alias, expected int64) error {
+	return s.change(ctx, func(aliases []*domain.Alias) ([]*domain.Alias, error) {
+		for i, a := range aliases {
+			if a.Name == alias.Name {
+				if err := repository.CheckRevision(a, expected); err != nil {
+					return nil, err
+				}
+				aliases[i] = repository.Revise(alias)
+				return aliases, nil
+			}
+		}
+		return nil, domain.ErrAliasNotFound
+	})
+}
+
+func (s *Store) Delete(ctx context.Context, name string, expected int64) error {
+	return s.change(ctx, func(aliases []*domain.Alias) ([]*domain.Alias, error) {
+		for i, a := range aliases {
+			if a.Name == name {
+				if err := repository.CheckRevision(a, expected); err != nil {
+					return nil, err
+				}
+				return append(aliases[:i], aliases[i+1:]...), nil
+			}
+		}
+		return nil, domain.ErrAliasNotFound
+	})
+}
+
+func (s *Store) Rename(ctx context.Context, renames map[string]string, overwrite bool) error {
+	return s.change(ctx, func(aliases []*domain.Alias) ([]*domain.Alias, error) {
+		return repository.ApplyRenames(aliases, renames, overwrite)
+	})
+}
+
+func (s *Store) Batch(ctx context.Context, ops []repository.Op) error {
+	if len(ops) == 0 {
+		return nil
+	}
+
+	return s.change(ctx, func(aliases []*domain.Alias) ([]*domain.Alias, error) {
+		return repository.ApplyBatch(aliases, ops)
+	})
+}
+
+func (s *Store) Replace(ctx context.Context, aliases []*domain.Alias) error {
+	return s.locked(func() error {
+		// A file that is not an alias file is replaced all the same; one
+		// that cannot be read for another reason, such as a newer format
+		// or the wrong key, is not
+		stored, err := s.readAliases(ctx)
+		if err != nil && !errors.Is(err, ErrInvalid) {
+			return err
+		}
+
+		if err := s.writeAliases(ctx, repository.ReviseAll(stored, aliases)); err != nil {
+			return fmt.Errorf("failed to write aliases: %w", err)
+		}
+		return nil
+	})
+}
+
+// Reencrypt rewrites the alias file, encrypted with the key of the store,
+// so that it is encrypted with to instead. A nil key stands for a
+// plaintext file, so Reencrypt also encrypts and decrypts alias files. The
+// snapshots of the file are encrypted with to as well, so that they can
+// still be restored; those that cannot be opened are left with a warning.
+// It returns the number of aliases in the file.
+func (s *Store) Reencrypt(to *crypt.Key) (int, error) {
+	ctx := context.Background()
+	var count int
+	err := s.locked(func() error {
+		aliases, err := s.readAliases(ctx)
+		if err != nil {
+			return err
+		}
+
+		from := s.key
+		s.key = to
+		count = len(aliases)
+		if err := s.writeAliases(ctx, aliases); err != nil {
+			return err
+		}
+
+		if s.snapshots != nil {
+			_, err := s.snapshots.Rewrite(func(data []byte) ([]byte, error) {
+				return reseal(data, from, to)
+			})
+			if err != nil {
+				logging.FromContext(ctx).Warn("some snapshots could not be re-encrypted and cannot be restored",
+					"dir", s.snapshots.Dir(), "error", err)
+			}
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Decode returns the aliases in raw, contents of the alias file such as a
+// version of it about to be restored, decrypted with the key of the store.
+// Damaged contents are refused.
+func (s *Store) Decode(raw []byte) ([]*domain.Alias, error) {
+	data, err := s.decrypt(raw)
+	if err != nil {
+		return nil, err
+	}
+	return s.decode(data)
+}
+
+// reseal returns data, the contents of a version of the alias file
+// encrypted with from or in plaintext, encrypted with to instead. A nil to
+// leaves it in plaintext.
+func reseal(data []byte, from, to *crypt.Key) ([]byte, error) {
+	if crypt.IsSealed(data) {
+		if from == nil {
+			return nil, crypt.ErrSealed
+		}
+		plaintext, err := from.Open(data)
+		if err != nil {
+			return nil, err
+		}
+		data = plaintext
+	}
+	if to == nil {
+		return data, nil
+	}
+	return to.Seal(data)
+}
+
+// readAliases returns the aliases in the file, or none when it does not
+// exist yet. The aliases that can be read from a damaged file are used
+// rather than failing, once the file is quarantined.
+func (s *Store) readAliases(ctx context.Context) ([]*domain.Alias, error) {
+	raw, data, err := s.readFile()
+	if err != nil {
+		return nil, err
+	}
+
+	aliases, err := s.decode(data)
+	var damage *Damage
+	if errors.As(err, &damage) {
+		// Keep working with what is left, so that a bad hand edit does not
+		// take every alias down; the next change writes the salvaged ones
+		quarantine, qerr := s.quarantine(raw)
+		if qerr != nil {
+			return nil, fmt.Errorf("alias file %s is damaged at %w", s.filePath, damage)
+		}
+		logging.FromContext(ctx).Warn("alias file is damaged, using the aliases that could be read",
+			"file", s.filePath, "line", damage.Line, "column", damage.Column, "error", damage.Err,
+			"salvaged", len(aliases), "quarantined", quarantine, "hint", "run 'mantrid store check'")
+	} else if err != nil {
+		return nil, err
+	}
+
+	for _, a := range aliases {
+		// Aliases written without a revision are at the first one
+		a.Revision = repository.Revision(a)
+	}
+	return aliases, nil
+}
+
+// decode returns the aliases in the plaintext data of the alias file,
+// with the errors of the codec naming the file.
+func (s *Store) decode(data []byte) ([]*domain.Alias, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return []*domain.Alias{}, nil
+	}
+
+	aliases, err := s.codec.Decode(data)
+	var damage *Damage
+	if err != nil && !errors.As(err, &damage) {
+		return nil, fmt.Errorf("%s: %w", s.filePath, err)
+	}
+	return aliases, err
+}
+
+// readFile returns the contents of the alias file as stored and
+// decrypted, or nothing when it does not exist yet.
+func (s *Store) readFile() (raw, data []byte, err error) {
+	raw, err = os.ReadFile(s.filePath)
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err = s.decrypt(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	return raw, data, nil
+}
+
+// decrypt returns the plaintext of the alias file contents data.
+func (s *Store) decrypt(data []byte) ([]byte, error) {
+	switch {
+	case crypt.IsSealed(data) && s.key == nil:
+		return nil, fmt.Errorf("%s: %w", s.filePath, crypt.ErrSealed)
+	case crypt.IsSealed(data):
+		plaintext, err := s.key.Open(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt %s: %w", s.filePath, err)
+		}
+		return plaintext, nil
+	case s.key != nil && len(data) > 0:
+		return nil, fmt.Errorf("%s: %w", s.filePath, crypt.ErrNotSealed)
+	}
+	return data, nil
+}
+
+func (s *Store) writeAliases(ctx context.Context, aliases []*domain.Alias) error {
+	current, err := os.ReadFile(s.filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, hook := range s.hooks {
+		if err := hook.BeforeWrite(ctx, current); err != nil {
+			return err
+		}
+	}
+
+	if s.snapshots != nil {
+		if _, err := s.snapshots.Save(); err != nil {
+			return fmt.Errorf("failed to snapshot aliases: %w", err)
+		}
+	}
+
+	if aliases == nil {
+		aliases = []*domain.Alias{}
+	}
+	// A file that cannot be decrypted has nothing to carry over
+	previous, _ := s.decrypt(current)
+
+	data, err := s.codec.Encode(aliases, previous)
+	if err != nil {
+		return err
+	}
+
+	if s.key != nil {
+		if data, err = s.key.Seal(data); err != nil {
+			return fmt.Errorf("failed to encrypt aliases: %w", err)
+		}
+	}
+
+	if err := fsutil.WriteFileAtomic(s.filePath, data, s.mode); err != nil {
+		return err
+	}
+	for _, hook := range s.hooks {
+		if err := hook.AfterWrite(ctx, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
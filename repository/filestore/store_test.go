@@ -0,0 +1,99 @@
+package filestore_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/repository/filestore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errUnsupported = errors.New("unsupported alias file")
+
+// lineCodec keeps an alias per "name=command" line. Lines starting with
+// "!" are unsupported rather than invalid.
+type lineCodec struct{}
+
+func (lineCodec) Decode(data []byte) ([]*domain.Alias, error) {
+	var aliases []*domain.Alias
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if strings.HasPrefix(line, "!") {
+			return nil, errUnsupported
+		}
+		name, command, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", filestore.ErrInvalid, line)
+		}
+		aliases = append(aliases, &domain.Alias{Name: name, Command: command})
+	}
+	return aliases, nil
+}
+
+func (lineCodec) Encode(aliases []*domain.Alias, previous []byte) ([]byte, error) {
+	var b strings.Builder
+	for _, a := range aliases {
+		fmt.Fprintf(&b, "%s=%s\n", a.Name, a.Command)
+	}
+	return []byte(b.String()), nil
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("aliases get revisions", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "aliases")
+		require.NoError(t, os.WriteFile(filePath, []byte("build=go build\n"), 0600))
+		store := filestore.New(filePath, lineCodec{})
+
+		alias, err := store.FindByName(ctx, "build")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), alias.Revision)
+	})
+
+	t.Run("replace overwrites invalid files", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "aliases")
+		require.NoError(t, os.WriteFile(filePath, []byte("not an alias\n"), 0600))
+		store := filestore.New(filePath, lineCodec{})
+
+		_, err := store.List(ctx)
+		require.ErrorIs(t, err, filestore.ErrInvalid)
+
+		require.NoError(t, store.Replace(ctx, []*domain.Alias{{Name: "build", Command: "go build"}}))
+		data, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, "build=go build\n", string(data))
+	})
+
+	t.Run("replace refuses files it cannot read otherwise", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "aliases")
+		require.NoError(t, os.WriteFile(filePath, []byte("!future\n"), 0600))
+		store := filestore.New(filePath, lineCodec{})
+
+		err := store.Replace(ctx, []*domain.Alias{{Name: "build", Command: "go build"}})
+		require.ErrorIs(t, err, errUnsupported)
+		data, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, "!future\n", string(data))
+	})
+
+	t.Run("written with the configured mode", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("file modes are not kept on Windows")
+		}
+		filePath := filepath.Join(t.TempDir(), "aliases")
+		store := filestore.New(filePath, lineCodec{}, filestore.WithMode(0644))
+
+		require.NoError(t, store.Create(ctx, &domain.Alias{Name: "build", Command: "go build"}))
+		info, err := os.Stat(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+	})
+}
@@ -0,0 +1,97 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/repository"
+)
+
+type aliasRepository struct {
+	repository.AliasRepository
+}
+
+// NewAliasRepository wraps repo, the store of an alias file whose writes a
+// Store follows, so that each change is committed with a message
+// describing it, such as "alias add deploy on laptop".
+func NewAliasRepository(repo repository.AliasRepository) repository.AliasRepository {
+	return &aliasRepository{AliasRepository: repo}
+}
+
+// Close closes the wrapped repository.
+func (r *aliasRepository) Close() error {
+	return repository.Close(r.AliasRepository)
+}
+
+func (r *aliasRepository) Create(ctx context.Context, alias *domain.Alias) error {
+	return r.AliasRepository.Create(describe(ctx, "alias add "+alias.Name), alias)
+}
+
+func (r *aliasRepository) Update(ctx context.Context, alias *domain.Alias, expected int64) error {
+	return r.AliasRepository.Update(describe(ctx, "alias update "+alias.Name), alias, expected)
+}
+
+func (r *aliasRepository) Delete(ctx context.Context, name string, expected int64) error {
+	return r.AliasRepository.Delete(describe(ctx, "alias remove "+name), name, expected)
+}
+
+func (r *aliasRepository) Rename(ctx context.Context, renames map[string]string, overwrite bool) error {
+	pairs := make([]string, 0, len(renames))
+	for from, to := range renames {
+		pairs = append(pairs, from+" -> "+to)
+	}
+	sort.Strings(pairs)
+	message := "alias rename " + strings.Join(pairs, ", ")
+	if len(pairs) > 3 {
+		message = fmt.Sprintf("alias rename %d aliases", len(pairs))
+	}
+	return r.AliasRepository.Rename(describe(ctx, message), renames, overwrite)
+}
+
+func (r *aliasRepository) Replace(ctx context.Context, aliases []*domain.Alias) error {
+	message := fmt.Sprintf("alias replace all (%d aliases)", len(aliases))
+	return r.AliasRepository.Replace(describe(ctx, message), aliases)
+}
+
+func (r *aliasRepository) Batch(ctx context.Context, ops []repository.Op) error {
+	message := fmt.Sprintf("alias batch (%d changes)", len(ops))
+	if len(ops) <= 3 {
+		changes := make([]string, len(ops))
+		for i, op := range ops {
+			changes[i] = verbs[op.Kind] + " " + op.Target()
+		}
+		message = "alias " + strings.Join(changes, ", ")
+	}
+	return r.AliasRepository.Batch(describe(ctx, message), ops)
+}
+
+// verbs name the operations of a batch in commit messages, as the single
+// changes do.
+var verbs = map[repository.OpKind]string{
+	repository.OpCreate: "add",
+	repository.OpUpdate: "update",
+	repository.OpDelete: "remove",
+}
+
+type messageKey struct{}
+
+// describe returns ctx carrying message, the commit message of the change
+// made with it, unless ctx already carries one: a change such as a store
+// checkout keeps its message whatever write makes it.
+func describe(ctx context.Context, message string) context.Context {
+	if _, ok := ctx.Value(messageKey{}).(string); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, messageKey{}, message)
+}
+
+// message returns the commit message of the change made with ctx.
+func message(ctx context.Context) string {
+	if message, ok := ctx.Value(messageKey{}).(string); ok {
+		return message
+	}
+	return "alias change"
+}
@@ -0,0 +1,202 @@
+package git_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/internal/logging"
+	"github.com/msaglietto/mantrid/repository"
+	"github.com/msaglietto/mantrid/repository/filestore"
+	"github.com/msaglietto/mantrid/repository/git"
+	jsonrepo "github.com/msaglietto/mantrid/repository/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requireGit skips tests needing the git binary when it is not installed,
+// and keeps the git configuration of the user out of them.
+func requireGit(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+}
+
+func TestAliasRepository(t *testing.T) {
+	requireGit(t)
+	ctx := context.Background()
+	file := filepath.Join(t.TempDir(), "aliases.json")
+	store := git.NewStore(file)
+	repo := git.NewAliasRepository(jsonrepo.NewAliasRepository(file, filestore.WithHook(store)))
+
+	commits, err := store.Log(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, commits)
+
+	deploy, _ := domain.NewAlias("deploy", "make deploy")
+	gs, _ := domain.NewAlias("gs", "git status")
+	require.NoError(t, repo.Create(ctx, deploy))
+	require.NoError(t, repo.Create(ctx, gs))
+	deploy.Command = "make deploy ENV=prod"
+	require.NoError(t, repo.Update(ctx, deploy, repository.AnyRevision))
+	require.NoError(t, repo.Rename(ctx, map[string]string{"gs": "st"}, false))
+	require.NoError(t, repo.Delete(ctx, "st", repository.AnyRevision))
+
+	// Failed changes are not committed
+	assert.ErrorIs(t, repo.Delete(ctx, "missing", repository.AnyRevision), domain.ErrAliasNotFound)
+
+	host, err := os.Hostname()
+	require.NoError(t, err)
+	commits, err = store.Log(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, commits, 5)
+	assert.Equal(t, "alias remove st on "+host, commits[0].Subject)
+	assert.Equal(t, "alias rename gs -> st on "+host, commits[1].Subject)
+	assert.Equal(t, "alias update deploy on "+host, commits[2].Subject)
+	assert.Equal(t, "alias add gs on "+host, commits[3].Subject)
+	assert.Equal(t, "alias add deploy on "+host, commits[4].Subject)
+	assert.WithinDuration(t, time.Now(), commits[0].Date, time.Minute)
+
+	commits, err = store.Log(ctx, 2)
+	require.NoError(t, err)
+	assert.Len(t, commits, 2)
+
+	// restore writes a version through the repository, like the store
+	// checkout command
+	restore := func(ctx context.Context, data []byte) error {
+		aliases, err := jsonrepo.DecodeFile(data)
+		if err != nil {
+			return err
+		}
+		return repo.Replace(ctx, aliases)
+	}
+
+	t.Run("checkout restores and commits a version", func(t *testing.T) {
+		restored, err := store.Checkout(ctx, "HEAD~2", restore)
+		require.NoError(t, err)
+		assert.Equal(t, "alias update deploy on "+host, restored.Subject)
+
+		aliases, err := repo.List(ctx)
+		require.NoError(t, err)
+		require.Len(t, aliases, 2)
+		assert.Equal(t, "make deploy ENV=prod", aliases[0].Command)
+		assert.Equal(t, "gs", aliases[1].Name)
+
+		commits, err := store.Log(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "store checkout "+restored.Short+" on "+host, commits[0].Subject)
+	})
+
+	t.Run("checkout of an unknown revision", func(t *testing.T) {
+		_, err := store.Checkout(ctx, "no-such-rev", restore)
+		assert.ErrorContains(t, err, `unknown revision "no-such-rev"`)
+	})
+
+	t.Run("checkout of a version that cannot be restored", func(t *testing.T) {
+		before, err := os.ReadFile(file)
+		require.NoError(t, err)
+
+		_, err = store.Checkout(ctx, "HEAD~1", func(context.Context, []byte) error { return errors.New("wrong key") })
+		assert.ErrorContains(t, err, "wrong key")
+		after, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, before, after)
+	})
+}
+
+func TestStoreKeepsOtherFilesOutOfCommits(t *testing.T) {
+	requireGit(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	file := filepath.Join(dir, "aliases.json")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("log_level: debug\n"), 0644))
+	repo := git.NewAliasRepository(jsonrepo.NewAliasRepository(file, filestore.WithHook(git.NewStore(file))))
+
+	alias, _ := domain.NewAlias("gs", "git status")
+	require.NoError(t, repo.Create(ctx, alias))
+
+	out, err := exec.Command("git", "-C", dir, "ls-files").Output()
+	require.NoError(t, err)
+	assert.Equal(t, "aliases.json\n", string(out))
+}
+
+func TestStoreLeavesEnclosingRepositoriesAlone(t *testing.T) {
+	requireGit(t)
+	ctx := context.Background()
+	home := t.TempDir()
+	require.NoError(t, exec.Command("git", "-C", home, "init", "--quiet").Run())
+	file := filepath.Join(home, ".mantrid", "aliases.json")
+	store := git.NewStore(file)
+	repo := git.NewAliasRepository(jsonrepo.NewAliasRepository(file, filestore.WithHook(store)))
+
+	alias, _ := domain.NewAlias("k", "kubectl")
+	require.NoError(t, repo.Create(ctx, alias))
+
+	// The dotfiles repository in the home directory has no commit
+	assert.Error(t, exec.Command("git", "-C", home, "rev-parse", "--verify", "--quiet", "HEAD").Run())
+	assert.DirExists(t, filepath.Join(home, ".mantrid", ".git"))
+	commits, err := store.Log(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, commits, 1)
+	assert.Contains(t, commits[0].Subject, "alias add k")
+}
+
+func TestAliasRepository_Batch(t *testing.T) {
+	requireGit(t)
+	ctx := context.Background()
+	file := filepath.Join(t.TempDir(), "aliases.json")
+	store := git.NewStore(file)
+	repo := git.NewAliasRepository(jsonrepo.NewAliasRepository(file, filestore.WithHook(store)))
+
+	deploy, _ := domain.NewAlias("deploy", "make deploy")
+	gs, _ := domain.NewAlias("gs", "git status")
+	require.NoError(t, repo.Batch(ctx, []repository.Op{repository.CreateOp(deploy), repository.CreateOp(gs)}))
+
+	ops := make([]repository.Op, 0, 4)
+	for _, name := range []string{"a", "b", "c", "d"} {
+		alias, _ := domain.NewAlias(name, "echo "+name)
+		ops = append(ops, repository.CreateOp(alias))
+	}
+	require.NoError(t, repo.Batch(ctx, ops))
+
+	// Failed and empty batches are not committed
+	assert.ErrorIs(t, repo.Batch(ctx, []repository.Op{repository.DeleteOp("missing")}), domain.ErrAliasNotFound)
+	require.NoError(t, repo.Batch(ctx, nil))
+
+	host, err := os.Hostname()
+	require.NoError(t, err)
+	commits, err := store.Log(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	assert.Equal(t, "alias batch (4 changes) on "+host, commits[0].Subject)
+	assert.Equal(t, "alias add deploy, add gs on "+host, commits[1].Subject)
+}
+
+func TestAliasRepository_CommitFailureWarns(t *testing.T) {
+	requireGit(t)
+	dir := t.TempDir()
+	file := filepath.Join(dir, "aliases.json")
+	// A .git file that points nowhere breaks every git command
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".git"), []byte("gitdir: missing\n"), 0644))
+	repo := git.NewAliasRepository(jsonrepo.NewAliasRepository(file, filestore.WithHook(git.NewStore(file))))
+
+	var logs bytes.Buffer
+	ctx := logging.WithLogger(context.Background(), slog.New(slog.NewTextHandler(&logs, nil)))
+	alias, _ := domain.NewAlias("gs", "git status")
+	require.NoError(t, repo.Create(ctx, alias))
+
+	found, err := repo.FindByName(ctx, "gs")
+	require.NoError(t, err)
+	assert.Equal(t, "git status", found.Command)
+	assert.Contains(t, logs.String(), "alias change saved but not committed")
+}
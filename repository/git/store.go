@@ -0,0 +1,224 @@
+// Package git versions an alias file in a git repository: every change to
+// the aliases becomes a commit, using only the local git binary.
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/msaglietto/mantrid/internal/logging"
+)
+
+// Commit is a version of the alias file.
+type Commit struct {
+	Hash    string
+	Short   string
+	Date    time.Time
+	Subject string
+}
+
+// Store is the git repository holding an alias file, at the file's
+// directory, where it is created when needed. A repository the directory
+// is nested in, such as one of dotfiles in the home directory, is never
+// committed to. It commits the writes of the store of the file it follows,
+// see filestore.Hook.
+type Store struct {
+	file string
+	host string
+}
+
+// NewStore returns the store versioning the alias file at file.
+func NewStore(file string) *Store {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown host"
+	}
+	return &Store{file: file, host: host}
+}
+
+// File returns the path of the versioned alias file.
+func (s *Store) File() string {
+	return s.file
+}
+
+// Commit records the current contents of the alias file with message,
+// followed by the name of this host. The directory is turned into a git
+// repository first when needed. Nothing is committed when the file did not
+// change.
+func (s *Store) Commit(ctx context.Context, message string) error {
+	if err := s.init(ctx); err != nil {
+		return err
+	}
+
+	base := filepath.Base(s.file)
+	if _, err := s.git(ctx, "add", "--", base); err != nil {
+		return err
+	}
+	status, err := s.git(ctx, "status", "--porcelain", "--", base)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(status) == "" {
+		return nil
+	}
+
+	_, err = s.git(ctx, s.commitArgs(ctx, fmt.Sprintf("%s on %s", message, s.host), base)...)
+	return err
+}
+
+// BeforeWrite lets every write of the alias file happen.
+func (s *Store) BeforeWrite(ctx context.Context, current []byte) error {
+	return nil
+}
+
+// AfterWrite commits a write of the alias file, under the store lock so
+// that the commit holds the contents written, with the message describing
+// the change (see NewAliasRepository). A change that cannot be committed
+// stays saved, with a warning.
+func (s *Store) AfterWrite(ctx context.Context, written []byte) error {
+	if err := s.Commit(ctx, message(ctx)); err != nil {
+		logging.FromContext(ctx).Warn("alias change saved but not committed", "file", s.file, "error", err)
+	}
+	return nil
+}
+
+// Log returns up to limit versions of the alias file, newest first. A
+// store without commits has no versions.
+func (s *Store) Log(ctx context.Context, limit int) ([]Commit, error) {
+	if !s.initialized(ctx) {
+		return nil, nil
+	}
+
+	out, err := s.git(ctx, "log", "--max-count="+strconv.Itoa(limit),
+		"--format=%H%x00%h%x00%aI%x00%s", "--", filepath.Base(s.file))
+	if err != nil {
+		// A repository without any commit has no log
+		if _, headErr := s.git(ctx, "rev-parse", "--verify", "--quiet", "HEAD"); headErr != nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 4 {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, fields[2])
+		commits = append(commits, Commit{Hash: fields[0], Short: fields[1], Date: date, Subject: fields[3]})
+	}
+	return commits, nil
+}
+
+// Checkout restores the alias file to its contents at rev, handing them to
+// restore, which writes them through the store of the file like any other
+// change: the contents replaced are snapshotted, and the write is committed
+// with a message naming rev, so that history only moves forward.
+func (s *Store) Checkout(ctx context.Context, rev string, restore func(ctx context.Context, data []byte) error) (*Commit, error) {
+	if !s.initialized(ctx) {
+		return nil, errors.New("the alias file has no history yet")
+	}
+
+	hash, err := s.git(ctx, "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("unknown revision %q", rev)
+	}
+	hash = strings.TrimSpace(hash)
+	data, err := s.git(ctx, "show", hash+":./"+filepath.Base(s.file))
+	if err != nil {
+		return nil, fmt.Errorf("the alias file does not exist at %s", rev)
+	}
+	subject, err := s.git(ctx, "log", "-1", "--format=%h%x00%s", hash)
+	if err != nil {
+		return nil, err
+	}
+	short, message, _ := strings.Cut(strings.TrimSpace(subject), "\x00")
+
+	if err := restore(describe(ctx, "store checkout "+short), []byte(data)); err != nil {
+		return nil, fmt.Errorf("%s: %w", short, err)
+	}
+	return &Commit{Hash: hash, Short: short, Subject: message}, nil
+}
+
+// initialized reports whether the directory of the alias file is the top
+// of a git repository, rather than nested in one or in none.
+func (s *Store) initialized(ctx context.Context) bool {
+	top, err := s.git(ctx, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return false
+	}
+	return samePath(filepath.FromSlash(strings.TrimSpace(top)), filepath.Dir(s.file))
+}
+
+// samePath reports whether a and b name the same directory, once symbolic
+// links are resolved.
+func samePath(a, b string) bool {
+	resolved := func(path string) string {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		if real, err := filepath.EvalSymlinks(path); err == nil {
+			path = real
+		}
+		return filepath.Clean(path)
+	}
+	return resolved(a) == resolved(b)
+}
+
+// init turns the directory of the alias file into a git repository, unless
+// it already is one.
+func (s *Store) init(ctx context.Context) error {
+	if s.initialized(ctx) {
+		return nil
+	}
+	_, err := s.git(ctx, "init", "--quiet")
+	return err
+}
+
+// commitArgs returns the arguments committing path with message. When git
+// has no identity configured, commits are made as mantrid on this host.
+// Signing is turned off, as it would prompt on every alias change.
+func (s *Store) commitArgs(ctx context.Context, message, path string) []string {
+	var args []string
+	if _, err := s.git(ctx, "config", "user.email"); err != nil {
+		args = append(args, "-c", "user.name=mantrid", "-c", "user.email=mantrid@"+s.host)
+	}
+	args = append(args, "-c", "commit.gpgsign=false", "commit", "--quiet", "--message", message, "--", path)
+	return args
+}
+
+// git runs git with args in the directory of the alias file and returns
+// its output.
+func (s *Store) git(ctx context.Context, args ...string) (string, error) {
+	dir := filepath.Dir(s.file)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return "", fmt.Errorf("git history needs the git binary: %w", err)
+		}
+		name := args[0]
+		for i := 0; i+2 < len(args) && args[i] == "-c"; i += 2 {
+			name = args[i+2]
+		}
+		return "", fmt.Errorf("git %s: %s", name, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,60 @@
+package json_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/internal/crypt"
+	"github.com/msaglietto/mantrid/repository/filestore"
+	"github.com/msaglietto/mantrid/repository/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptedAliasRepository(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "aliases.json")
+	keyPath := filepath.Join(dir, "aliases.key")
+	require.NoError(t, crypt.GenerateKeyFile(keyPath))
+
+	plain := json.NewAliasRepository(filePath)
+	alias, _ := domain.NewAlias("db", "psql -h db.internal")
+	require.NoError(t, plain.Create(ctx, alias))
+
+	encrypted := json.NewAliasRepository(filePath, filestore.WithKey(crypt.KeyFile(keyPath)))
+	_, err := encrypted.List(ctx)
+	assert.ErrorIs(t, err, crypt.ErrNotSealed)
+
+	count, err := json.Reencrypt(filePath, nil, crypt.KeyFile(keyPath), filestore.WithLockTimeout(filestore.DefaultLockTimeout))
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	data, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	assert.True(t, crypt.IsSealed(data))
+	assert.NotContains(t, string(data), "db.internal")
+
+	_, err = plain.List(ctx)
+	assert.ErrorIs(t, err, crypt.ErrSealed)
+
+	alias, _ = domain.NewAlias("gs", "git status")
+	require.NoError(t, encrypted.Create(ctx, alias))
+	aliases, err := encrypted.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, aliases, 2)
+	assert.Equal(t, "psql -h db.internal", aliases[0].Command)
+
+	t.Run("decrypt", func(t *testing.T) {
+		count, err := json.Reencrypt(filePath, crypt.KeyFile(keyPath), nil, filestore.WithLockTimeout(filestore.DefaultLockTimeout))
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+
+		aliases, err := plain.List(ctx)
+		require.NoError(t, err)
+		assert.Len(t, aliases, 2)
+	})
+}
@@ -0,0 +1,81 @@
+package json_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/internal/fsutil"
+	"github.com/msaglietto/mantrid/repository/filestore"
+	"github.com/msaglietto/mantrid/repository/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	helperStoreEnv   = "MANTRID_TEST_LOCK_STORE"
+	helperWriterEnv  = "MANTRID_TEST_LOCK_WRITER"
+	aliasesPerWriter = 20
+)
+
+// TestLockHelperProcess is not a real test: it is the writer process
+// spawned by TestAliasRepository_CrossProcessLocking.
+func TestLockHelperProcess(t *testing.T) {
+	store := os.Getenv(helperStoreEnv)
+	if store == "" {
+		t.Skip("only runs as a helper process")
+	}
+
+	repo := json.NewAliasRepository(store, filestore.WithLockTimeout(time.Minute))
+	writer := os.Getenv(helperWriterEnv)
+	for i := 0; i < aliasesPerWriter; i++ {
+		alias, err := domain.NewAlias(fmt.Sprintf("w%s-%d", writer, i), "echo "+writer)
+		require.NoError(t, err)
+		require.NoError(t, repo.Create(context.Background(), alias))
+	}
+}
+
+func TestAliasRepository_CrossProcessLocking(t *testing.T) {
+	if testing.Short() {
+		t.Skip("spawns processes")
+	}
+	store := filepath.Join(t.TempDir(), "aliases.json")
+
+	const writers = 4
+	procs := make([]*exec.Cmd, writers)
+	for i := range procs {
+		procs[i] = exec.Command(os.Args[0], "-test.run=^TestLockHelperProcess$")
+		procs[i].Env = append(os.Environ(),
+			helperStoreEnv+"="+store,
+			fmt.Sprintf("%s=%d", helperWriterEnv, i))
+		require.NoError(t, procs[i].Start())
+	}
+	for _, p := range procs {
+		require.NoError(t, p.Wait())
+	}
+
+	aliases, err := json.NewAliasRepository(store).List(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, aliases, writers*aliasesPerWriter, "no process overwrote another's changes")
+}
+
+func TestAliasRepository_LockTimeout(t *testing.T) {
+	store := filepath.Join(t.TempDir(), "aliases.json")
+	repo := json.NewAliasRepository(store, filestore.WithLockTimeout(50*time.Millisecond))
+
+	lock, err := fsutil.Lock(store+".lock", 0)
+	require.NoError(t, err)
+
+	alias, _ := domain.NewAlias("gs", "git status")
+	err = repo.Create(context.Background(), alias)
+	assert.ErrorIs(t, err, fsutil.ErrLocked)
+	assert.ErrorContains(t, err, fmt.Sprintf("store is locked by PID %d", os.Getpid()))
+
+	require.NoError(t, lock.Unlock())
+	assert.NoError(t, repo.Create(context.Background(), alias))
+}
@@ -6,6 +6,9 @@ import (
 	"testing"
 
 	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/internal/backup"
+	"github.com/msaglietto/mantrid/repository"
+	"github.com/msaglietto/mantrid/repository/filestore"
 	"github.com/msaglietto/mantrid/repository/json"
 	"github.com/stretchr/testify/assert"
 )
@@ -59,7 +62,7 @@ func TestAliasRepository_Update(t *testing.T) {
 		err = alias.UpdateCommand("echo updated")
 		assert.NoError(t, err)
 
-		err = repo.Update(ctx, alias)
+		err = repo.Update(ctx, alias, repository.AnyRevision)
 		assert.NoError(t, err)
 
 		// Verify the update
@@ -74,7 +77,7 @@ func TestAliasRepository_Update(t *testing.T) {
 	t.Run("update non-existent alias", func(t *testing.T) {
 		// Try to update an alias that doesn't exist
 		alias, _ := domain.NewAlias("nonexistent", "echo test")
-		err := repo.Update(ctx, alias)
+		err := repo.Update(ctx, alias, repository.AnyRevision)
 		assert.Error(t, err)
 		assert.ErrorIs(t, err, domain.ErrAliasNotFound)
 	})
@@ -100,7 +103,7 @@ func TestAliasRepository_Update(t *testing.T) {
 		// Update only alias2
 		err = alias2.UpdateCommand("echo updated two")
 		assert.NoError(t, err)
-		err = repo.Update(ctx, alias2)
+		err = repo.Update(ctx, alias2, repository.AnyRevision)
 		assert.NoError(t, err)
 
 		// Verify all aliases
@@ -134,7 +137,7 @@ func TestAliasRepository_Delete(t *testing.T) {
 		assert.NoError(t, err)
 
 		// Delete the alias
-		err = repo.Delete(ctx, "test")
+		err = repo.Delete(ctx, "test", repository.AnyRevision)
 		assert.NoError(t, err)
 
 		// Verify the alias is gone
@@ -145,7 +148,7 @@ func TestAliasRepository_Delete(t *testing.T) {
 
 	t.Run("delete non-existent alias", func(t *testing.T) {
 		// Try to delete an alias that doesn't exist
-		err := repo.Delete(ctx, "nonexistent")
+		err := repo.Delete(ctx, "nonexistent", repository.AnyRevision)
 		assert.Error(t, err)
 		assert.ErrorIs(t, err, domain.ErrAliasNotFound)
 	})
@@ -169,7 +172,7 @@ func TestAliasRepository_Delete(t *testing.T) {
 		assert.NoError(t, err)
 
 		// Delete only alias2
-		err = repo.Delete(ctx, "alias2")
+		err = repo.Delete(ctx, "alias2", repository.AnyRevision)
 		assert.NoError(t, err)
 
 		// Verify remaining aliases
@@ -201,8 +204,130 @@ func TestAliasRepository_Delete(t *testing.T) {
 		repo := json.NewAliasRepository(filePath)
 
 		// Try to delete from empty repository
-		err := repo.Delete(ctx, "test")
+		err := repo.Delete(ctx, "test", repository.AnyRevision)
 		assert.Error(t, err)
 		assert.ErrorIs(t, err, domain.ErrAliasNotFound)
 	})
 }
+
+func TestAliasRepository_Rename(t *testing.T) {
+	ctx := context.Background()
+
+	newRepo := func(t *testing.T, names ...string) repository.AliasRepository {
+		repo := json.NewAliasRepository(filepath.Join(t.TempDir(), "aliases.json"))
+		for _, name := range names {
+			alias, _ := domain.NewAlias(name, "echo "+name)
+			assert.NoError(t, repo.Create(ctx, alias))
+		}
+		return repo
+	}
+
+	t.Run("rename subtree", func(t *testing.T) {
+		repo := newRepo(t, "k8s/logs", "k8s/prod/logs", "git/st")
+		original, _ := repo.FindByName(ctx, "k8s/logs")
+
+		err := repo.Rename(ctx, map[string]string{
+			"k8s/logs":      "kube/logs",
+			"k8s/prod/logs": "kube/prod/logs",
+		}, false)
+		assert.NoError(t, err)
+
+		renamed, err := repo.FindByName(ctx, "kube/logs")
+		assert.NoError(t, err)
+		assert.Equal(t, "echo k8s/logs", renamed.Command)
+		assert.True(t, original.CreatedAt.Equal(renamed.CreatedAt))
+
+		_, err = repo.FindByName(ctx, "k8s/logs")
+		assert.ErrorIs(t, err, domain.ErrAliasNotFound)
+
+		aliases, _ := repo.List(ctx)
+		assert.Len(t, aliases, 3)
+	})
+
+	t.Run("destination exists", func(t *testing.T) {
+		repo := newRepo(t, "a", "b", "c")
+
+		err := repo.Rename(ctx, map[string]string{"a": "x", "b": "c"}, false)
+		assert.ErrorIs(t, err, domain.ErrAliasExists)
+
+		// Nothing is applied when one rename conflicts
+		_, err = repo.FindByName(ctx, "a")
+		assert.NoError(t, err)
+	})
+
+	t.Run("destination overwritten", func(t *testing.T) {
+		repo := newRepo(t, "a", "b")
+
+		err := repo.Rename(ctx, map[string]string{"a": "b"}, true)
+		assert.NoError(t, err)
+
+		aliases, _ := repo.List(ctx)
+		assert.Len(t, aliases, 1)
+		assert.Equal(t, "echo a", aliases[0].Command)
+	})
+
+	t.Run("swap names", func(t *testing.T) {
+		repo := newRepo(t, "a", "b")
+
+		err := repo.Rename(ctx, map[string]string{"a": "b", "b": "a"}, false)
+		assert.NoError(t, err)
+
+		a, _ := repo.FindByName(ctx, "a")
+		assert.Equal(t, "echo b", a.Command)
+	})
+
+	t.Run("source missing", func(t *testing.T) {
+		repo := newRepo(t, "a")
+
+		err := repo.Rename(ctx, map[string]string{"missing": "b"}, false)
+		assert.ErrorIs(t, err, domain.ErrAliasNotFound)
+	})
+}
+
+func TestAliasRepository_Replace(t *testing.T) {
+	repo := json.NewAliasRepository(filepath.Join(t.TempDir(), "aliases.json"))
+	ctx := context.Background()
+
+	old, _ := domain.NewAlias("old", "echo old")
+	assert.NoError(t, repo.Create(ctx, old))
+
+	a, _ := domain.NewAlias("a", "echo a")
+	b, _ := domain.NewAlias("b", "echo b")
+	assert.NoError(t, repo.Replace(ctx, []*domain.Alias{a, b}))
+
+	aliases, err := repo.List(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, aliases, 2)
+
+	_, err = repo.FindByName(ctx, "old")
+	assert.ErrorIs(t, err, domain.ErrAliasNotFound)
+}
+
+func TestAliasRepository_Batch(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "aliases.json")
+	snapshots := backup.New(file, filepath.Join(dir, "backups"), 10, 0)
+	repo := json.NewAliasRepository(file, filestore.WithSnapshots(snapshots))
+	ctx := context.Background()
+
+	old, _ := domain.NewAlias("old", "echo old")
+	assert.NoError(t, repo.Create(ctx, old))
+
+	a, _ := domain.NewAlias("a", "echo a")
+	b, _ := domain.NewAlias("b", "echo b")
+	assert.NoError(t, repo.Batch(ctx, []repository.Op{
+		repository.CreateOp(a),
+		repository.CreateOp(b),
+		repository.DeleteOp("old"),
+	}))
+
+	// The batch is written once, so it takes a single snapshot; creating
+	// the file took none
+	list, err := snapshots.List()
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	aliases, err := repo.List(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, aliases, 2)
+}
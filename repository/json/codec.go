@@ -0,0 +1,77 @@
+// Package json stores aliases in a versioned JSON file, the default
+// global store, which can be encrypted.
+package json
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/internal/crypt"
+	"github.com/msaglietto/mantrid/repository"
+	"github.com/msaglietto/mantrid/repository/filestore"
+)
+
+// codec reads and writes alias files in the current format version,
+// upgrading those of older versions.
+type codec struct{}
+
+func (codec) Decode(data []byte) ([]*domain.Alias, error) {
+	aliases, damage, err := decodeAliases(data)
+	if err != nil {
+		return nil, err
+	}
+	if damage != nil {
+		return aliases, damage
+	}
+	return aliases, nil
+}
+
+func (codec) Encode(aliases []*domain.Alias, previous []byte) ([]byte, error) {
+	return json.MarshalIndent(document{Version: CurrentVersion, Aliases: aliases}, "", "  ")
+}
+
+// DecodeFile decodes the aliases in data, the plaintext contents of an
+// alias file of any format version up to CurrentVersion, such as a copy of
+// the file being imported. Unlike the store, it salvages nothing: damaged
+// files are refused with their Damage.
+func DecodeFile(data []byte) ([]*domain.Alias, error) {
+	aliases, damage, err := decodeAliases(data)
+	if err != nil {
+		return nil, err
+	}
+	if damage != nil {
+		return nil, damage
+	}
+	return aliases, nil
+}
+
+func NewAliasRepository(filePath string, opts ...filestore.Option) repository.AliasRepository {
+	return filestore.New(filePath, codec{}, opts...)
+}
+
+// Inspect reads the alias file at filePath, decrypted with key unless it
+// is nil, salvaging what it can when the file is damaged. A missing file
+// has no aliases.
+func Inspect(filePath string, key *crypt.Key) (*filestore.Inspection, error) {
+	return filestore.New(filePath, codec{}, filestore.WithKey(key)).Inspect()
+}
+
+// Repair rewrites the alias file at filePath with the aliases fix returns
+// for the ones that can be read from it, under the store lock. The file is
+// first copied next to itself as a quarantined file, whose path is
+// returned.
+func Repair(filePath string, key *crypt.Key, lockTimeout time.Duration, fix func([]*domain.Alias) []*domain.Alias) (string, error) {
+	store := filestore.New(filePath, codec{}, filestore.WithKey(key), filestore.WithLockTimeout(lockTimeout))
+	return store.Repair(fix)
+}
+
+// Reencrypt rewrites the alias file at filePath, encrypted with from, so
+// that it is encrypted with to instead, along with its snapshots when opts
+// has them; see filestore.Store.Reencrypt. A nil key stands for a
+// plaintext file, so Reencrypt also encrypts and decrypts alias files. It
+// returns the number of aliases in the file.
+func Reencrypt(filePath string, from, to *crypt.Key, opts ...filestore.Option) (int, error) {
+	store := filestore.New(filePath, codec{}, append(opts, filestore.WithKey(from))...)
+	return store.Reencrypt(to)
+}
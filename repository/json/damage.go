@@ -0,0 +1,70 @@
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/repository/filestore"
+)
+
+// decodeAliases decodes the aliases in data, upgrading them from the
+// format version of the file. When data is not a valid alias file, the
+// aliases that can still be decoded are returned with the location of the
+// damage. Files of a newer format version are refused with an error
+// matching ErrNewerVersion.
+func decodeAliases(data []byte) ([]*domain.Alias, *filestore.Damage, error) {
+	entries, err := decodeDocument(data)
+	if errors.Is(err, ErrNewerVersion) {
+		return nil, nil, err
+	}
+	if err == nil {
+		var aliases []*domain.Alias
+		if aliases, err = decodeEntries(data, entries); err == nil {
+			return aliases, nil, nil
+		}
+	}
+
+	return salvageAliases(data), locate(data, err), nil
+}
+
+// salvageAliases decodes every object in data that is a complete alias on
+// its own, skipping the damaged parts in between.
+func salvageAliases(data []byte) []*domain.Alias {
+	aliases := []*domain.Alias{}
+	for i := 0; i < len(data); i++ {
+		if data[i] != '{' {
+			continue
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(data[i:]))
+		var alias domain.Alias
+		if err := dec.Decode(&alias); err != nil || alias.Name == "" || alias.Command == "" {
+			continue
+		}
+		aliases = append(aliases, &alias)
+		i += int(dec.InputOffset()) - 1
+	}
+	return aliases
+}
+
+// locate returns the line and column of the decoding error err in data.
+func locate(data []byte, err error) *filestore.Damage {
+	var offset int64
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		// The offset is just past the byte that could not be read
+		offset = syntaxErr.Offset - 1
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset - 1
+	}
+	offset = max(0, min(offset, int64(len(data))))
+
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return &filestore.Damage{Line: line, Column: column, Err: err}
+}
@@ -0,0 +1,114 @@
+package json_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/repository/filestore"
+	"github.com/msaglietto/mantrid/repository/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// damagedFile has a missing comma between the first and second alias, and
+// a third alias that is cut off.
+const damagedFile = `[
+  {"name": "gs", "command": "git status", "created_at": "2024-01-01T00:00:00Z", "updated_at": "2024-01-01T00:00:00Z"}
+  {"name": "awk", "command": "awk '{print $1}'", "created_at": "2024-01-01T00:00:00Z", "updated_at": "2024-01-01T00:00:00Z"},
+  {"name": "cut", "command": "ec
+`
+
+func TestDamagedAliasFile(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("inspect locates the damage and salvages aliases", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "aliases.json")
+		require.NoError(t, os.WriteFile(filePath, []byte(damagedFile), 0600))
+
+		inspection, err := json.Inspect(filePath, nil)
+		require.NoError(t, err)
+		require.NotNil(t, inspection.Damage)
+		assert.Equal(t, 3, inspection.Damage.Line)
+		assert.Equal(t, 3, inspection.Damage.Column)
+		require.Len(t, inspection.Aliases, 2)
+		assert.Equal(t, "gs", inspection.Aliases[0].Name)
+		assert.Equal(t, "awk '{print $1}'", inspection.Aliases[1].Command)
+	})
+
+	t.Run("reads keep working and quarantine the file", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "aliases.json")
+		require.NoError(t, os.WriteFile(filePath, []byte(damagedFile), 0600))
+		repo := json.NewAliasRepository(filePath)
+
+		alias, err := repo.FindByName(ctx, "awk")
+		require.NoError(t, err)
+		assert.Equal(t, "awk", alias.Name)
+		_, err = repo.List(ctx)
+		require.NoError(t, err)
+
+		quarantined, err := filepath.Glob(filePath + ".damaged-*")
+		require.NoError(t, err)
+		require.Len(t, quarantined, 1, "the same damage is quarantined once")
+		data, err := os.ReadFile(quarantined[0])
+		require.NoError(t, err)
+		assert.Equal(t, damagedFile, string(data))
+
+		// The next change writes the salvaged aliases
+		alias, _ = domain.NewAlias("ll", "ls -l")
+		require.NoError(t, repo.Create(ctx, alias))
+		inspection, err := json.Inspect(filePath, nil)
+		require.NoError(t, err)
+		assert.Nil(t, inspection.Damage)
+		assert.Len(t, inspection.Aliases, 3)
+	})
+
+	t.Run("repair", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "aliases.json")
+		require.NoError(t, os.WriteFile(filePath, []byte(damagedFile), 0600))
+
+		quarantine, err := json.Repair(filePath, nil, filestore.DefaultLockTimeout, func(aliases []*domain.Alias) []*domain.Alias {
+			return aliases[:1]
+		})
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(quarantine, filePath+".damaged-"))
+		assert.FileExists(t, quarantine)
+
+		aliases, err := json.NewAliasRepository(filePath).List(ctx)
+		require.NoError(t, err)
+		require.Len(t, aliases, 1)
+		assert.Equal(t, "gs", aliases[0].Name)
+	})
+
+	t.Run("damage in a versioned file", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "aliases.json")
+		require.NoError(t, os.WriteFile(filePath, []byte(`{
+  "version": 2,
+  "aliases": [
+    {"name": "gs", "command": "git status"},
+    {"name": 5, "command": "echo five"}
+  ]
+}`), 0600))
+
+		inspection, err := json.Inspect(filePath, nil)
+		require.NoError(t, err)
+		require.NotNil(t, inspection.Damage)
+		assert.Equal(t, 5, inspection.Damage.Line)
+		assert.Equal(t, 14, inspection.Damage.Column)
+		require.Len(t, inspection.Aliases, 1)
+		assert.Equal(t, "gs", inspection.Aliases[0].Name)
+	})
+
+	t.Run("blank file", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "aliases.json")
+		require.NoError(t, os.WriteFile(filePath, []byte("\n"), 0600))
+
+		inspection, err := json.Inspect(filePath, nil)
+		require.NoError(t, err)
+		assert.Nil(t, inspection.Damage)
+		assert.Empty(t, inspection.Aliases)
+	})
+}
@@ -0,0 +1,94 @@
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/msaglietto/mantrid/domain"
+)
+
+// CurrentVersion is the format version of the alias files written by this
+// version of mantrid.
+const CurrentVersion = 2
+
+// ErrNewerVersion is matched by the errors for alias files written in a
+// format newer than CurrentVersion, which are refused rather than read
+// partially and written back without the data this version does not know.
+var ErrNewerVersion = errors.New("alias file was written by a newer version of mantrid")
+
+// document is the layout of alias files from version 2 on. Version 1 files
+// are a bare list of aliases.
+type document struct {
+	Version int             `json:"version"`
+	Aliases []*domain.Alias `json:"aliases"`
+}
+
+// migrations upgrade the aliases of an alias file from one format version
+// to the next: migrations[v] turns the entries of a version v file into
+// those of a version v+1 file. Every version below CurrentVersion needs one.
+var migrations = map[int]func(entries []json.RawMessage) ([]json.RawMessage, error){
+	// Version 2 only wrapped the list in a versioned document
+	1: func(entries []json.RawMessage) ([]json.RawMessage, error) {
+		return entries, nil
+	},
+}
+
+// decodeDocument decodes the entries of an alias file of any version and
+// upgrades them to the current version.
+func decodeDocument(data []byte) ([]json.RawMessage, error) {
+	var entries []json.RawMessage
+	version := 1
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, err
+		}
+	} else {
+		var doc struct {
+			Version int               `json:"version"`
+			Aliases []json.RawMessage `json:"aliases"`
+		}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		if doc.Version < 2 {
+			return nil, fmt.Errorf("invalid format version %d", doc.Version)
+		}
+		entries, version = doc.Aliases, doc.Version
+	}
+
+	if version > CurrentVersion {
+		return nil, fmt.Errorf("%w: format version %d, while this one reads up to version %d; upgrade mantrid",
+			ErrNewerVersion, version, CurrentVersion)
+	}
+	for ; version < CurrentVersion; version++ {
+		migrate, ok := migrations[version]
+		if !ok {
+			return nil, fmt.Errorf("no migration from format version %d", version)
+		}
+		var err error
+		if entries, err = migrate(entries); err != nil {
+			return nil, fmt.Errorf("failed to migrate aliases from format version %d: %w", version, err)
+		}
+	}
+	return entries, nil
+}
+
+// decodeEntries decodes the entries of an alias file in data. The offsets
+// of decoding errors are made relative to data.
+func decodeEntries(data []byte, entries []json.RawMessage) ([]*domain.Alias, error) {
+	aliases := make([]*domain.Alias, 0, len(entries))
+	for _, entry := range entries {
+		var alias domain.Alias
+		if err := json.Unmarshal(entry, &alias); err != nil {
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) {
+				typeErr.Offset += int64(max(0, bytes.Index(data, entry)))
+			}
+			return nil, err
+		}
+		aliases = append(aliases, &alias)
+	}
+	return aliases, nil
+}
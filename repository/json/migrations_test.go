@@ -0,0 +1,109 @@
+package json_test
+
+import (
+	"context"
+	encjson "encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/repository/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// copyFixture copies the file in testdata named name to a temporary alias
+// file and returns its path.
+func copyFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	filePath := filepath.Join(t.TempDir(), "aliases.json")
+	require.NoError(t, os.WriteFile(filePath, data, 0600))
+	return filePath
+}
+
+func TestLegacyAliasFiles(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("first release", func(t *testing.T) {
+		repo := json.NewAliasRepository(copyFixture(t, "v1-initial.json"))
+
+		aliases, err := repo.List(ctx)
+		require.NoError(t, err)
+		require.Len(t, aliases, 2)
+		assert.Equal(t, "gs", aliases[0].Name)
+		assert.Equal(t, "git status", aliases[0].Command)
+		created := time.Date(2024, 3, 2, 9, 15, 30, 123456789, time.UTC)
+		assert.True(t, created.Equal(aliases[0].CreatedAt))
+		assert.Equal(t, "echo Hello, $1!", aliases[1].Command)
+		// Aliases stored before revisions were kept are at the first one
+		assert.Equal(t, int64(1), aliases[0].Revision)
+	})
+
+	t.Run("every field", func(t *testing.T) {
+		filePath := copyFixture(t, "v1-full.json")
+		repo := json.NewAliasRepository(filePath)
+
+		before, err := repo.List(ctx)
+		require.NoError(t, err)
+		require.Len(t, before, 3)
+		assert.Equal(t, "/src/app", before[1].WorkDir)
+		assert.Equal(t, "make:/src/app/Makefile", before[1].Source)
+		assert.Equal(t, "kubectl logs -n prod", before[0].Completion)
+
+		// A change writes the file in the current format, losing nothing
+		alias, _ := domain.NewAlias("gs", "git status")
+		require.NoError(t, repo.Create(ctx, alias))
+
+		data, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		var doc struct {
+			Version int              `json:"version"`
+			Aliases []map[string]any `json:"aliases"`
+		}
+		require.NoError(t, encjson.Unmarshal(data, &doc))
+		assert.Equal(t, json.CurrentVersion, doc.Version)
+		assert.Len(t, doc.Aliases, 4)
+
+		after, err := repo.List(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, before, after[:3])
+	})
+
+	t.Run("empty list", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "aliases.json")
+		require.NoError(t, os.WriteFile(filePath, []byte("[]"), 0600))
+
+		aliases, err := json.NewAliasRepository(filePath).List(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, aliases)
+	})
+}
+
+func TestNewerAliasFiles(t *testing.T) {
+	ctx := context.Background()
+	filePath := copyFixture(t, "v3-future.json")
+	original, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	repo := json.NewAliasRepository(filePath)
+
+	_, err = repo.List(ctx)
+	assert.ErrorIs(t, err, json.ErrNewerVersion)
+	assert.ErrorContains(t, err, "format version 3, while this one reads up to version 2; upgrade mantrid")
+
+	alias, _ := domain.NewAlias("ll", "ls -l")
+	assert.ErrorIs(t, repo.Create(ctx, alias), json.ErrNewerVersion)
+	assert.ErrorIs(t, repo.Replace(ctx, []*domain.Alias{alias}), json.ErrNewerVersion)
+	_, err = json.Inspect(filePath, nil)
+	assert.ErrorIs(t, err, json.ErrNewerVersion)
+
+	data, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, original, data, "the file is left alone")
+	quarantined, err := filepath.Glob(filePath + ".damaged-*")
+	require.NoError(t, err)
+	assert.Empty(t, quarantined)
+}
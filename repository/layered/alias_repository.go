@@ -0,0 +1,385 @@
+// Package layered combines several alias stores into one, such as the alias
+// files of a project and its parent directories over the user's global
+// store. Stores nearer the front shadow aliases of the same name further
+// back.
+package layered
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/repository"
+)
+
+// Layer is one of the stores of a layered repository.
+type Layer struct {
+	*domain.Layer
+	Repo repository.AliasRepository
+}
+
+type aliasRepository struct {
+	// layers are ordered nearest first; the last one is the global store,
+	// where aliases without a layer are created.
+	layers []Layer
+}
+
+// NewAliasRepository returns a repository reading aliases from layers, the
+// nearest first, and writing each alias to the layer it belongs to. The last
+// layer is the global store. Aliases read from it carry their layer.
+//
+// Changes spanning several layers, such as renaming aliases of different
+// layers at once, are applied layer by layer and are only atomic within each
+// layer.
+func NewAliasRepository(layers ...Layer) repository.AliasRepository {
+	return &aliasRepository{layers: layers}
+}
+
+// Close closes the repositories of every layer.
+func (r *aliasRepository) Close() error {
+	var errs []error
+	for _, l := range r.layers {
+		errs = append(errs, repository.Close(l.Repo))
+	}
+	return errors.Join(errs...)
+}
+
+// tag returns a copy of alias marked as read from layer i.
+func (r *aliasRepository) tag(alias *domain.Alias, i int) *domain.Alias {
+	cp := *alias
+	cp.Layer = r.layers[i].Layer
+	return &cp
+}
+
+// untag returns a copy of alias without its layer, as stored.
+func untag(alias *domain.Alias) *domain.Alias {
+	cp := *alias
+	cp.Layer = nil
+	return &cp
+}
+
+// readLayers returns the aliases of every layer and, for every visible
+// alias name, the index of the layer it is read from.
+func (r *aliasRepository) readLayers(ctx context.Context) ([][]*domain.Alias, map[string]int, error) {
+	contents := make([][]*domain.Alias, len(r.layers))
+	owners := map[string]int{}
+	for i, layer := range r.layers {
+		aliases, err := layer.Repo.List(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", layer.Name, err)
+		}
+		contents[i] = aliases
+		for _, a := range aliases {
+			if _, ok := owners[a.Name]; !ok {
+				owners[a.Name] = i
+			}
+		}
+	}
+	return contents, owners, nil
+}
+
+// layerOf returns the index of the layer alias is to be stored in: its own
+// layer when it has one, else the layer of the visible alias of the same
+// name, else the global store.
+func (r *aliasRepository) layerOf(alias *domain.Alias, owners map[string]int) (int, error) {
+	if alias.Layer != nil {
+		for i, layer := range r.layers {
+			if layer.File == alias.Layer.File {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("alias %q belongs to unknown alias file %s", alias.Name, alias.Layer.File)
+	}
+	if i, ok := owners[alias.Name]; ok {
+		return i, nil
+	}
+	return len(r.layers) - 1, nil
+}
+
+func (r *aliasRepository) Create(ctx context.Context, alias *domain.Alias) error {
+	_, owners, err := r.readLayers(ctx)
+	if err != nil {
+		return err
+	}
+	if _, exists := owners[alias.Name]; exists {
+		return domain.ErrAliasExists
+	}
+
+	i, err := r.layerOf(alias, owners)
+	if err != nil {
+		return err
+	}
+	return r.layers[i].Repo.Create(ctx, untag(alias))
+}
+
+func (r *aliasRepository) FindByName(ctx context.Context, name string) (*domain.Alias, error) {
+	for i, layer := range r.layers {
+		alias, err := layer.Repo.FindByName(ctx, name)
+		if err == nil {
+			return r.tag(alias, i), nil
+		}
+		if !errors.Is(err, domain.ErrAliasNotFound) {
+			return nil, fmt.Errorf("%s: %w", layer.Name, err)
+		}
+	}
+	return nil, domain.ErrAliasNotFound
+}
+
+func (r *aliasRepository) List(ctx context.Context) ([]*domain.Alias, error) {
+	contents, owners, err := r.readLayers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var aliases []*domain.Alias
+	for i, layer := range contents {
+		for _, a := range layer {
+			if owners[a.Name] == i {
+				aliases = append(aliases, r.tag(a, i))
+			}
+		}
+	}
+	return aliases, nil
+}
+
+func (r *aliasRepository) Update(ctx context.Context, alias *domain.Alias, expected int64) error {
+	_, owners, err := r.readLayers(ctx)
+	if err != nil {
+		return err
+	}
+	i, ok := owners[alias.Name]
+	if !ok {
+		return domain.ErrAliasNotFound
+	}
+	return r.layers[i].Repo.Update(ctx, untag(alias), expected)
+}
+
+func (r *aliasRepository) Delete(ctx context.Context, name string, expected int64) error {
+	_, owners, err := r.readLayers(ctx)
+	if err != nil {
+		return err
+	}
+	i, ok := owners[name]
+	if !ok {
+		return domain.ErrAliasNotFound
+	}
+	return r.layers[i].Repo.Delete(ctx, name, expected)
+}
+
+// Rename renames every alias within its own layer. A destination taken by a
+// visible alias of another layer is an error unless overwrite is set, in
+// which case that alias is deleted, at the revision it was read at, once
+// the renames of every layer are checked.
+func (r *aliasRepository) Rename(ctx context.Context, renames map[string]string, overwrite bool) error {
+	contents, owners, err := r.readLayers(ctx)
+	if err != nil {
+		return err
+	}
+
+	visible := make([]string, 0, len(owners))
+	for name := range owners {
+		visible = append(visible, name)
+	}
+	if err := repository.CheckRenames(visible, renames, overwrite); err != nil {
+		return err
+	}
+
+	groups := map[int]map[string]string{}
+	var replaced []*domain.Alias
+	for from, to := range renames {
+		i, ok := owners[from]
+		if !ok {
+			return fmt.Errorf("%w: %q", domain.ErrAliasNotFound, from)
+		}
+		if groups[i] == nil {
+			groups[i] = map[string]string{}
+		}
+		groups[i][from] = to
+
+		if j, taken := owners[to]; taken && j != i {
+			if _, moving := renames[to]; !moving {
+				if !overwrite {
+					return fmt.Errorf("%w: %q", domain.ErrAliasExists, to)
+				}
+				replaced = append(replaced, find(contents[j], to))
+			}
+		}
+	}
+
+	for i, group := range groups {
+		names := make([]string, len(contents[i]))
+		for n, a := range contents[i] {
+			names[n] = a.Name
+		}
+		if err := repository.CheckRenames(names, group, overwrite); err != nil {
+			return fmt.Errorf("%s: %w", r.layers[i].Name, err)
+		}
+	}
+	for _, alias := range replaced {
+		if err := r.layers[owners[alias.Name]].Repo.Delete(ctx, alias.Name, alias.Revision); err != nil {
+			return err
+		}
+	}
+	for i, group := range groups {
+		if err := r.layers[i].Repo.Rename(ctx, group, overwrite); err != nil {
+			return fmt.Errorf("%s: %w", r.layers[i].Name, err)
+		}
+	}
+	return nil
+}
+
+// find returns the alias called name among aliases, or nil.
+func find(aliases []*domain.Alias, name string) *domain.Alias {
+	for _, a := range aliases {
+		if a.Name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// Replace makes aliases the visible contents of the repository. Each alias
+// goes to its layer, see layerOf; aliases shadowed by a nearer layer are
+// kept, and layers whose contents do not change are not written.
+func (r *aliasRepository) Replace(ctx context.Context, aliases []*domain.Alias) error {
+	contents, owners, err := r.readLayers(ctx)
+	if err != nil {
+		return err
+	}
+
+	updated := make([][]*domain.Alias, len(r.layers))
+	for i, layer := range contents {
+		updated[i] = []*domain.Alias{}
+		for _, a := range layer {
+			if owners[a.Name] < i {
+				updated[i] = append(updated[i], a)
+			}
+		}
+	}
+	for _, alias := range aliases {
+		i, err := r.layerOf(alias, owners)
+		if err != nil {
+			return err
+		}
+		updated[i] = append(updated[i], untag(alias))
+	}
+
+	for i, layer := range r.layers {
+		if sameAliases(contents[i], updated[i]) {
+			continue
+		}
+		if err := layer.Repo.Replace(ctx, updated[i]); err != nil {
+			return fmt.Errorf("%s: %w", layer.Name, err)
+		}
+	}
+	return nil
+}
+
+// Batch routes each operation to the layer of the visible alias it changes,
+// or for a created alias to its layer, see layerOf, and applies the
+// operations of every layer as one batch of that layer. The whole batch,
+// with the revisions it expects, is checked before any layer is written, so
+// that a batch that cannot apply writes nothing. A layer that fails to
+// write after that, such as one changed meanwhile, leaves the layers
+// written before it changed; the error names them.
+func (r *aliasRepository) Batch(ctx context.Context, ops []repository.Op) error {
+	contents, _, err := r.readLayers(ctx)
+	if err != nil {
+		return err
+	}
+
+	// stored holds the aliases of every layer as the batch leaves them
+	stored := make([]map[string]*domain.Alias, len(r.layers))
+	for i, layer := range contents {
+		stored[i] = make(map[string]*domain.Alias, len(layer))
+		for _, a := range layer {
+			stored[i][a.Name] = a
+		}
+	}
+	owners := func() map[string]int {
+		owners := map[string]int{}
+		for i := len(stored) - 1; i >= 0; i-- {
+			for name := range stored[i] {
+				owners[name] = i
+			}
+		}
+		return owners
+	}
+
+	groups := make([][]repository.Op, len(r.layers))
+	indexes := make([][]int, len(r.layers))
+	for n, op := range ops {
+		if err := op.Validate(); err != nil {
+			return &repository.BatchError{Index: n, Op: op, Err: err}
+		}
+		current := owners()
+		name := op.Target()
+		i, exists := current[name]
+		switch {
+		case op.Kind == repository.OpCreate && exists:
+			return &repository.BatchError{Index: n, Op: op, Err: domain.ErrAliasExists}
+		case op.Kind != repository.OpCreate && !exists:
+			return &repository.BatchError{Index: n, Op: op, Err: domain.ErrAliasNotFound}
+		case op.Kind != repository.OpCreate:
+			if err := repository.CheckRevision(stored[i][name], op.Expected); err != nil {
+				return &repository.BatchError{Index: n, Op: op, Err: err}
+			}
+		}
+
+		switch op.Kind {
+		case repository.OpCreate, repository.OpUpdate:
+			if op.Kind == repository.OpCreate {
+				if i, err = r.layerOf(op.Alias, current); err != nil {
+					return &repository.BatchError{Index: n, Op: op, Err: err}
+				}
+			}
+			op.Alias = untag(op.Alias)
+			stored[i][name] = repository.Revise(op.Alias)
+		case repository.OpDelete:
+			// An alias of the same name further back becomes visible
+			delete(stored[i], name)
+		}
+		groups[i] = append(groups[i], op)
+		indexes[i] = append(indexes[i], n)
+	}
+
+	var written []string
+	for i, group := range groups {
+		if len(group) == 0 {
+			continue
+		}
+		if err := r.layers[i].Repo.Batch(ctx, group); err != nil {
+			// Operations are numbered within the whole batch
+			var batchErr *repository.BatchError
+			if errors.As(err, &batchErr) && batchErr.Index < len(indexes[i]) {
+				n := indexes[i][batchErr.Index]
+				err = &repository.BatchError{Index: n, Op: ops[n], Err: batchErr.Err}
+			}
+			if len(written) > 0 {
+				return fmt.Errorf("%s: %w; the changes to %s were already written", r.layers[i].Name, err, strings.Join(written, ", "))
+			}
+			return fmt.Errorf("%s: %w", r.layers[i].Name, err)
+		}
+		written = append(written, r.layers[i].Name)
+	}
+	return nil
+}
+
+// sameAliases reports whether a and b hold the same aliases in the same
+// order.
+func sameAliases(a, b []*domain.Alias) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		x, y := a[i], b[i]
+		if x.Name != y.Name || x.Command != y.Command || x.Description != y.Description ||
+			x.Completion != y.Completion || x.WorkDir != y.WorkDir || x.Source != y.Source ||
+			!x.CreatedAt.Equal(y.CreatedAt) || !x.UpdatedAt.Equal(y.UpdatedAt) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,279 @@
+package layered_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/repository"
+	"github.com/msaglietto/mantrid/repository/layered"
+	"github.com/msaglietto/mantrid/repository/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	projectLayer = &domain.Layer{Name: ".mantrid.yaml", File: "/src/app/.mantrid.yaml", Root: "/src/app"}
+	globalLayer  = &domain.Layer{Name: domain.GlobalLayerName, File: "/home/user/.mantrid/aliases.json"}
+)
+
+// setup returns a layered repository over a project store holding "build"
+// and "test", and a global store holding "test" and "gs".
+func setup(t *testing.T) (repo, project, global repository.AliasRepository) {
+	t.Helper()
+	ctx := context.Background()
+
+	project, global = memory.NewAliasRepository(), memory.NewAliasRepository()
+	require.NoError(t, project.Create(ctx, &domain.Alias{Name: "build", Command: "make build"}))
+	require.NoError(t, project.Create(ctx, &domain.Alias{Name: "test", Command: "make test"}))
+	require.NoError(t, global.Create(ctx, &domain.Alias{Name: "test", Command: "go test ./..."}))
+	require.NoError(t, global.Create(ctx, &domain.Alias{Name: "gs", Command: "git status"}))
+
+	repo = layered.NewAliasRepository(
+		layered.Layer{Layer: projectLayer, Repo: project},
+		layered.Layer{Layer: globalLayer, Repo: global},
+	)
+	return repo, project, global
+}
+
+func names(aliases []*domain.Alias) []string {
+	result := make([]string, len(aliases))
+	for i, a := range aliases {
+		result[i] = a.Name + "@" + a.Layer.Name
+	}
+	return result
+}
+
+// changingRepo is a store where the aliases change, by change, right
+// before any of them is deleted.
+type changingRepo struct {
+	repository.AliasRepository
+	change func()
+}
+
+func (r *changingRepo) Delete(ctx context.Context, name string, expected int64) error {
+	r.change()
+	return r.AliasRepository.Delete(ctx, name, expected)
+}
+
+// wrappingRepo is a store that tells which alias it did not find.
+type wrappingRepo struct {
+	repository.AliasRepository
+}
+
+func (r *wrappingRepo) FindByName(ctx context.Context, name string) (*domain.Alias, error) {
+	alias, err := r.AliasRepository.FindByName(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", err, name)
+	}
+	return alias, nil
+}
+
+func TestAliasRepository_Read(t *testing.T) {
+	ctx := context.Background()
+	repo, _, _ := setup(t)
+
+	alias, err := repo.FindByName(ctx, "test")
+	require.NoError(t, err)
+	assert.Equal(t, "make test", alias.Command)
+	assert.Same(t, projectLayer, alias.Layer)
+
+	alias, err = repo.FindByName(ctx, "gs")
+	require.NoError(t, err)
+	assert.Same(t, globalLayer, alias.Layer)
+
+	_, err = repo.FindByName(ctx, "missing")
+	assert.ErrorIs(t, err, domain.ErrAliasNotFound)
+
+	aliases, err := repo.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"build@.mantrid.yaml", "test@.mantrid.yaml", "gs@global"}, names(aliases))
+
+	t.Run("lower layers are searched past wrapped not found errors", func(t *testing.T) {
+		_, project, global := setup(t)
+		repo := layered.NewAliasRepository(
+			layered.Layer{Layer: projectLayer, Repo: &wrappingRepo{project}},
+			layered.Layer{Layer: globalLayer, Repo: global},
+		)
+
+		alias, err := repo.FindByName(ctx, "gs")
+		require.NoError(t, err)
+		assert.Same(t, globalLayer, alias.Layer)
+	})
+}
+
+func TestAliasRepository_Write(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("create goes to the alias layer", func(t *testing.T) {
+		repo, project, global := setup(t)
+
+		require.NoError(t, repo.Create(ctx, &domain.Alias{Name: "lint", Command: "make lint", Layer: projectLayer}))
+		require.NoError(t, repo.Create(ctx, &domain.Alias{Name: "ll", Command: "ls -la"}))
+		assert.ErrorIs(t, repo.Create(ctx, &domain.Alias{Name: "build", Command: "go build"}), domain.ErrAliasExists)
+
+		_, err := project.FindByName(ctx, "lint")
+		assert.NoError(t, err)
+		_, err = global.FindByName(ctx, "ll")
+		assert.NoError(t, err)
+	})
+
+	t.Run("update and delete the visible alias", func(t *testing.T) {
+		repo, project, global := setup(t)
+
+		require.NoError(t, repo.Update(ctx, &domain.Alias{Name: "test", Command: "make check"}, repository.AnyRevision))
+		alias, _ := project.FindByName(ctx, "test")
+		assert.Equal(t, "make check", alias.Command)
+		alias, _ = global.FindByName(ctx, "test")
+		assert.Equal(t, "go test ./...", alias.Command)
+
+		require.NoError(t, repo.Delete(ctx, "test", repository.AnyRevision))
+		alias, err := repo.FindByName(ctx, "test")
+		require.NoError(t, err)
+		assert.Equal(t, "go test ./...", alias.Command, "the global alias shows through")
+
+		assert.ErrorIs(t, repo.Delete(ctx, "missing", repository.AnyRevision), domain.ErrAliasNotFound)
+	})
+
+	t.Run("rename within each layer", func(t *testing.T) {
+		repo, project, _ := setup(t)
+
+		require.NoError(t, repo.Rename(ctx, map[string]string{"build": "b", "gs": "st"}, false))
+		aliases, err := repo.List(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"b@.mantrid.yaml", "test@.mantrid.yaml", "st@global"}, names(aliases))
+
+		assert.ErrorIs(t, repo.Rename(ctx, map[string]string{"st": "b"}, false), domain.ErrAliasExists)
+		require.NoError(t, repo.Rename(ctx, map[string]string{"st": "b"}, true))
+		_, err = project.FindByName(ctx, "b")
+		assert.ErrorIs(t, err, domain.ErrAliasNotFound)
+		alias, err := repo.FindByName(ctx, "b")
+		require.NoError(t, err)
+		assert.Equal(t, "git status", alias.Command)
+	})
+
+	t.Run("rename keeps a destination changed meanwhile", func(t *testing.T) {
+		_, project, global := setup(t)
+		changing := &changingRepo{AliasRepository: project, change: func() {
+			require.NoError(t, project.Update(ctx, &domain.Alias{Name: "build", Command: "make all"}, repository.AnyRevision))
+		}}
+		repo := layered.NewAliasRepository(
+			layered.Layer{Layer: projectLayer, Repo: changing},
+			layered.Layer{Layer: globalLayer, Repo: global},
+		)
+
+		err := repo.Rename(ctx, map[string]string{"gs": "build"}, true)
+		assert.ErrorIs(t, err, domain.ErrConflict)
+		build, err := project.FindByName(ctx, "build")
+		require.NoError(t, err)
+		assert.Equal(t, "make all", build.Command)
+		_, err = global.FindByName(ctx, "gs")
+		assert.NoError(t, err)
+	})
+
+	t.Run("replace keeps layers and shadowed aliases", func(t *testing.T) {
+		repo, project, global := setup(t)
+
+		require.NoError(t, repo.Replace(ctx, []*domain.Alias{
+			{Name: "build", Command: "make -j4 build"},
+			{Name: "gs", Command: "git status -sb"},
+			{Name: "ll", Command: "ls -la"},
+		}))
+
+		aliases, err := project.List(ctx)
+		require.NoError(t, err)
+		require.Len(t, aliases, 1)
+		assert.Equal(t, "make -j4 build", aliases[0].Command)
+		assert.Nil(t, aliases[0].Layer)
+
+		aliases, err = global.List(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"test", "gs", "ll"}, []string{aliases[0].Name, aliases[1].Name, aliases[2].Name})
+	})
+}
+
+func TestAliasRepository_Batch(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("routes operations to their layers", func(t *testing.T) {
+		repo, project, global := setup(t)
+
+		err := repo.Batch(ctx, []repository.Op{
+			repository.CreateOp(&domain.Alias{Name: "lint", Command: "make lint", Layer: projectLayer}),
+			repository.CreateOp(&domain.Alias{Name: "gd", Command: "git diff"}),
+			repository.UpdateOp(&domain.Alias{Name: "build", Command: "make all"}),
+			repository.DeleteOp("gs"),
+		})
+		require.NoError(t, err)
+
+		lint, err := project.FindByName(ctx, "lint")
+		require.NoError(t, err)
+		assert.Nil(t, lint.Layer)
+		build, err := project.FindByName(ctx, "build")
+		require.NoError(t, err)
+		assert.Equal(t, "make all", build.Command)
+		_, err = global.FindByName(ctx, "gd")
+		assert.NoError(t, err)
+		_, err = global.FindByName(ctx, "gs")
+		assert.ErrorIs(t, err, domain.ErrAliasNotFound)
+	})
+
+	t.Run("deleting a shadowing alias reveals the one behind it", func(t *testing.T) {
+		repo, _, global := setup(t)
+
+		err := repo.Batch(ctx, []repository.Op{
+			repository.DeleteOp("test"),
+			repository.UpdateOp(&domain.Alias{Name: "test", Command: "go test -race ./..."}),
+		})
+		require.NoError(t, err)
+
+		test, err := global.FindByName(ctx, "test")
+		require.NoError(t, err)
+		assert.Equal(t, "go test -race ./...", test.Command)
+	})
+
+	t.Run("a failing operation changes no layer", func(t *testing.T) {
+		repo, project, _ := setup(t)
+
+		err := repo.Batch(ctx, []repository.Op{
+			repository.DeleteOp("build"),
+			repository.CreateOp(&domain.Alias{Name: "gs", Command: "git st"}),
+		})
+		assert.ErrorIs(t, err, domain.ErrAliasExists)
+
+		_, err = project.FindByName(ctx, "build")
+		assert.NoError(t, err)
+	})
+
+	t.Run("a stale revision in a later layer changes no layer", func(t *testing.T) {
+		repo, project, global := setup(t)
+		gs, err := global.FindByName(ctx, "gs")
+		require.NoError(t, err)
+		require.NoError(t, global.Update(ctx, &domain.Alias{Name: "gs", Command: "git status -sb"}, repository.AnyRevision))
+
+		err = repo.Batch(ctx, []repository.Op{
+			repository.DeleteOp("build"),
+			repository.DeleteOp("gs").At(gs.Revision),
+		})
+		var batchErr *repository.BatchError
+		require.ErrorAs(t, err, &batchErr)
+		assert.Equal(t, 1, batchErr.Index)
+		assert.ErrorIs(t, err, domain.ErrConflict)
+
+		_, err = project.FindByName(ctx, "build")
+		assert.NoError(t, err)
+	})
+
+	t.Run("revisions of aliases changed earlier in the batch", func(t *testing.T) {
+		repo, project, _ := setup(t)
+		build, err := project.FindByName(ctx, "build")
+		require.NoError(t, err)
+
+		err = repo.Batch(ctx, []repository.Op{
+			repository.UpdateOp(&domain.Alias{Name: "build", Command: "make all"}).At(build.Revision),
+			repository.DeleteOp("build").At(build.Revision),
+		})
+		assert.ErrorIs(t, err, domain.ErrConflict)
+	})
+}
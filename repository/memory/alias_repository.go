@@ -11,6 +11,9 @@ import (
 type aliasRepository struct {
 	mu      sync.RWMutex
 	aliases map[string]*domain.Alias
+	// names lists the aliases in the order they were added, as the file
+	// stores keep them.
+	names []string
 }
 
 // NewAliasRepository creates a new in-memory alias repository.
@@ -28,7 +31,8 @@ func (r *aliasRepository) Create(ctx context.Context, alias *domain.Alias) error
 		return domain.ErrAliasExists
 	}
 
-	r.aliases[alias.Name] = alias
+	r.aliases[alias.Name] = repository.Revise(alias)
+	r.names = append(r.names, alias.Name)
 	return nil
 }
 
@@ -49,33 +53,96 @@ func (r *aliasRepository) List(ctx context.Context) ([]*domain.Alias, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	result := make([]*domain.Alias, 0, len(r.aliases))
-	for _, alias := range r.aliases {
-		result = append(result, alias)
+	return r.list(), nil
+}
+
+// list returns the aliases in the order they were added.
+func (r *aliasRepository) list() []*domain.Alias {
+	result := make([]*domain.Alias, len(r.names))
+	for i, name := range r.names {
+		result[i] = r.aliases[name]
+	}
+	return result
+}
+
+// set makes aliases the contents of the repository, in their order.
+func (r *aliasRepository) set(aliases []*domain.Alias) {
+	r.aliases = make(map[string]*domain.Alias, len(aliases))
+	r.names = make([]string, len(aliases))
+	for i, alias := range aliases {
+		r.aliases[alias.Name] = alias
+		r.names[i] = alias.Name
 	}
-	return result, nil
 }
 
-func (r *aliasRepository) Update(ctx context.Context, alias *domain.Alias) error {
+func (r *aliasRepository) Update(ctx context.Context, alias *domain.Alias, expected int64) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, ok := r.aliases[alias.Name]; !ok {
+	stored, ok := r.aliases[alias.Name]
+	if !ok {
 		return domain.ErrAliasNotFound
 	}
+	if err := repository.CheckRevision(stored, expected); err != nil {
+		return err
+	}
 
-	r.aliases[alias.Name] = alias
+	r.aliases[alias.Name] = repository.Revise(alias)
 	return nil
 }
 
-func (r *aliasRepository) Delete(ctx context.Context, name string) error {
+func (r *aliasRepository) Delete(ctx context.Context, name string, expected int64) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, ok := r.aliases[name]; !ok {
+	stored, ok := r.aliases[name]
+	if !ok {
 		return domain.ErrAliasNotFound
 	}
+	if err := repository.CheckRevision(stored, expected); err != nil {
+		return err
+	}
 
 	delete(r.aliases, name)
+	for i, n := range r.names {
+		if n == name {
+			r.names = append(r.names[:i], r.names[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (r *aliasRepository) Rename(ctx context.Context, renames map[string]string, overwrite bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	renamed, err := repository.ApplyRenames(r.list(), renames, overwrite)
+	if err != nil {
+		return err
+	}
+
+	r.set(renamed)
+	return nil
+}
+
+func (r *aliasRepository) Batch(ctx context.Context, ops []repository.Op) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	changed, err := repository.ApplyBatch(r.list(), ops)
+	if err != nil {
+		return err
+	}
+
+	r.set(changed)
+	return nil
+}
+
+func (r *aliasRepository) Replace(ctx context.Context, aliases []*domain.Alias) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.set(repository.ReviseAll(r.list(), aliases))
 	return nil
 }
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/msaglietto/mantrid/domain"
+)
+
+// CheckRenames fails when renames cannot be applied to a store holding the
+// aliases called names: when a source does not exist, when two sources
+// share a destination, or when a destination is taken by an alias that is
+// not itself being renamed and overwrite is not set.
+func CheckRenames(names []string, renames map[string]string, overwrite bool) error {
+	existing := make(map[string]bool, len(names))
+	for _, name := range names {
+		existing[name] = true
+	}
+
+	targets := make(map[string]bool, len(renames))
+	for from, to := range renames {
+		if !existing[from] {
+			return fmt.Errorf("%w: %q", domain.ErrAliasNotFound, from)
+		}
+		if targets[to] {
+			return fmt.Errorf("%w: %q is the destination of more than one rename", domain.ErrAliasExists, to)
+		}
+		targets[to] = true
+
+		if _, moving := renames[to]; existing[to] && !moving && !overwrite {
+			return fmt.Errorf("%w: %q", domain.ErrAliasExists, to)
+		}
+	}
+	return nil
+}
+
+// ApplyRenames returns aliases with renames applied, for use by repository
+// implementations of Rename. It fails without modifying anything when
+// CheckRenames does. Renamed aliases keep their other attributes and get a
+// new UpdatedAt and a new revision.
+func ApplyRenames(aliases []*domain.Alias, renames map[string]string, overwrite bool) ([]*domain.Alias, error) {
+	names := make([]string, len(aliases))
+	for i, a := range aliases {
+		names[i] = a.Name
+	}
+	if err := CheckRenames(names, renames, overwrite); err != nil {
+		return nil, err
+	}
+
+	targets := make(map[string]bool, len(renames))
+	for _, to := range renames {
+		targets[to] = true
+	}
+
+	now := time.Now()
+	result := make([]*domain.Alias, 0, len(aliases))
+	for _, a := range aliases {
+		if to, ok := renames[a.Name]; ok {
+			renamed := *a
+			renamed.Name = to
+			renamed.UpdatedAt = now
+			renamed.Revision = ContentRevision(&renamed)
+			result = append(result, &renamed)
+			continue
+		}
+		if targets[a.Name] {
+			// Overwritten by a renamed alias
+			continue
+		}
+		result = append(result, a)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/msaglietto/mantrid/domain"
+)
+
+// AnyRevision, as the revision expected by Update, Delete or an Op, applies
+// the change whatever the revision of the stored alias.
+const AnyRevision int64 = 0
+
+// CheckRevision fails with domain.ErrConflict when stored is not at
+// revision expected.
+func CheckRevision(stored *domain.Alias, expected int64) error {
+	if expected != AnyRevision && Revision(stored) != expected {
+		return fmt.Errorf("%w: %q is at revision %d, expected %d",
+			domain.ErrConflict, stored.Name, Revision(stored), expected)
+	}
+	return nil
+}
+
+// Revision returns the revision of the stored alias. Aliases stored before
+// revisions were kept are at revision 1.
+func Revision(stored *domain.Alias) int64 {
+	if stored.Revision < 1 {
+		return 1
+	}
+	return stored.Revision
+}
+
+// Revise returns a copy of alias at the revision of its attributes, see
+// ContentRevision, for writing it to a store.
+func Revise(alias *domain.Alias) *domain.Alias {
+	revised := *alias
+	revised.Revision = ContentRevision(&revised)
+	return &revised
+}
+
+// ContentRevision returns the revision of the attributes of alias, a hash of
+// those sameAlias compares. An alias removed and created again does not
+// return to a revision it had before, as a count of its writes would, since
+// its creation time differs; a change based on a copy of the alias that
+// was removed still conflicts.
+func ContentRevision(alias *domain.Alias) int64 {
+	h := fnv.New64a()
+	for _, field := range []string{alias.Name, alias.Command, alias.Description, alias.Completion,
+		alias.WorkDir, alias.Source, alias.CreatedAt.UTC().Format(time.RFC3339Nano),
+		alias.UpdatedAt.UTC().Format(time.RFC3339Nano)} {
+		h.Write([]byte(field))
+		h.Write([]byte{0})
+	}
+
+	// Positive, and distinct from AnyRevision and the revision of aliases
+	// stored before revisions were kept
+	revision := int64(h.Sum64() >> 1)
+	if revision <= 1 {
+		revision += 2
+	}
+	return revision
+}
+
+// ReviseAll returns aliases, about to replace stored as the contents of a
+// store, with their revisions set for implementations of Replace: an alias
+// equal to the stored alias of the same name keeps its revision, and any
+// other is revised.
+func ReviseAll(stored, aliases []*domain.Alias) []*domain.Alias {
+	previous := make(map[string]*domain.Alias, len(stored))
+	for _, a := range stored {
+		previous[a.Name] = a
+	}
+
+	result := make([]*domain.Alias, len(aliases))
+	for i, a := range aliases {
+		p := previous[a.Name]
+		if p != nil && sameAlias(p, a) {
+			kept := *a
+			kept.Revision = Revision(p)
+			result[i] = &kept
+			continue
+		}
+		result[i] = Revise(a)
+	}
+	return result
+}
+
+// sameAlias reports whether a and b hold the same stored attributes, apart
+// from their revision.
+func sameAlias(a, b *domain.Alias) bool {
+	return a.Name == b.Name && a.Command == b.Command && a.Description == b.Description &&
+		a.Completion == b.Completion && a.WorkDir == b.WorkDir && a.Source == b.Source &&
+		a.CreatedAt.Equal(b.CreatedAt) && a.UpdatedAt.Equal(b.UpdatedAt)
+}
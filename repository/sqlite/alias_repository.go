@@ -0,0 +1,492 @@
+// Package sqlite stores aliases in a SQLite database, using a pure-Go
+// driver so that mantrid builds without cgo. Unlike the JSON store it does
+// not rewrite every alias on each change, which keeps large stores fast.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/repository"
+	_ "modernc.org/sqlite"
+)
+
+// DefaultBusyTimeout is how long changes wait for another process writing
+// the database, unless configured otherwise.
+const DefaultBusyTimeout = 5 * time.Second
+
+// aliasColumns are the columns of an alias, in the order scanAlias reads
+// them.
+const aliasColumns = "name, command, description, completion, workdir, source, created_at, updated_at, revision"
+
+type aliasRepository struct {
+	filePath    string
+	busyTimeout time.Duration
+
+	once sync.Once
+	db   *sql.DB
+	err  error
+}
+
+// Option configures optional behaviour of the repository.
+type Option func(*aliasRepository)
+
+// WithBusyTimeout sets how long changes wait for another process writing
+// the database before failing.
+func WithBusyTimeout(timeout time.Duration) Option {
+	return func(r *aliasRepository) {
+		r.busyTimeout = timeout
+	}
+}
+
+// NewAliasRepository returns a repository storing aliases in the database
+// at filePath. The database is created and migrated to the current schema
+// when first used.
+func NewAliasRepository(filePath string, opts ...Option) repository.AliasRepository {
+	r := &aliasRepository{
+		filePath:    filePath,
+		busyTimeout: DefaultBusyTimeout,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// conn opens the database on first use.
+func (r *aliasRepository) conn(ctx context.Context) (*sql.DB, error) {
+	r.once.Do(func() {
+		r.db, r.err = open(ctx, r.filePath, r.busyTimeout)
+	})
+	return r.db, r.err
+}
+
+// Close closes the database. A repository that was never used is not
+// opened afterwards.
+func (r *aliasRepository) Close() error {
+	r.once.Do(func() {
+		r.err = errClosed
+	})
+	if r.db == nil {
+		return nil
+	}
+	return r.db.Close()
+}
+
+var errClosed = errors.New("database is closed")
+
+// open opens the database at path in WAL mode, so that readers do not wait
+// for writers, and migrates it. Transactions take the write lock when they
+// begin, which keeps concurrent read-modify-writes from deadlocking.
+func open(ctx context.Context, path string, busyTimeout time.Duration) (*sql.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", busyTimeout.Milliseconds()))
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_txlock", "immediate")
+	db, err := sql.Open("sqlite", "file:"+filepath.ToSlash(path)+"?"+params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	if err := migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// write runs fn in a transaction and then checkpoints the write-ahead log,
+// so that the modification time of the database file follows its changes.
+func (r *aliasRepository) write(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to write aliases: %w", err)
+	}
+
+	// A checkpoint blocked by readers is retried after the next change
+	db.ExecContext(ctx, "PRAGMA wal_checkpoint(PASSIVE)")
+	return nil
+}
+
+func (r *aliasRepository) Create(ctx context.Context, alias *domain.Alias) error {
+	return r.write(ctx, func(tx *sql.Tx) error {
+		exists, err := hasAlias(ctx, tx, alias.Name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return domain.ErrAliasExists
+		}
+		return insertAlias(ctx, tx, repository.Revise(alias))
+	})
+}
+
+func (r *aliasRepository) FindByName(ctx context.Context, name string) (*domain.Alias, error) {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	row := db.QueryRowContext(ctx, "SELECT "+aliasColumns+" FROM aliases WHERE name = ?", name)
+	alias, err := scanAlias(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrAliasNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alias: %w", err)
+	}
+	return alias, nil
+}
+
+func (r *aliasRepository) List(ctx context.Context) ([]*domain.Alias, error) {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return listAliases(ctx, db)
+}
+
+func (r *aliasRepository) Update(ctx context.Context, alias *domain.Alias, expected int64) error {
+	return r.write(ctx, func(tx *sql.Tx) error {
+		return updateAlias(ctx, tx, alias, expected)
+	})
+}
+
+func (r *aliasRepository) Delete(ctx context.Context, name string, expected int64) error {
+	return r.write(ctx, func(tx *sql.Tx) error {
+		return deleteAlias(ctx, tx, name, expected)
+	})
+}
+
+// Rename moves the renamed aliases out of the way under names no alias can
+// have before giving them their destinations, so that aliases can swap
+// names without ever sharing one.
+func (r *aliasRepository) Rename(ctx context.Context, renames map[string]string, overwrite bool) error {
+	return r.write(ctx, func(tx *sql.Tx) error {
+		names, err := listNames(ctx, tx)
+		if err != nil {
+			return err
+		}
+		if err := repository.CheckRenames(names, renames, overwrite); err != nil {
+			return err
+		}
+
+		for _, to := range renames {
+			if _, moving := renames[to]; moving {
+				continue
+			}
+			// Overwritten by a renamed alias, when it exists
+			if _, err := tx.ExecContext(ctx, "DELETE FROM aliases WHERE name = ?", to); err != nil {
+				return fmt.Errorf("failed to write aliases: %w", err)
+			}
+		}
+
+		now := time.Now()
+		for from := range renames {
+			_, err := tx.ExecContext(ctx, "UPDATE aliases SET name = ? WHERE name = ?", renamingPrefix+from, from)
+			if err != nil {
+				return fmt.Errorf("failed to write aliases: %w", err)
+			}
+		}
+		for from, to := range renames {
+			stored, err := storedAlias(ctx, tx, renamingPrefix+from, repository.AnyRevision)
+			if err != nil {
+				return err
+			}
+			renamed := *stored
+			renamed.Name = to
+			renamed.UpdatedAt = now
+			revised := repository.Revise(&renamed)
+			_, err = tx.ExecContext(ctx, "UPDATE aliases SET name = ?, updated_at = ?, revision = ? WHERE name = ?",
+				to, formatTime(now), revised.Revision, renamingPrefix+from)
+			if err != nil {
+				return fmt.Errorf("failed to write aliases: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// renamingPrefix makes the names renamed aliases hold while Rename runs,
+// which no valid alias name can hold, since it contains a space.
+const renamingPrefix = "renaming "
+
+// Replace writes only the aliases that differ from the stored ones.
+func (r *aliasRepository) Replace(ctx context.Context, aliases []*domain.Alias) error {
+	return r.write(ctx, func(tx *sql.Tx) error {
+		stored, err := listAliases(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		previous := make(map[string]*domain.Alias, len(stored))
+		for _, a := range stored {
+			previous[a.Name] = a
+		}
+		kept := make(map[string]bool, len(aliases))
+		for _, a := range aliases {
+			kept[a.Name] = true
+		}
+
+		for _, a := range stored {
+			if !kept[a.Name] {
+				if err := deleteAlias(ctx, tx, a.Name, a.Revision); err != nil {
+					return err
+				}
+			}
+		}
+		for _, alias := range repository.ReviseAll(stored, aliases) {
+			p := previous[alias.Name]
+			switch {
+			case p == nil:
+				err = insertAlias(ctx, tx, alias)
+			case p.Revision != alias.Revision:
+				err = setAlias(ctx, tx, alias, p.Revision)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Batch applies each operation with its own statement, in order, within
+// the transaction.
+func (r *aliasRepository) Batch(ctx context.Context, ops []repository.Op) error {
+	if len(ops) == 0 {
+		return nil
+	}
+
+	return r.write(ctx, func(tx *sql.Tx) error {
+		for n, op := range ops {
+			if err := applyOp(ctx, tx, op); err != nil {
+				return &repository.BatchError{Index: n, Op: op, Err: err}
+			}
+		}
+		return nil
+	})
+}
+
+func applyOp(ctx context.Context, tx *sql.Tx, op repository.Op) error {
+	if err := op.Validate(); err != nil {
+		return err
+	}
+
+	switch op.Kind {
+	case repository.OpCreate:
+		exists, err := hasAlias(ctx, tx, op.Alias.Name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return domain.ErrAliasExists
+		}
+		return insertAlias(ctx, tx, repository.Revise(op.Alias))
+	case repository.OpUpdate:
+		return updateAlias(ctx, tx, op.Alias, op.Expected)
+	default:
+		return deleteAlias(ctx, tx, op.Name, op.Expected)
+	}
+}
+
+// querier is what reading aliases needs of a database or transaction.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func hasAlias(ctx context.Context, q querier, name string) (bool, error) {
+	var n int
+	err := q.QueryRowContext(ctx, "SELECT COUNT(*) FROM aliases WHERE name = ?", name).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("failed to read aliases: %w", err)
+	}
+	return n > 0, nil
+}
+
+// storedAlias returns the alias called name, failing with
+// domain.ErrConflict unless it is at revision expected, see
+// repository.CheckRevision.
+func storedAlias(ctx context.Context, tx *sql.Tx, name string, expected int64) (*domain.Alias, error) {
+	row := tx.QueryRowContext(ctx, "SELECT "+aliasColumns+" FROM aliases WHERE name = ?", name)
+	stored, err := scanAlias(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrAliasNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alias: %w", err)
+	}
+	if err := repository.CheckRevision(stored, expected); err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// listAliases returns every alias in the order they were added.
+func listAliases(ctx context.Context, q querier) ([]*domain.Alias, error) {
+	rows, err := q.QueryContext(ctx, "SELECT "+aliasColumns+" FROM aliases ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read aliases: %w", err)
+	}
+	defer rows.Close()
+
+	aliases := []*domain.Alias{}
+	for rows.Next() {
+		alias, err := scanAlias(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read aliases: %w", err)
+		}
+		aliases = append(aliases, alias)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read aliases: %w", err)
+	}
+	return aliases, nil
+}
+
+func insertAlias(ctx context.Context, tx *sql.Tx, alias *domain.Alias) error {
+	_, err := tx.ExecContext(ctx, "INSERT INTO aliases ("+aliasColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		alias.Name, alias.Command, alias.Description, alias.Completion, alias.WorkDir, alias.Source,
+		formatTime(alias.CreatedAt), formatTime(alias.UpdatedAt), alias.Revision)
+	if err != nil {
+		return fmt.Errorf("failed to write alias %q: %w", alias.Name, err)
+	}
+	return nil
+}
+
+// updateAlias replaces the stored alias of the same name as alias with
+// alias at a new revision, unless it is not at revision expected.
+func updateAlias(ctx context.Context, tx *sql.Tx, alias *domain.Alias, expected int64) error {
+	stored, err := storedAlias(ctx, tx, alias.Name, expected)
+	if err != nil {
+		return err
+	}
+	return setAlias(ctx, tx, repository.Revise(alias), stored.Revision)
+}
+
+// setAlias replaces the stored alias of the same name as alias, at
+// revision, with alias.
+func setAlias(ctx context.Context, tx *sql.Tx, alias *domain.Alias, revision int64) error {
+	res, err := tx.ExecContext(ctx, `UPDATE aliases SET command = ?, description = ?, completion = ?,
+		workdir = ?, source = ?, created_at = ?, updated_at = ?, revision = ? WHERE name = ? AND revision = ?`,
+		alias.Command, alias.Description, alias.Completion, alias.WorkDir, alias.Source,
+		formatTime(alias.CreatedAt), formatTime(alias.UpdatedAt), alias.Revision, alias.Name, revision)
+	return checkWritten(ctx, tx, res, err, alias.Name, revision)
+}
+
+// deleteAlias removes the alias called name, unless it is not at revision
+// expected.
+func deleteAlias(ctx context.Context, tx *sql.Tx, name string, expected int64) error {
+	stored, err := storedAlias(ctx, tx, name, expected)
+	if err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx, "DELETE FROM aliases WHERE name = ? AND revision = ?", name, stored.Revision)
+	return checkWritten(ctx, tx, res, err, name, stored.Revision)
+}
+
+// checkWritten returns the error of a statement writing the alias called
+// name at revision, which fails as storedAlias does when it matched no
+// row.
+func checkWritten(ctx context.Context, tx *sql.Tx, res sql.Result, err error, name string, revision int64) error {
+	if err != nil {
+		return fmt.Errorf("failed to write aliases: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to write aliases: %w", err)
+	}
+	if n == 0 {
+		if _, err := storedAlias(ctx, tx, name, revision); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: %q", domain.ErrConflict, name)
+	}
+	return nil
+}
+
+// listNames returns the names of every alias.
+func listNames(ctx context.Context, q querier) ([]string, error) {
+	rows, err := q.QueryContext(ctx, "SELECT name FROM aliases")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read aliases: %w", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to read aliases: %w", err)
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read aliases: %w", err)
+	}
+	return names, nil
+}
+
+// scanner is a row of aliasColumns.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAlias(row scanner) (*domain.Alias, error) {
+	var alias domain.Alias
+	var createdAt, updatedAt string
+	if err := row.Scan(&alias.Name, &alias.Command, &alias.Description, &alias.Completion,
+		&alias.WorkDir, &alias.Source, &createdAt, &updatedAt, &alias.Revision); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if alias.CreatedAt, err = parseTime(createdAt); err != nil {
+		return nil, err
+	}
+	if alias.UpdatedAt, err = parseTime(updatedAt); err != nil {
+		return nil, err
+	}
+	return &alias, nil
+}
+
+// Timestamps are stored as RFC 3339 text, which keeps their time zone and
+// stays readable with the sqlite3 shell.
+
+func formatTime(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+func parseTime(s string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q: %w", s, err)
+	}
+	return t, nil
+}
@@ -0,0 +1,214 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/repository"
+	"github.com/msaglietto/mantrid/repository/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRepo(t *testing.T, path string) repository.AliasRepository {
+	t.Helper()
+	repo := sqlite.NewAliasRepository(path)
+	t.Cleanup(func() { repository.Close(repo) })
+	return repo
+}
+
+func TestAliasRepository(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "aliases.db")
+	repo := newRepo(t, path)
+
+	created := time.Date(2024, 5, 1, 10, 30, 0, 123, time.FixedZone("CEST", 2*60*60))
+	gs := &domain.Alias{Name: "gs", Command: "git status", Description: "Status",
+		Completion: "files", WorkDir: "/src", Source: "bash:/home/me/.bashrc", CreatedAt: created, UpdatedAt: created}
+
+	t.Run("create and find", func(t *testing.T) {
+		require.NoError(t, repo.Create(ctx, gs))
+		assert.ErrorIs(t, repo.Create(ctx, gs), domain.ErrAliasExists)
+
+		found, err := repo.FindByName(ctx, "gs")
+		require.NoError(t, err)
+		assert.Equal(t, gs.Command, found.Command)
+		assert.Equal(t, gs.Source, found.Source)
+		assert.True(t, found.CreatedAt.Equal(created))
+		_, offset := found.CreatedAt.Zone()
+		assert.Equal(t, 2*60*60, offset)
+
+		_, err = repo.FindByName(ctx, "missing")
+		assert.ErrorIs(t, err, domain.ErrAliasNotFound)
+	})
+
+	t.Run("update and delete", func(t *testing.T) {
+		updated := *gs
+		updated.Command = "git status -sb"
+		require.NoError(t, repo.Update(ctx, &updated, repository.AnyRevision))
+		assert.ErrorIs(t, repo.Update(ctx, &domain.Alias{Name: "missing", Command: "x"}, repository.AnyRevision), domain.ErrAliasNotFound)
+
+		found, err := newRepo(t, path).FindByName(ctx, "gs")
+		require.NoError(t, err)
+		assert.Equal(t, "git status -sb", found.Command)
+
+		require.NoError(t, repo.Delete(ctx, "gs", repository.AnyRevision))
+		assert.ErrorIs(t, repo.Delete(ctx, "gs", repository.AnyRevision), domain.ErrAliasNotFound)
+	})
+
+	t.Run("replace keeps order and rename keeps position", func(t *testing.T) {
+		require.NoError(t, repo.Replace(ctx, []*domain.Alias{
+			{Name: "c", Command: "echo c", CreatedAt: created, UpdatedAt: created},
+			{Name: "a", Command: "echo a", CreatedAt: created, UpdatedAt: created},
+			{Name: "b", Command: "echo b", CreatedAt: created, UpdatedAt: created},
+		}))
+		require.NoError(t, repo.Rename(ctx, map[string]string{"a": "z"}, false))
+		assert.ErrorIs(t, repo.Rename(ctx, map[string]string{"z": "b"}, false), domain.ErrAliasExists)
+
+		aliases, err := repo.List(ctx)
+		require.NoError(t, err)
+		names := []string{}
+		for _, a := range aliases {
+			names = append(names, a.Name)
+		}
+		assert.Equal(t, []string{"c", "z", "b"}, names)
+	})
+
+	t.Run("writes leave the rows of other aliases alone", func(t *testing.T) {
+		db, err := sql.Open("sqlite", path)
+		require.NoError(t, err)
+		defer db.Close()
+		ids := func() map[string]int64 {
+			rows, err := db.Query("SELECT name, id FROM aliases")
+			require.NoError(t, err)
+			defer rows.Close()
+			result := map[string]int64{}
+			for rows.Next() {
+				var name string
+				var id int64
+				require.NoError(t, rows.Scan(&name, &id))
+				result[name] = id
+			}
+			return result
+		}
+		before := ids()
+
+		require.NoError(t, repo.Replace(ctx, []*domain.Alias{
+			{Name: "c", Command: "echo c", CreatedAt: created, UpdatedAt: created},
+			{Name: "z", Command: "echo z", CreatedAt: created, UpdatedAt: created},
+			{Name: "d", Command: "echo d", CreatedAt: created, UpdatedAt: created},
+		}))
+		after := ids()
+		assert.Equal(t, before["c"], after["c"])
+		assert.Equal(t, before["z"], after["z"])
+		assert.NotContains(t, after, "b")
+
+		// Aliases swapping names keep their rows
+		require.NoError(t, repo.Rename(ctx, map[string]string{"c": "z", "z": "c"}, false))
+		swapped := ids()
+		assert.Equal(t, after["c"], swapped["z"])
+		assert.Equal(t, after["z"], swapped["c"])
+		found, err := repo.FindByName(ctx, "z")
+		require.NoError(t, err)
+		assert.Equal(t, "echo c", found.Command)
+	})
+}
+
+func TestAliasRepository_ConcurrentWriters(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "aliases.db")
+
+	const writers, perWriter = 4, 25
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*perWriter)
+	for w := 0; w < writers; w++ {
+		// Each writer has its own connection, like a separate process
+		repo := newRepo(t, path)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				alias, _ := domain.NewAlias(fmt.Sprintf("w%d-%d", w, i), "echo")
+				errs <- repo.Create(ctx, alias)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	aliases, err := newRepo(t, path).List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, aliases, writers*perWriter)
+}
+
+func TestAliasRepository_Schema(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "aliases.db")
+	_, err := newRepo(t, path).List(ctx)
+	require.NoError(t, err)
+
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var mode string
+	require.NoError(t, db.QueryRow("PRAGMA journal_mode").Scan(&mode))
+	assert.Equal(t, "wal", mode)
+
+	var index string
+	require.NoError(t, db.QueryRow("SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = 'aliases' AND sql LIKE '%(name)'").Scan(&index))
+	assert.Equal(t, "aliases_name", index)
+
+	t.Run("newer schema is refused", func(t *testing.T) {
+		_, err := db.Exec("PRAGMA user_version = 99")
+		require.NoError(t, err)
+
+		_, err = newRepo(t, path).List(ctx)
+		assert.ErrorContains(t, err, "database schema version 99 is newer")
+	})
+}
+
+func TestAliasRepository_SchemaUpgrade(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "aliases.db")
+
+	// A database of the first schema, before revisions were kept
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE aliases (
+		id          INTEGER PRIMARY KEY,
+		name        TEXT NOT NULL,
+		command     TEXT NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		completion  TEXT NOT NULL DEFAULT '',
+		workdir     TEXT NOT NULL DEFAULT '',
+		source      TEXT NOT NULL DEFAULT '',
+		created_at  TEXT NOT NULL,
+		updated_at  TEXT NOT NULL
+	);
+	CREATE UNIQUE INDEX aliases_name ON aliases (name);
+	CREATE INDEX aliases_source ON aliases (source);
+	INSERT INTO aliases (name, command, created_at, updated_at)
+		VALUES ('gs', 'git status', '2024-05-01T10:30:00Z', '2024-05-01T10:30:00Z');
+	PRAGMA user_version = 1;`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	repo := newRepo(t, path)
+	alias, err := repo.FindByName(ctx, "gs")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), alias.Revision)
+
+	alias.Command = "git status -sb"
+	require.NoError(t, repo.Update(ctx, alias, 1))
+	assert.ErrorIs(t, repo.Update(ctx, alias, 1), domain.ErrConflict)
+}
@@ -0,0 +1,76 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// migrations upgrade the schema one version at a time; the database records
+// how many it has applied as its user_version. Released migrations must
+// never change: append new ones instead.
+var migrations = []string{
+	// 1: aliases, ordered by id in the order they were added
+	`CREATE TABLE aliases (
+		id          INTEGER PRIMARY KEY,
+		name        TEXT NOT NULL,
+		command     TEXT NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		completion  TEXT NOT NULL DEFAULT '',
+		workdir     TEXT NOT NULL DEFAULT '',
+		source      TEXT NOT NULL DEFAULT '',
+		created_at  TEXT NOT NULL,
+		updated_at  TEXT NOT NULL
+	);
+	CREATE UNIQUE INDEX aliases_name ON aliases (name);
+	CREATE INDEX aliases_source ON aliases (source);`,
+	// 2: revisions, identifying the stored contents of each alias; rows
+	// written before are at revision 1
+	`ALTER TABLE aliases ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;`,
+}
+
+// migrate brings the schema of db up to the version of this build.
+// Databases written by a newer mantrid are refused rather than misread.
+func migrate(ctx context.Context, db *sql.DB) error {
+	// Skip the write lock when there is nothing to do, as is usually the case
+	version, err := schemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+	if version == len(migrations) {
+		return nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Another process may have migrated the database in the meantime
+	if version, err = schemaVersion(ctx, tx); err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than the supported version %d; upgrade mantrid",
+			version, len(migrations))
+	}
+
+	for i := version; i < len(migrations); i++ {
+		if _, err := tx.ExecContext(ctx, migrations[i]); err != nil {
+			return fmt.Errorf("failed to migrate database to version %d: %w", i+1, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", len(migrations))); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+	return tx.Commit()
+}
+
+func schemaVersion(ctx context.Context, q querier) (int, error) {
+	var version int
+	if err := q.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
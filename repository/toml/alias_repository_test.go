@@ -0,0 +1,106 @@
+package toml_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/repository"
+	"github.com/msaglietto/mantrid/repository/toml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAliasRepository(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("round trip", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "aliases.toml")
+		repo := toml.NewAliasRepository(filePath)
+
+		created := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+		commands := map[string]string{
+			"plain":     "git status",
+			"quotes":    `echo "it's" \n`,
+			"lines":     "go vet ./...\ngo test ./...",
+			"delimiter": "echo '''\necho done'",
+			"control":   "printf '\x1b[1m'\r\nbold",
+		}
+		for name, command := range commands {
+			require.NoError(t, repo.Create(ctx, &domain.Alias{
+				Name: name, Command: command, Description: "Runs " + name,
+				CreatedAt: created, UpdatedAt: created,
+			}))
+		}
+		assert.ErrorIs(t, repo.Create(ctx, &domain.Alias{Name: "plain", Command: "x"}), domain.ErrAliasExists)
+
+		aliases, err := toml.NewAliasRepository(filePath).List(ctx)
+		require.NoError(t, err)
+		require.Len(t, aliases, len(commands))
+		for _, a := range aliases {
+			assert.Equal(t, commands[a.Name], a.Command, a.Name)
+			assert.True(t, a.CreatedAt.Equal(created))
+		}
+
+		data, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "command = 'git status'\n")
+		assert.Contains(t, string(data), "command = '''\ngo vet ./...\ngo test ./...'''\n")
+
+		require.NoError(t, repo.Rename(ctx, map[string]string{"plain": "gs"}, false))
+		require.NoError(t, repo.Delete(ctx, "gs", repository.AnyRevision))
+		assert.ErrorIs(t, repo.Delete(ctx, "gs", repository.AnyRevision), domain.ErrAliasNotFound)
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "aliases.toml")
+		require.NoError(t, os.WriteFile(filePath, []byte("[[aliases]\n"), 0644))
+
+		_, err := toml.NewAliasRepository(filePath).List(ctx)
+		assert.ErrorContains(t, err, "invalid alias file")
+	})
+}
+
+func TestAliasRepository_Formatting(t *testing.T) {
+	ctx := context.Background()
+	filePath := filepath.Join(t.TempDir(), "aliases.toml")
+	require.NoError(t, os.WriteFile(filePath, []byte(`# Team aliases
+# Reviewed in PRs
+
+# Runs the deploy script
+[[aliases]]
+name = "deploy"
+# keep in sync with CI
+command = """
+./deploy.sh
+# not a comment"""
+
+[[aliases]]
+name = "build"
+command = "go build ./..."
+
+# end of file
+`), 0644))
+	repo := toml.NewAliasRepository(filePath)
+
+	alias, _ := domain.NewAlias("check", "go vet ./...")
+	require.NoError(t, repo.Create(ctx, alias))
+
+	data, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	content := string(data)
+
+	assert.True(t, strings.HasPrefix(content, "# Team aliases\n# Reviewed in PRs\n\n[[aliases]]\nname = 'build'\n"), content)
+	assert.Contains(t, content, "# Runs the deploy script\n[[aliases]]\nname = 'deploy'\n# keep in sync with CI\ncommand = '''\n./deploy.sh\n# not a comment'''\n")
+	assert.True(t, strings.HasSuffix(content, "\n\n# end of file\n"), content)
+	assert.Less(t, strings.Index(content, "name = 'check'"), strings.Index(content, "name = 'deploy'"))
+	assert.NotContains(t, content, "# not a comment\n[[aliases]]")
+
+	found, err := repo.FindByName(ctx, "deploy")
+	require.NoError(t, err)
+	assert.Equal(t, "./deploy.sh\n# not a comment", found.Command)
+}
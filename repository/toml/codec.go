@@ -0,0 +1,69 @@
+// Package toml stores aliases in a TOML file meant to be read and edited by
+// people, such as a global store kept in a dotfiles repository.
+package toml
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/repository"
+	"github.com/msaglietto/mantrid/repository/filestore"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// document is the layout of the alias file, an array of tables.
+type document struct {
+	Aliases []entry `toml:"aliases"`
+}
+
+// entry is an alias in the file. Timestamps are optional, so that aliases
+// can be written by hand.
+type entry struct {
+	Name        string    `toml:"name"`
+	Command     string    `toml:"command"`
+	Description string    `toml:"description"`
+	Completion  string    `toml:"completion"`
+	WorkDir     string    `toml:"workdir"`
+	Source      string    `toml:"source"`
+	CreatedAt   time.Time `toml:"created_at"`
+	UpdatedAt   time.Time `toml:"updated_at"`
+	Revision    int64     `toml:"revision"`
+}
+
+// codec reads and writes alias files, keeping the comments people wrote in
+// them.
+type codec struct{}
+
+func (codec) Decode(data []byte) ([]*domain.Alias, error) {
+	var doc document
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %w", filestore.ErrInvalid, err)
+	}
+
+	aliases := make([]*domain.Alias, len(doc.Aliases))
+	for i, e := range doc.Aliases {
+		aliases[i] = &domain.Alias{
+			Name:        e.Name,
+			Command:     e.Command,
+			Description: e.Description,
+			Completion:  e.Completion,
+			WorkDir:     e.WorkDir,
+			Source:      e.Source,
+			CreatedAt:   e.CreatedAt,
+			UpdatedAt:   e.UpdatedAt,
+			Revision:    e.Revision,
+		}
+	}
+	return aliases, nil
+}
+
+func (codec) Encode(aliases []*domain.Alias, previous []byte) ([]byte, error) {
+	return encode(aliases, previous), nil
+}
+
+// NewAliasRepository returns the store of the alias file at filePath,
+// which is written readable by others like any dotfile.
+func NewAliasRepository(filePath string, opts ...filestore.Option) repository.AliasRepository {
+	return filestore.New(filePath, codec{}, append([]filestore.Option{filestore.WithMode(0644)}, opts...)...)
+}
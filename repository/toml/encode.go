@@ -0,0 +1,238 @@
+package toml
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// tableHeader starts the table of each alias.
+const tableHeader = "[[aliases]]"
+
+// encode renders aliases as an alias file, sorted by name so that changes
+// make small diffs. Multi-line values are written as multi-line strings.
+// The full-line comments of previous, the file being replaced, are carried
+// over to the aliases and keys they preceded; comments after a value on the
+// same line are not.
+func encode(aliases []*domain.Alias, previous []byte) []byte {
+	sorted := make([]*domain.Alias, len(aliases))
+	copy(sorted, aliases)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	c := parseComments(previous)
+	var buf bytes.Buffer
+	writeLines(&buf, c.head)
+	if len(c.head) > 0 {
+		buf.WriteString("\n")
+	}
+
+	for i, a := range sorted {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		table := c.tables[a.Name]
+		writeLines(&buf, table.head)
+		buf.WriteString(tableHeader + "\n")
+
+		fields := []struct{ key, value string }{
+			{"name", a.Name},
+			{"command", a.Command},
+			{"description", a.Description},
+			{"completion", a.Completion},
+			{"workdir", a.WorkDir},
+			{"source", a.Source},
+		}
+		for _, f := range fields {
+			if f.value != "" || f.key == "name" || f.key == "command" {
+				writeLines(&buf, table.keys[f.key])
+				fmt.Fprintf(&buf, "%s = %s\n", f.key, formatString(f.value))
+			}
+		}
+		for _, f := range []struct {
+			key   string
+			value time.Time
+		}{{"created_at", a.CreatedAt}, {"updated_at", a.UpdatedAt}} {
+			if !f.value.IsZero() {
+				writeLines(&buf, table.keys[f.key])
+				fmt.Fprintf(&buf, "%s = %s\n", f.key, f.value.Format(time.RFC3339Nano))
+			}
+		}
+		if a.Revision > 0 {
+			writeLines(&buf, table.keys["revision"])
+			fmt.Fprintf(&buf, "revision = %d\n", a.Revision)
+		}
+	}
+
+	if len(c.foot) > 0 {
+		buf.WriteString("\n")
+		writeLines(&buf, c.foot)
+	}
+	return buf.Bytes()
+}
+
+func writeLines(buf *bytes.Buffer, lines []string) {
+	for _, line := range lines {
+		buf.WriteString(line + "\n")
+	}
+}
+
+// comments are the full-line comments of an alias file.
+type comments struct {
+	// head opens the file, separated from the first alias by a blank line.
+	head []string
+	// tables are the comments of each alias, by name.
+	tables map[string]tableComments
+	// foot follows the last alias.
+	foot []string
+}
+
+type tableComments struct {
+	// head precedes the table header.
+	head []string
+	// keys precede each key.
+	keys map[string][]string
+}
+
+// parseComments collects the full-line comments of data, an alias file.
+// Lines of multi-line strings are skipped, even when they look like
+// comments.
+func parseComments(data []byte) comments {
+	c := comments{tables: map[string]tableComments{}}
+
+	var pending []string
+	var table *tableComments
+	seenTable := false
+	inString := ""
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case inString != "":
+			if strings.Contains(line, inString) {
+				inString = ""
+			}
+		case trimmed == "":
+			if !seenTable {
+				pending = append(pending, "")
+			}
+		case strings.HasPrefix(trimmed, "#"):
+			pending = append(pending, trimmed)
+		case trimmed == tableHeader:
+			if !seenTable {
+				// The file comment ends at the last blank line before the
+				// first alias
+				split := 0
+				for i, l := range pending {
+					if l == "" {
+						split = i + 1
+					}
+				}
+				c.head = trimBlank(pending[:split])
+				pending = pending[split:]
+				seenTable = true
+			}
+			table = &tableComments{head: pending, keys: map[string][]string{}}
+			pending = nil
+		default:
+			key, value, ok := strings.Cut(trimmed, "=")
+			if !ok {
+				pending = nil
+				continue
+			}
+			key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+			if table != nil {
+				if len(pending) > 0 {
+					table.keys[key] = pending
+				}
+				if key == "name" {
+					var named struct {
+						Name string `toml:"name"`
+					}
+					if toml.Unmarshal([]byte(trimmed), &named) == nil {
+						c.tables[named.Name] = *table
+					}
+				}
+			}
+			pending = nil
+			for _, delim := range []string{`'''`, `"""`} {
+				if strings.HasPrefix(value, delim) && !strings.Contains(value[len(delim):], delim) {
+					inString = delim
+				}
+			}
+		}
+	}
+
+	if seenTable {
+		c.foot = trimBlank(pending)
+	} else {
+		c.head = trimBlank(pending)
+	}
+	return c
+}
+
+// trimBlank removes the blank lines around lines.
+func trimBlank(lines []string) []string {
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// formatString quotes s as the most readable TOML string that represents
+// it: a literal string when s needs no escapes, a multi-line string when it
+// spans lines.
+func formatString(s string) string {
+	multiLine := strings.Contains(s, "\n")
+	literal := !strings.ContainsFunc(s, func(r rune) bool {
+		return (r < 0x20 && r != '\t' && r != '\n') || r == 0x7f
+	})
+
+	switch {
+	case !multiLine && literal && !strings.Contains(s, "'"):
+		return "'" + s + "'"
+	case !multiLine:
+		return `"` + escape(s, false) + `"`
+	case literal && !strings.Contains(s, "'''") && !strings.HasSuffix(s, "'"):
+		return "'''\n" + s + "'''"
+	default:
+		return `"""` + "\n" + escape(s, true) + `"""`
+	}
+}
+
+// escape escapes s for a basic string, keeping line breaks in multi-line
+// strings.
+func escape(s string, multiLine bool) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r == '\n' && multiLine:
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteRune(r)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
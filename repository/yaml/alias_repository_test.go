@@ -0,0 +1,111 @@
+package yaml_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/repository"
+	"github.com/msaglietto/mantrid/repository/yaml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAliasRepository(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("reads hand-written files", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), ".mantrid.yaml")
+		require.NoError(t, os.WriteFile(filePath, []byte(`aliases:
+  - name: build
+    command: go build ./...
+    description: Build everything
+  - name: test
+    command: go test $@
+`), 0644))
+		repo := yaml.NewAliasRepository(filePath)
+
+		aliases, err := repo.List(ctx)
+		require.NoError(t, err)
+		require.Len(t, aliases, 2)
+		assert.Equal(t, "Build everything", aliases[0].Description)
+		assert.True(t, aliases[0].CreatedAt.IsZero())
+
+		alias, err := repo.FindByName(ctx, "test")
+		require.NoError(t, err)
+		assert.Equal(t, "go test $@", alias.Command)
+	})
+
+	t.Run("missing file is empty", func(t *testing.T) {
+		repo := yaml.NewAliasRepository(filepath.Join(t.TempDir(), ".mantrid.yaml"))
+
+		aliases, err := repo.List(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, aliases)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), ".mantrid.yaml")
+		repo := yaml.NewAliasRepository(filePath)
+
+		alias, _ := domain.NewAlias("deploy", "make deploy", domain.WithDescription("Ship it"))
+		require.NoError(t, repo.Create(ctx, alias))
+		assert.ErrorIs(t, repo.Create(ctx, alias), domain.ErrAliasExists)
+
+		require.NoError(t, alias.UpdateCommand("make deploy ENV=$1"))
+		require.NoError(t, repo.Update(ctx, alias, repository.AnyRevision))
+		require.NoError(t, repo.Rename(ctx, map[string]string{"deploy": "ship"}, false))
+
+		found, err := yaml.NewAliasRepository(filePath).FindByName(ctx, "ship")
+		require.NoError(t, err)
+		assert.Equal(t, "make deploy ENV=$1", found.Command)
+		assert.Equal(t, "Ship it", found.Description)
+		assert.True(t, found.CreatedAt.Equal(alias.CreatedAt))
+
+		require.NoError(t, repo.Delete(ctx, "ship", repository.AnyRevision))
+		assert.ErrorIs(t, repo.Delete(ctx, "ship", repository.AnyRevision), domain.ErrAliasNotFound)
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), ".mantrid.yaml")
+		require.NoError(t, os.WriteFile(filePath, []byte("aliases: {"), 0644))
+
+		_, err := yaml.NewAliasRepository(filePath).List(ctx)
+		assert.ErrorContains(t, err, "invalid alias file")
+	})
+}
+
+func TestAliasRepository_Formatting(t *testing.T) {
+	ctx := context.Background()
+	filePath := filepath.Join(t.TempDir(), "aliases.yaml")
+	require.NoError(t, os.WriteFile(filePath, []byte(`# Team aliases
+aliases:
+  # Runs the deploy script
+  - name: deploy
+    command: ./deploy.sh # keep in sync with CI
+  - name: build
+    command: go build ./...
+`), 0644))
+	repo := yaml.NewAliasRepository(filePath)
+
+	alias, _ := domain.NewAlias("check", "go vet ./...\ngo test ./...")
+	require.NoError(t, repo.Create(ctx, alias))
+
+	data, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	content := string(data)
+
+	assert.Contains(t, content, "# Team aliases\n")
+	assert.Contains(t, content, "# Runs the deploy script\n")
+	assert.Contains(t, content, "command: ./deploy.sh # keep in sync with CI\n")
+	assert.Contains(t, content, "command: |-\n      go vet ./...\n      go test ./...\n")
+	assert.Less(t, strings.Index(content, "name: build"), strings.Index(content, "name: check"))
+	assert.Less(t, strings.Index(content, "name: check"), strings.Index(content, "name: deploy"))
+
+	found, err := repo.FindByName(ctx, "check")
+	require.NoError(t, err)
+	assert.Equal(t, alias.Command, found.Command)
+}
@@ -0,0 +1,70 @@
+// Package yaml stores aliases in a YAML file meant to be read and edited by
+// people, such as the .mantrid.yaml a project ships its aliases in or a
+// global store kept in a dotfiles repository.
+package yaml
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/repository"
+	"github.com/msaglietto/mantrid/repository/filestore"
+	"gopkg.in/yaml.v3"
+)
+
+// document is the layout of the alias file.
+type document struct {
+	Aliases []entry `yaml:"aliases"`
+}
+
+// entry is an alias in the file. Timestamps are optional, so that aliases
+// can be written by hand.
+type entry struct {
+	Name        string    `yaml:"name"`
+	Command     string    `yaml:"command"`
+	Description string    `yaml:"description,omitempty"`
+	Completion  string    `yaml:"completion,omitempty"`
+	WorkDir     string    `yaml:"workdir,omitempty"`
+	Source      string    `yaml:"source,omitempty"`
+	CreatedAt   time.Time `yaml:"created_at,omitempty"`
+	UpdatedAt   time.Time `yaml:"updated_at,omitempty"`
+	Revision    int64     `yaml:"revision,omitempty"`
+}
+
+// codec reads and writes alias files, keeping the comments people wrote in
+// them.
+type codec struct{}
+
+func (codec) Decode(data []byte) ([]*domain.Alias, error) {
+	var doc document
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %w", filestore.ErrInvalid, err)
+	}
+
+	aliases := make([]*domain.Alias, len(doc.Aliases))
+	for i, e := range doc.Aliases {
+		aliases[i] = &domain.Alias{
+			Name:        e.Name,
+			Command:     e.Command,
+			Description: e.Description,
+			Completion:  e.Completion,
+			WorkDir:     e.WorkDir,
+			Source:      e.Source,
+			CreatedAt:   e.CreatedAt,
+			UpdatedAt:   e.UpdatedAt,
+			Revision:    e.Revision,
+		}
+	}
+	return aliases, nil
+}
+
+func (codec) Encode(aliases []*domain.Alias, previous []byte) ([]byte, error) {
+	return encode(aliases, previous)
+}
+
+// NewAliasRepository returns the store of the alias file at filePath,
+// which is written readable by others like any file of a project.
+func NewAliasRepository(filePath string, opts ...filestore.Option) repository.AliasRepository {
+	return filestore.New(filePath, codec{}, append([]filestore.Option{filestore.WithMode(0644)}, opts...)...)
+}
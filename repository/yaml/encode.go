@@ -0,0 +1,116 @@
+package yaml
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	"github.com/msaglietto/mantrid/domain"
+	"gopkg.in/yaml.v3"
+)
+
+// encode renders aliases as an alias file, sorted by name so that changes
+// make small diffs. Multi-line values are written as block scalars. The
+// comments of previous, the file being replaced, are carried over to the
+// aliases and fields they were attached to.
+func encode(aliases []*domain.Alias, previous []byte) ([]byte, error) {
+	sorted := make([]*domain.Alias, len(aliases))
+	copy(sorted, aliases)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	items := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for _, a := range sorted {
+		var item yaml.Node
+		if err := item.Encode(entry{
+			Name:        a.Name,
+			Command:     a.Command,
+			Description: a.Description,
+			Completion:  a.Completion,
+			WorkDir:     a.WorkDir,
+			Source:      a.Source,
+			CreatedAt:   a.CreatedAt,
+			UpdatedAt:   a.UpdatedAt,
+			Revision:    a.Revision,
+		}); err != nil {
+			return nil, err
+		}
+		for i := 1; i < len(item.Content); i += 2 {
+			if value := item.Content[i]; strings.Contains(value.Value, "\n") {
+				value.Style = yaml.LiteralStyle
+			}
+		}
+		items.Content = append(items.Content, &item)
+	}
+
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "aliases"}
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{key, items}}
+	doc := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}
+
+	var old yaml.Node
+	if yaml.Unmarshal(previous, &old) == nil && len(old.Content) == 1 {
+		copyComments(doc, &old)
+		oldRoot := old.Content[0]
+		copyComments(root, oldRoot)
+		if oldKey, oldItems := lookup(oldRoot, "aliases"); oldKey != nil {
+			copyComments(key, oldKey)
+			copyComments(items, oldItems)
+			copyItemComments(items, oldItems)
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// copyItemComments gives every alias of items the comments of the alias of
+// the same name in old, and of its fields.
+func copyItemComments(items, old *yaml.Node) {
+	byName := map[string]*yaml.Node{}
+	for _, item := range old.Content {
+		if _, name := lookup(item, "name"); name != nil {
+			byName[name.Value] = item
+		}
+	}
+
+	for _, item := range items.Content {
+		_, name := lookup(item, "name")
+		oldItem, ok := byName[name.Value]
+		if !ok {
+			continue
+		}
+		copyComments(item, oldItem)
+		for i := 0; i+1 < len(item.Content); i += 2 {
+			if oldKey, oldValue := lookup(oldItem, item.Content[i].Value); oldKey != nil {
+				copyComments(item.Content[i], oldKey)
+				copyComments(item.Content[i+1], oldValue)
+			}
+		}
+	}
+}
+
+// lookup returns the key and value nodes of key in the mapping node m.
+func lookup(m *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if m.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i], m.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+func copyComments(dst, src *yaml.Node) {
+	dst.HeadComment = src.HeadComment
+	dst.LineComment = src.LineComment
+	dst.FootComment = src.FootComment
+}
@@ -2,34 +2,77 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/msaglietto/mantrid/domain"
 	"github.com/msaglietto/mantrid/repository"
 )
 
 type AliasService interface {
-	CreateAlias(ctx context.Context, name, command string) error
+	CreateAlias(ctx context.Context, name, command string, opts ...domain.AliasOption) error
 	GetAlias(ctx context.Context, name string) (*domain.Alias, error)
 	ListAliases(ctx context.Context) ([]*domain.Alias, error)
 	UpdateAlias(ctx context.Context, name, newCommand string) error
-	DeleteAlias(ctx context.Context, name string) error
+	DeleteAlias(ctx context.Context, name string, rev int64) error
+	ResolveAlias(ctx context.Context, name string, allowPrefix bool) (*domain.Alias, error)
+	SuggestAliases(ctx context.Context, name string) ([]string, error)
+	ListNamespace(ctx context.Context, namespace string) ([]*domain.Alias, error)
+	DeleteNamespace(ctx context.Context, namespace string, confirmed []*domain.Alias) ([]string, error)
+	MoveNamespace(ctx context.Context, from, to string, overwrite bool) (map[string]string, error)
+	RenameAlias(ctx context.Context, oldName, newName string, overwrite bool) error
+	CopyAlias(ctx context.Context, srcName, dstName string, overwrite bool) error
+	SearchAliases(ctx context.Context, query string, opts SearchOptions) ([]SearchResult, error)
+	BatchAliases(ctx context.Context, ops []repository.Op) error
+	ImportAliases(ctx context.Context, aliases []*domain.Alias, opts ImportOptions) (*ImportResult, error)
+	ValidateAlias(alias *domain.Alias) error
+	ValidateAliases(aliases []*domain.Alias) error
 }
 
+// defaultNamespaceSeparator separates the segments of namespaced alias names
+// unless configured otherwise.
+const defaultNamespaceSeparator = "/"
+
 type aliasService struct {
-	repo repository.AliasRepository
+	repo      repository.AliasRepository
+	separator string
+}
+
+// Option configures optional behaviour of the alias service.
+type Option func(*aliasService)
+
+// WithNamespaceSeparator sets the separator used in namespaced alias names.
+// It must be one of domain.NamespaceSeparators.
+func WithNamespaceSeparator(separator string) Option {
+	return func(s *aliasService) {
+		if separator != "" {
+			s.separator = separator
+		}
+	}
 }
 
-func NewAliasService(repo repository.AliasRepository) AliasService {
-	return &aliasService{
-		repo: repo,
+func NewAliasService(repo repository.AliasRepository, opts ...Option) AliasService {
+	s := &aliasService{
+		repo:      repo,
+		separator: defaultNamespaceSeparator,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
-func (s *aliasService) CreateAlias(ctx context.Context, name, command string) error {
-	alias, err := domain.NewAlias(name, command)
+func (s *aliasService) CreateAlias(ctx context.Context, name, command string, opts ...domain.AliasOption) error {
+	alias, err := domain.NewAlias(name, command, opts...)
 	if err != nil {
 		return err
 	}
+	if err := s.checkSeparator(name); err != nil {
+		return err
+	}
 
 	return s.repo.Create(ctx, alias)
 }
@@ -62,16 +105,270 @@ func (s *aliasService) UpdateAlias(ctx context.Context, name, newCommand string)
 		return err
 	}
 
-	// Save the updated alias
-	return s.repo.Update(ctx, alias)
+	// Save the updated alias, unless it was changed since it was read
+	return s.repo.Update(ctx, alias, alias.Revision)
 }
 
-func (s *aliasService) DeleteAlias(ctx context.Context, name string) error {
+// DeleteAlias removes the alias called name unless it is no longer at rev,
+// the revision the user agreed to remove, failing with domain.ErrConflict
+// then. repository.AnyRevision removes it whatever its revision.
+func (s *aliasService) DeleteAlias(ctx context.Context, name string, rev int64) error {
 	// Validate input
 	if name == "" {
 		return domain.ErrEmptyAliasName
 	}
 
 	// Delete the alias
-	return s.repo.Delete(ctx, name)
+	return s.repo.Delete(ctx, name, rev)
+}
+
+// ResolveAlias looks up an alias by its exact name. When allowPrefix is set and
+// no exact match exists, a name that is a unique prefix of a stored alias
+// resolves to that alias; a prefix shared by several aliases is reported as
+// domain.ErrAmbiguousAlias.
+func (s *aliasService) ResolveAlias(ctx context.Context, name string, allowPrefix bool) (*domain.Alias, error) {
+	if name == "" {
+		return nil, domain.ErrEmptyAliasName
+	}
+
+	alias, err := s.repo.FindByName(ctx, name)
+	if err == nil || !allowPrefix || !errors.Is(err, domain.ErrAliasNotFound) {
+		return alias, err
+	}
+
+	aliases, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []*domain.Alias
+	for _, a := range aliases {
+		if strings.HasPrefix(a.Name, name) {
+			matches = append(matches, a)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, domain.ErrAliasNotFound
+	case 1:
+		cp := *matches[0]
+		return &cp, nil
+	default:
+		names := make([]string, len(matches))
+		for i, m := range matches {
+			names[i] = m.Name
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("%w: %q matches %s", domain.ErrAmbiguousAlias, name, strings.Join(names, ", "))
+	}
+}
+
+// SuggestAliases returns the names of stored aliases that look like a
+// misspelling or abbreviation of name, best match first.
+func (s *aliasService) SuggestAliases(ctx context.Context, name string) ([]string, error) {
+	aliases, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(aliases))
+	for i, a := range aliases {
+		names[i] = a.Name
+	}
+	return suggestNames(name, names), nil
+}
+
+// RenameAlias gives an alias a new name, keeping its command, description and
+// creation time. An existing alias at newName is replaced only when overwrite
+// is set.
+func (s *aliasService) RenameAlias(ctx context.Context, oldName, newName string, overwrite bool) error {
+	if oldName == "" {
+		return domain.ErrEmptyAliasName
+	}
+	if err := s.validateName(newName); err != nil {
+		return err
+	}
+
+	return s.repo.Rename(ctx, map[string]string{oldName: newName}, overwrite)
+}
+
+// CopyAlias creates dstName as a copy of srcName with the same command and
+// description. The copy is a new alias, created now even when it replaces
+// another, that belongs to no import source and is created in the global
+// store, whatever the layer of srcName. An existing alias at dstName
+// is replaced, in its own layer and in a single batch, only when overwrite
+// is set; it fails with domain.ErrConflict if it changes meanwhile.
+func (s *aliasService) CopyAlias(ctx context.Context, srcName, dstName string, overwrite bool) error {
+	if srcName == "" {
+		return domain.ErrEmptyAliasName
+	}
+	if err := s.validateName(dstName); err != nil {
+		return err
+	}
+
+	src, err := s.repo.FindByName(ctx, srcName)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	dst := *src
+	dst.Name = dstName
+	dst.Source = ""
+	dst.Layer = nil
+	dst.Revision = 0
+	dst.CreatedAt = now
+	dst.UpdatedAt = now
+
+	existing, err := s.repo.FindByName(ctx, dstName)
+	switch {
+	case errors.Is(err, domain.ErrAliasNotFound):
+		return s.repo.Create(ctx, &dst)
+	case err != nil:
+		return err
+	case !overwrite:
+		return domain.ErrAliasExists
+	}
+
+	dst.Layer = existing.Layer
+	return s.repo.Batch(ctx, []repository.Op{
+		repository.DeleteOp(dstName).At(existing.Revision),
+		repository.CreateOp(&dst),
+	})
+}
+
+// BatchAliases validates the aliases created and updated by ops and applies
+// ops atomically, see repository.AliasRepository.Batch. Invalid operations
+// are reported as ValidationErrors, keyed by their index in ops, and
+// nothing is written.
+func (s *aliasService) BatchAliases(ctx context.Context, ops []repository.Op) error {
+	if len(ops) == 0 {
+		return nil
+	}
+	if err := s.validateOps(ops); err != nil {
+		return err
+	}
+
+	return s.repo.Batch(ctx, ops)
+}
+
+// ListNamespace returns the aliases below namespace, for example everything
+// named "k8s/..." for namespace "k8s/". An empty namespace lists every alias.
+func (s *aliasService) ListNamespace(ctx context.Context, namespace string) ([]*domain.Alias, error) {
+	aliases, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if namespace == "" {
+		return aliases, nil
+	}
+
+	prefix := s.namespacePrefix(namespace)
+	var result []*domain.Alias
+	for _, a := range aliases {
+		if strings.HasPrefix(a.Name, prefix) {
+			result = append(result, a)
+		}
+	}
+	return result, nil
+}
+
+// DeleteNamespace removes the aliases below namespace in a single batch and
+// returns the names of the removed aliases. confirmed are the aliases the
+// user agreed to remove, as listed by ListNamespace; the batch fails with
+// domain.ErrConflict if any of them changed since, and aliases added
+// meanwhile are left alone. A nil confirmed removes the aliases there are.
+func (s *aliasService) DeleteNamespace(ctx context.Context, namespace string, confirmed []*domain.Alias) ([]string, error) {
+	if namespace == "" {
+		return nil, domain.ErrEmptyAliasName
+	}
+
+	aliases := confirmed
+	if aliases == nil {
+		var err error
+		if aliases, err = s.ListNamespace(ctx, namespace); err != nil {
+			return nil, err
+		}
+	}
+	if len(aliases) == 0 {
+		return nil, fmt.Errorf("%w: no aliases in namespace %q", domain.ErrAliasNotFound, s.namespacePrefix(namespace))
+	}
+
+	removed := make([]string, len(aliases))
+	ops := make([]repository.Op, len(aliases))
+	for i, a := range aliases {
+		removed[i] = a.Name
+		ops[i] = repository.DeleteOp(a.Name).At(a.Revision)
+	}
+	if err := s.repo.Batch(ctx, ops); err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+// MoveNamespace renames every alias below from to the same name below to,
+// as a single repository operation. It returns the applied renames.
+func (s *aliasService) MoveNamespace(ctx context.Context, from, to string, overwrite bool) (map[string]string, error) {
+	if from == "" || to == "" {
+		return nil, domain.ErrEmptyAliasName
+	}
+
+	aliases, err := s.ListNamespace(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	if len(aliases) == 0 {
+		return nil, fmt.Errorf("%w: no aliases in namespace %q", domain.ErrAliasNotFound, s.namespacePrefix(from))
+	}
+
+	fromPrefix, toPrefix := s.namespacePrefix(from), s.namespacePrefix(to)
+	renames := make(map[string]string, len(aliases))
+	for _, a := range aliases {
+		newName := toPrefix + strings.TrimPrefix(a.Name, fromPrefix)
+		if err := s.validateName(newName); err != nil {
+			return nil, err
+		}
+		renames[a.Name] = newName
+	}
+
+	if err := s.repo.Rename(ctx, renames, overwrite); err != nil {
+		return nil, err
+	}
+	return renames, nil
+}
+
+// namespacePrefix normalizes namespace to end with the separator, so that
+// "k8s" and "k8s/" both select "k8s/..." but not "k8s-old".
+func (s *aliasService) namespacePrefix(namespace string) string {
+	return strings.TrimSuffix(namespace, s.separator) + s.separator
+}
+
+// ValidateAlias checks alias the way CreateAlias would, including that it
+// uses the configured namespace separator.
+func (s *aliasService) ValidateAlias(alias *domain.Alias) error {
+	if err := alias.Validate(); err != nil {
+		return err
+	}
+	return s.checkSeparator(alias.Name)
+}
+
+// validateName checks that name is a valid alias name for this service.
+func (s *aliasService) validateName(name string) error {
+	if err := domain.ValidateName(name); err != nil {
+		return err
+	}
+	return s.checkSeparator(name)
+}
+
+// checkSeparator rejects names namespaced with a separator other than the
+// configured one.
+func (s *aliasService) checkSeparator(name string) error {
+	for _, sep := range domain.NamespaceSeparators {
+		if string(sep) != s.separator && strings.ContainsRune(name, sep) {
+			return fmt.Errorf("%w: %q uses namespace separator %q, configured separator is %q",
+				domain.ErrInvalidAliasName, name, string(sep), s.separator)
+		}
+	}
+	return nil
 }
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/repository"
 	"github.com/msaglietto/mantrid/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -44,13 +45,28 @@ func (m *MockAliasRepository) List(ctx context.Context) ([]*domain.Alias, error)
 	return args.Get(0).([]*domain.Alias), args.Error(1)
 }
 
-func (m *MockAliasRepository) Update(ctx context.Context, alias *domain.Alias) error {
-	args := m.Called(ctx, alias)
+func (m *MockAliasRepository) Update(ctx context.Context, alias *domain.Alias, expected int64) error {
+	args := m.Called(ctx, alias, expected)
 	return args.Error(0)
 }
 
-func (m *MockAliasRepository) Delete(ctx context.Context, name string) error {
-	args := m.Called(ctx, name)
+func (m *MockAliasRepository) Delete(ctx context.Context, name string, expected int64) error {
+	args := m.Called(ctx, name, expected)
+	return args.Error(0)
+}
+
+func (m *MockAliasRepository) Rename(ctx context.Context, renames map[string]string, overwrite bool) error {
+	args := m.Called(ctx, renames, overwrite)
+	return args.Error(0)
+}
+
+func (m *MockAliasRepository) Replace(ctx context.Context, aliases []*domain.Alias) error {
+	args := m.Called(ctx, aliases)
+	return args.Error(0)
+}
+
+func (m *MockAliasRepository) Batch(ctx context.Context, ops []repository.Op) error {
+	args := m.Called(ctx, ops)
 	return args.Error(0)
 }
 
@@ -136,8 +152,10 @@ func TestUpdateAlias(t *testing.T) {
 		cleanupMock(t, mockRepo)
 
 		existingAlias, _ := domain.NewAlias("test", "echo original")
+		existingAlias.Revision = 3
 		mockRepo.On("FindByName", ctx, "test").Return(existingAlias, nil)
-		mockRepo.On("Update", ctx, mock.AnythingOfType("*domain.Alias")).Return(nil)
+		// Only the revision that was read is updated
+		mockRepo.On("Update", ctx, mock.AnythingOfType("*domain.Alias"), int64(3)).Return(nil)
 
 		err := service.UpdateAlias(ctx, "test", "echo updated")
 		assert.NoError(t, err)
@@ -181,7 +199,7 @@ func TestUpdateAlias(t *testing.T) {
 		existingAlias, _ := domain.NewAlias("test", "echo original")
 		expectedErr := errors.New("repository error")
 		mockRepo.On("FindByName", ctx, "test").Return(existingAlias, nil)
-		mockRepo.On("Update", ctx, mock.AnythingOfType("*domain.Alias")).Return(expectedErr)
+		mockRepo.On("Update", ctx, mock.AnythingOfType("*domain.Alias"), mock.Anything).Return(expectedErr)
 
 		err := service.UpdateAlias(ctx, "test", "echo updated")
 		assert.Error(t, err)
@@ -198,9 +216,9 @@ func TestDeleteAlias(t *testing.T) {
 	t.Run("delete alias successfully", func(t *testing.T) {
 		cleanupMock(t, mockRepo)
 
-		mockRepo.On("Delete", ctx, "test").Return(nil)
+		mockRepo.On("Delete", ctx, "test", repository.AnyRevision).Return(nil)
 
-		err := service.DeleteAlias(ctx, "test")
+		err := service.DeleteAlias(ctx, "test", repository.AnyRevision)
 		assert.NoError(t, err)
 		mockRepo.AssertExpectations(t)
 	})
@@ -208,9 +226,9 @@ func TestDeleteAlias(t *testing.T) {
 	t.Run("delete non-existent alias", func(t *testing.T) {
 		cleanupMock(t, mockRepo)
 
-		mockRepo.On("Delete", ctx, "nonexistent").Return(domain.ErrAliasNotFound)
+		mockRepo.On("Delete", ctx, "nonexistent", repository.AnyRevision).Return(domain.ErrAliasNotFound)
 
-		err := service.DeleteAlias(ctx, "nonexistent")
+		err := service.DeleteAlias(ctx, "nonexistent", repository.AnyRevision)
 		assert.Error(t, err)
 		assert.ErrorIs(t, err, domain.ErrAliasNotFound)
 		mockRepo.AssertExpectations(t)
@@ -219,21 +237,406 @@ func TestDeleteAlias(t *testing.T) {
 	t.Run("delete with empty name", func(t *testing.T) {
 		cleanupMock(t, mockRepo)
 
-		err := service.DeleteAlias(ctx, "")
+		err := service.DeleteAlias(ctx, "", repository.AnyRevision)
 		assert.Error(t, err)
 		assert.ErrorIs(t, err, domain.ErrEmptyAliasName)
 		mockRepo.AssertNotCalled(t, "Delete")
 	})
 
+	t.Run("delete the revision that was confirmed", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+
+		mockRepo.On("Delete", ctx, "test", int64(3)).Return(domain.ErrConflict)
+
+		err := service.DeleteAlias(ctx, "test", 3)
+		assert.ErrorIs(t, err, domain.ErrConflict)
+		mockRepo.AssertExpectations(t)
+	})
+
 	t.Run("delete fails at repository level", func(t *testing.T) {
 		cleanupMock(t, mockRepo)
 
 		expectedErr := errors.New("repository error")
-		mockRepo.On("Delete", ctx, "test").Return(expectedErr)
+		mockRepo.On("Delete", ctx, "test", repository.AnyRevision).Return(expectedErr)
 
-		err := service.DeleteAlias(ctx, "test")
+		err := service.DeleteAlias(ctx, "test", repository.AnyRevision)
 		assert.Error(t, err)
 		assert.Equal(t, expectedErr, err)
 		mockRepo.AssertExpectations(t)
 	})
 }
+
+func TestResolveAlias(t *testing.T) {
+	mockRepo := new(MockAliasRepository)
+	service := service.NewAliasService(mockRepo)
+	ctx := context.Background()
+
+	stored := []*domain.Alias{
+		{Name: "deploy", Command: "kubectl apply"},
+		{Name: "build-prod", Command: "make prod"},
+		{Name: "build-stg", Command: "make stg"},
+	}
+
+	t.Run("exact match", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+
+		mockRepo.On("FindByName", ctx, "deploy").Return(stored[0], nil)
+
+		alias, err := service.ResolveAlias(ctx, "deploy", true)
+		assert.NoError(t, err)
+		assert.Equal(t, "deploy", alias.Name)
+		mockRepo.AssertNotCalled(t, "List")
+	})
+
+	t.Run("unique prefix", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+
+		mockRepo.On("FindByName", ctx, "depl").Return(nil, domain.ErrAliasNotFound)
+		mockRepo.On("List", ctx).Return(stored, nil)
+
+		alias, err := service.ResolveAlias(ctx, "depl", true)
+		assert.NoError(t, err)
+		assert.Equal(t, "deploy", alias.Name)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("ambiguous prefix", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+
+		mockRepo.On("FindByName", ctx, "build").Return(nil, domain.ErrAliasNotFound)
+		mockRepo.On("List", ctx).Return(stored, nil)
+
+		_, err := service.ResolveAlias(ctx, "build", true)
+		assert.ErrorIs(t, err, domain.ErrAmbiguousAlias)
+		assert.Contains(t, err.Error(), "build-prod, build-stg")
+	})
+
+	t.Run("prefix resolution disabled", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+
+		mockRepo.On("FindByName", ctx, "depl").Return(nil, domain.ErrAliasNotFound)
+
+		_, err := service.ResolveAlias(ctx, "depl", false)
+		assert.ErrorIs(t, err, domain.ErrAliasNotFound)
+		mockRepo.AssertNotCalled(t, "List")
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+
+		mockRepo.On("FindByName", ctx, "zzz").Return(nil, domain.ErrAliasNotFound)
+		mockRepo.On("List", ctx).Return(stored, nil)
+
+		_, err := service.ResolveAlias(ctx, "zzz", true)
+		assert.ErrorIs(t, err, domain.ErrAliasNotFound)
+	})
+}
+
+func TestSuggestAliases(t *testing.T) {
+	mockRepo := new(MockAliasRepository)
+	service := service.NewAliasService(mockRepo)
+	ctx := context.Background()
+
+	stored := []*domain.Alias{
+		{Name: "deploy-prod"},
+		{Name: "deploy-stg"},
+		{Name: "status"},
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{name: "shared prefix", query: "deploy", expected: []string{"deploy-prod", "deploy-stg"}},
+		{name: "typo", query: "stauts", expected: []string{"status"}},
+		{name: "nothing similar", query: "kubectl", expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cleanupMock(t, mockRepo)
+
+			mockRepo.On("List", ctx).Return(stored, nil)
+
+			suggestions, err := service.SuggestAliases(ctx, tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, suggestions)
+		})
+	}
+}
+
+func TestNamespaces(t *testing.T) {
+	mockRepo := new(MockAliasRepository)
+	service := service.NewAliasService(mockRepo)
+	ctx := context.Background()
+
+	stored := []*domain.Alias{
+		{Name: "k8s/logs"},
+		{Name: "k8s/prod/logs"},
+		{Name: "k8s-old"},
+		{Name: "git/st"},
+	}
+
+	t.Run("list namespace", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+
+		mockRepo.On("List", ctx).Return(stored, nil)
+
+		aliases, err := service.ListNamespace(ctx, "k8s")
+		assert.NoError(t, err)
+		assert.Equal(t, stored[:2], aliases)
+	})
+
+	t.Run("delete namespace", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+
+		mockRepo.On("List", ctx).Return(stored, nil)
+		mockRepo.On("Batch", ctx, []repository.Op{repository.DeleteOp("k8s/prod/logs")}).Return(nil)
+
+		removed, err := service.DeleteNamespace(ctx, "k8s/prod/", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"k8s/prod/logs"}, removed)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("delete the confirmed aliases of a namespace", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+
+		confirmed := []*domain.Alias{{Name: "k8s/logs", Revision: 2}, {Name: "k8s/prod/logs", Revision: 5}}
+		mockRepo.On("Batch", ctx, []repository.Op{
+			repository.DeleteOp("k8s/logs").At(2),
+			repository.DeleteOp("k8s/prod/logs").At(5),
+		}).Return(nil)
+
+		removed, err := service.DeleteNamespace(ctx, "k8s/", confirmed)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"k8s/logs", "k8s/prod/logs"}, removed)
+		mockRepo.AssertNotCalled(t, "List", ctx)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("delete empty namespace", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+
+		mockRepo.On("List", ctx).Return(stored, nil)
+
+		_, err := service.DeleteNamespace(ctx, "aws/", nil)
+		assert.ErrorIs(t, err, domain.ErrAliasNotFound)
+		mockRepo.AssertNotCalled(t, "Batch")
+	})
+
+	t.Run("move namespace", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+
+		expected := map[string]string{
+			"k8s/logs":      "kube/logs",
+			"k8s/prod/logs": "kube/prod/logs",
+		}
+		mockRepo.On("List", ctx).Return(stored, nil)
+		mockRepo.On("Rename", ctx, expected, false).Return(nil)
+
+		renames, err := service.MoveNamespace(ctx, "k8s/", "kube/", false)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, renames)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("move rejects other separator", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+
+		mockRepo.On("List", ctx).Return(stored, nil)
+
+		_, err := service.MoveNamespace(ctx, "k8s/", "kube.", false)
+		assert.ErrorIs(t, err, domain.ErrInvalidAliasName)
+		mockRepo.AssertNotCalled(t, "Rename")
+	})
+
+	t.Run("create rejects other separator", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+
+		err := service.CreateAlias(ctx, "k8s.logs", "kubectl logs")
+		assert.ErrorIs(t, err, domain.ErrInvalidAliasName)
+		mockRepo.AssertNotCalled(t, "Create")
+	})
+}
+
+func TestRenameAlias(t *testing.T) {
+	mockRepo := new(MockAliasRepository)
+	service := service.NewAliasService(mockRepo)
+	ctx := context.Background()
+
+	t.Run("rename alias successfully", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+
+		mockRepo.On("Rename", ctx, map[string]string{"old": "new"}, false).Return(nil)
+
+		err := service.RenameAlias(ctx, "old", "new", false)
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("rename to invalid name", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+
+		err := service.RenameAlias(ctx, "old", "bad name", false)
+		assert.ErrorIs(t, err, domain.ErrInvalidAliasName)
+		mockRepo.AssertNotCalled(t, "Rename")
+	})
+
+	t.Run("rename with empty name", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+
+		err := service.RenameAlias(ctx, "", "new", false)
+		assert.ErrorIs(t, err, domain.ErrEmptyAliasName)
+		mockRepo.AssertNotCalled(t, "Rename")
+	})
+}
+
+func TestCopyAlias(t *testing.T) {
+	mockRepo := new(MockAliasRepository)
+	service := service.NewAliasService(mockRepo)
+	ctx := context.Background()
+
+	source := func() *domain.Alias {
+		alias, _ := domain.NewAlias("src", "echo src", domain.WithDescription("Source"))
+		return alias
+	}
+
+	t.Run("copy alias successfully", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+
+		mockRepo.On("FindByName", ctx, "src").Return(source(), nil)
+		mockRepo.On("FindByName", ctx, "dst").Return(nil, domain.ErrAliasNotFound)
+		mockRepo.On("Create", ctx, mock.MatchedBy(func(a *domain.Alias) bool {
+			return a.Name == "dst" && a.Command == "echo src" && a.Description == "Source"
+		})).Return(nil)
+
+		err := service.CopyAlias(ctx, "src", "dst", false)
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("copy of a project alias goes to the global store", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+
+		src := source()
+		src.Layer = &domain.Layer{Name: "project", File: "/work/.mantrid.yaml"}
+		mockRepo.On("FindByName", ctx, "src").Return(src, nil)
+		mockRepo.On("FindByName", ctx, "dst").Return(nil, domain.ErrAliasNotFound)
+		mockRepo.On("Create", ctx, mock.MatchedBy(func(a *domain.Alias) bool {
+			return a.Name == "dst" && a.Layer == nil
+		})).Return(nil)
+
+		err := service.CopyAlias(ctx, "src", "dst", false)
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("copy onto existing alias", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+
+		existing, _ := domain.NewAlias("dst", "echo dst")
+		mockRepo.On("FindByName", ctx, "src").Return(source(), nil)
+		mockRepo.On("FindByName", ctx, "dst").Return(existing, nil)
+
+		err := service.CopyAlias(ctx, "src", "dst", false)
+		assert.ErrorIs(t, err, domain.ErrAliasExists)
+		mockRepo.AssertNotCalled(t, "Create")
+		mockRepo.AssertNotCalled(t, "Batch")
+	})
+
+	t.Run("copy onto existing alias with overwrite", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+
+		existing, _ := domain.NewAlias("dst", "echo dst")
+		existing.Revision = 2
+		existing.CreatedAt = time.Now().Add(-time.Hour)
+		mockRepo.On("FindByName", ctx, "src").Return(source(), nil)
+		mockRepo.On("FindByName", ctx, "dst").Return(existing, nil)
+		mockRepo.On("Batch", ctx, mock.MatchedBy(func(ops []repository.Op) bool {
+			return len(ops) == 2 &&
+				ops[0].Kind == repository.OpDelete && ops[0].Name == "dst" && ops[0].Expected == 2 &&
+				ops[1].Kind == repository.OpCreate && ops[1].Alias.Command == "echo src" &&
+				ops[1].Alias.CreatedAt.After(existing.CreatedAt) &&
+				ops[1].Alias.CreatedAt.Equal(ops[1].Alias.UpdatedAt)
+		})).Return(nil)
+
+		err := service.CopyAlias(ctx, "src", "dst", true)
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "Create")
+	})
+
+	t.Run("copy non-existent alias", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+
+		mockRepo.On("FindByName", ctx, "src").Return(nil, domain.ErrAliasNotFound)
+
+		err := service.CopyAlias(ctx, "src", "dst", false)
+		assert.ErrorIs(t, err, domain.ErrAliasNotFound)
+		mockRepo.AssertNotCalled(t, "Create")
+	})
+}
+
+func TestValidateAliases(t *testing.T) {
+	svc := service.NewAliasService(new(MockAliasRepository))
+
+	assert.NoError(t, svc.ValidateAliases([]*domain.Alias{
+		{Name: "a", Command: "echo a"},
+		{Name: "b", Command: "echo b"},
+	}))
+
+	err := svc.ValidateAliases([]*domain.Alias{
+		{Name: "a", Command: "echo a"},
+		{Name: "b", Command: ""},
+		{Name: "a", Command: "echo again"},
+		{Name: "k8s.logs", Command: "kubectl logs"},
+	})
+	var invalid service.ValidationErrors
+	assert.True(t, errors.As(err, &invalid))
+	assert.Len(t, invalid, 3)
+	assert.ErrorIs(t, invalid[1], domain.ErrEmptyAliasCommand)
+	assert.ErrorIs(t, invalid[2], domain.ErrAliasExists)
+	assert.ErrorIs(t, invalid[3], domain.ErrInvalidAliasName)
+}
+
+func TestBatchAliases(t *testing.T) {
+	mockRepo := new(MockAliasRepository)
+	svc := service.NewAliasService(mockRepo)
+	ctx := context.Background()
+
+	t.Run("apply batch", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+
+		ops := []repository.Op{
+			repository.CreateOp(&domain.Alias{Name: "a", Command: "echo a"}),
+			repository.DeleteOp("b"),
+		}
+		mockRepo.On("Batch", ctx, ops).Return(nil)
+
+		assert.NoError(t, svc.BatchAliases(ctx, ops))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid operations reported by index", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+
+		ops := []repository.Op{
+			repository.CreateOp(&domain.Alias{Name: "a", Command: "echo a"}),
+			repository.UpdateOp(&domain.Alias{Name: "b", Command: ""}),
+			repository.DeleteOp("k8s.old"),
+			repository.DeleteOp(""),
+			repository.CreateOp(nil),
+		}
+
+		err := svc.BatchAliases(ctx, ops)
+		var invalid service.ValidationErrors
+		assert.True(t, errors.As(err, &invalid))
+		assert.Len(t, invalid, 3)
+		assert.ErrorIs(t, invalid[1], domain.ErrEmptyAliasCommand)
+		assert.ErrorIs(t, invalid[3], domain.ErrEmptyAliasName)
+		assert.Error(t, invalid[4])
+		mockRepo.AssertNotCalled(t, "Batch")
+	})
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/repository"
+)
+
+// ConflictStrategy decides what happens to an imported alias whose name is
+// already taken in the store.
+type ConflictStrategy string
+
+const (
+	// ConflictSkip keeps the stored alias.
+	ConflictSkip ConflictStrategy = "skip"
+	// ConflictOverwrite replaces the stored alias with the imported one.
+	ConflictOverwrite ConflictStrategy = "overwrite"
+	// ConflictNewest keeps whichever alias was updated last.
+	ConflictNewest ConflictStrategy = "newest"
+	// ConflictInteractive asks ImportOptions.Decide about each conflict.
+	ConflictInteractive ConflictStrategy = "interactive"
+)
+
+// ConflictStrategies lists the valid conflict strategies.
+var ConflictStrategies = []ConflictStrategy{ConflictSkip, ConflictOverwrite, ConflictNewest, ConflictInteractive}
+
+// ImportOptions modifies how ImportAliases merges aliases into the store.
+type ImportOptions struct {
+	// Strategy decides what happens to aliases that already exist. The zero
+	// value skips them.
+	Strategy ConflictStrategy
+	// DryRun works out the result without writing anything.
+	DryRun bool
+	// Source, when set, makes the import maintain a set of aliases: they are
+	// tagged with Source, aliases tagged with it earlier that are no longer
+	// imported are removed, and aliases with another or no source are always
+	// skipped, whatever the Strategy.
+	Source string
+	// Decide settles the conflicts of the ConflictInteractive strategy: it
+	// reports whether imported replaces stored. An error aborts the import
+	// without writing anything.
+	Decide func(stored, imported *domain.Alias) (bool, error)
+}
+
+// ImportResult lists the names of the imported aliases by outcome.
+type ImportResult struct {
+	Created []string
+	Updated []string
+	// Skipped aliases already existed with a different definition and were
+	// kept as they were.
+	Skipped []string
+	// Unchanged aliases already existed with the same definition.
+	Unchanged []string
+	// Removed aliases had been imported from the same source before and are
+	// no longer part of it.
+	Removed []string
+	// Conflicts are the aliases a dry run with ConflictInteractive would
+	// ask about.
+	Conflicts []string
+}
+
+// ImportAliases merges aliases into the store in a single batch. Aliases
+// whose name is taken are handled according to opts.Strategy; an overwritten
+// alias keeps its creation time and takes the update time of the imported
+// one, when it has any. With opts.Source the import replaces what was
+// imported from that source before; see ImportOptions. Invalid aliases are
+// reported as ValidationErrors and nothing is written. The batch only
+// touches the aliases the import creates, updates or removes, and fails
+// with domain.ErrConflict, writing nothing, if any of them changed since
+// the store was read.
+func (s *aliasService) ImportAliases(ctx context.Context, aliases []*domain.Alias, opts ImportOptions) (*ImportResult, error) {
+	strategy := opts.Strategy
+	if strategy == "" {
+		strategy = ConflictSkip
+	}
+	if !validStrategy(strategy) {
+		names := make([]string, len(ConflictStrategies))
+		for i, s := range ConflictStrategies {
+			names[i] = string(s)
+		}
+		return nil, fmt.Errorf("invalid conflict strategy %q: must be one of %s", strategy, strings.Join(names, ", "))
+	}
+	if strategy == ConflictInteractive && opts.Decide == nil && !opts.DryRun {
+		return nil, errors.New("the interactive conflict strategy needs a way to decide conflicts")
+	}
+
+	if err := s.ValidateAliases(aliases); err != nil {
+		return nil, err
+	}
+
+	stored, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]*domain.Alias, len(stored))
+	for _, a := range stored {
+		existing[a.Name] = a
+	}
+
+	now := time.Now()
+	result := &ImportResult{}
+	var ops []repository.Op
+	imported := make(map[string]bool, len(aliases))
+	for _, alias := range aliases {
+		if opts.Source != "" {
+			tagged := *alias
+			tagged.Source = opts.Source
+			alias = &tagged
+		}
+		imported[alias.Name] = true
+
+		current, exists := existing[alias.Name]
+		switch {
+		case !exists:
+			created := *alias
+			if created.CreatedAt.IsZero() {
+				created.CreatedAt = now
+			}
+			if created.UpdatedAt.IsZero() {
+				created.UpdatedAt = created.CreatedAt
+			}
+			ops = append(ops, repository.CreateOp(&created))
+			result.Created = append(result.Created, alias.Name)
+			continue
+		case sameDefinition(current, alias):
+			result.Unchanged = append(result.Unchanged, alias.Name)
+			continue
+		}
+
+		replace := true
+		switch {
+		case opts.Source != "":
+			replace = current.Source == opts.Source
+		case strategy == ConflictSkip:
+			replace = false
+		case strategy == ConflictNewest:
+			replace = alias.UpdatedAt.After(current.UpdatedAt)
+		case strategy == ConflictInteractive && opts.DryRun:
+			result.Conflicts = append(result.Conflicts, alias.Name)
+			continue
+		case strategy == ConflictInteractive:
+			replace, err = opts.Decide(current, alias)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if !replace {
+			result.Skipped = append(result.Skipped, alias.Name)
+			continue
+		}
+
+		updated := *alias
+		updated.CreatedAt = current.CreatedAt
+		if updated.UpdatedAt.IsZero() {
+			updated.UpdatedAt = now
+		}
+		ops = append(ops, repository.UpdateOp(&updated).At(current.Revision))
+		result.Updated = append(result.Updated, alias.Name)
+	}
+
+	if opts.Source != "" {
+		for _, a := range stored {
+			if a.Source == opts.Source && !imported[a.Name] {
+				ops = append(ops, repository.DeleteOp(a.Name).At(a.Revision))
+				result.Removed = append(result.Removed, a.Name)
+			}
+		}
+	}
+
+	if opts.DryRun || len(ops) == 0 {
+		return result, nil
+	}
+	err = s.BatchAliases(ctx, ops)
+	if errors.Is(err, domain.ErrAliasExists) || errors.Is(err, domain.ErrAliasNotFound) {
+		// Created or removed by someone else since the store was read
+		err = fmt.Errorf("%w: %w", domain.ErrConflict, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// validStrategy reports whether strategy is one of ConflictStrategies.
+func validStrategy(strategy ConflictStrategy) bool {
+	for _, s := range ConflictStrategies {
+		if s == strategy {
+			return true
+		}
+	}
+	return false
+}
+
+// sameDefinition reports whether a and b define the same alias, ignoring
+// their timestamps.
+func sameDefinition(a, b *domain.Alias) bool {
+	return a.Name == b.Name &&
+		a.Command == b.Command &&
+		a.Description == b.Description &&
+		a.Completion == b.Completion &&
+		a.WorkDir == b.WorkDir &&
+		a.Source == b.Source
+}
@@ -0,0 +1,232 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/repository"
+	"github.com/msaglietto/mantrid/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportAliases(t *testing.T) {
+	mockRepo := new(MockAliasRepository)
+	svc := service.NewAliasService(mockRepo)
+	ctx := context.Background()
+
+	created := time.Now().Add(-time.Hour)
+	stored := func() []*domain.Alias {
+		return []*domain.Alias{
+			{Name: "gs", Command: "git status", CreatedAt: created, UpdatedAt: created, Revision: 1},
+			{Name: "k", Command: "kubectl", CreatedAt: created, UpdatedAt: created, Revision: 3},
+		}
+	}
+	imported := func() []*domain.Alias {
+		return []*domain.Alias{
+			{Name: "gs", Command: "git status"},
+			{Name: "k", Command: "kubectl --context prod"},
+			{Name: "ll", Command: "ls -la"},
+		}
+	}
+
+	t.Run("skip existing aliases", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+		mockRepo.On("List", ctx).Return(stored(), nil)
+		mockRepo.On("Batch", ctx, mock.MatchedBy(func(ops []repository.Op) bool {
+			return assert.ObjectsAreEqual([]string{"create ll=ls -la"}, describeOps(ops))
+		})).Return(nil)
+
+		result, err := svc.ImportAliases(ctx, imported(), service.ImportOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ll"}, result.Created)
+		assert.Empty(t, result.Updated)
+		assert.Equal(t, []string{"k"}, result.Skipped)
+		assert.Equal(t, []string{"gs"}, result.Unchanged)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("overwrite existing aliases", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+		mockRepo.On("List", ctx).Return(stored(), nil)
+		mockRepo.On("Batch", ctx, mock.MatchedBy(func(ops []repository.Op) bool {
+			return assert.ObjectsAreEqual([]string{"update k@3=kubectl --context prod", "create ll=ls -la"}, describeOps(ops)) &&
+				ops[0].Alias.CreatedAt.Equal(created) &&
+				ops[0].Alias.UpdatedAt.After(created)
+		})).Return(nil)
+
+		result, err := svc.ImportAliases(ctx, imported(), service.ImportOptions{Strategy: service.ConflictOverwrite})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ll"}, result.Created)
+		assert.Equal(t, []string{"k"}, result.Updated)
+		assert.Empty(t, result.Skipped)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("dry run writes nothing", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+		mockRepo.On("List", ctx).Return(stored(), nil)
+
+		result, err := svc.ImportAliases(ctx, imported(), service.ImportOptions{Strategy: service.ConflictOverwrite, DryRun: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ll"}, result.Created)
+		assert.Equal(t, []string{"k"}, result.Updated)
+		mockRepo.AssertNotCalled(t, "Batch", mock.Anything, mock.Anything)
+	})
+
+	t.Run("invalid aliases write nothing", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+
+		_, err := svc.ImportAliases(ctx, []*domain.Alias{{Name: "..", Command: "cd .."}}, service.ImportOptions{})
+		var invalid service.ValidationErrors
+		assert.True(t, errors.As(err, &invalid))
+		mockRepo.AssertNotCalled(t, "List", mock.Anything)
+	})
+
+	t.Run("newest keeps the latest update", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+		mockRepo.On("List", ctx).Return(stored(), nil)
+		newer := created.Add(time.Minute)
+		mockRepo.On("Batch", ctx, mock.MatchedBy(func(ops []repository.Op) bool {
+			return assert.ObjectsAreEqual([]string{"update gs@1=git status -sb"}, describeOps(ops)) &&
+				ops[0].Alias.UpdatedAt.Equal(newer) &&
+				ops[0].Alias.CreatedAt.Equal(created)
+		})).Return(nil)
+
+		result, err := svc.ImportAliases(ctx, []*domain.Alias{
+			{Name: "gs", Command: "git status -sb", UpdatedAt: newer},
+			{Name: "k", Command: "kubectl --context prod", UpdatedAt: created.Add(-time.Minute)},
+		}, service.ImportOptions{Strategy: service.ConflictNewest})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"gs"}, result.Updated)
+		assert.Equal(t, []string{"k"}, result.Skipped)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("interactive asks about each conflict", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+		mockRepo.On("List", ctx).Return(stored(), nil)
+		mockRepo.On("Batch", ctx, mock.Anything).Return(nil)
+
+		asked := []string{}
+		result, err := svc.ImportAliases(ctx, []*domain.Alias{
+			{Name: "gs", Command: "git status -sb"},
+			{Name: "k", Command: "kubectl --context prod"},
+		}, service.ImportOptions{
+			Strategy: service.ConflictInteractive,
+			Decide: func(stored, imported *domain.Alias) (bool, error) {
+				asked = append(asked, stored.Command+" -> "+imported.Command)
+				return imported.Name == "k", nil
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"git status -> git status -sb", "kubectl -> kubectl --context prod"}, asked)
+		assert.Equal(t, []string{"k"}, result.Updated)
+		assert.Equal(t, []string{"gs"}, result.Skipped)
+	})
+
+	t.Run("interactive dry run lists conflicts", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+		mockRepo.On("List", ctx).Return(stored(), nil)
+
+		result, err := svc.ImportAliases(ctx, imported(), service.ImportOptions{Strategy: service.ConflictInteractive, DryRun: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"k"}, result.Conflicts)
+		assert.Equal(t, []string{"ll"}, result.Created)
+	})
+
+	t.Run("interactive abort writes nothing", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+		mockRepo.On("List", ctx).Return(stored(), nil)
+		abort := errors.New("aborted")
+
+		_, err := svc.ImportAliases(ctx, imported(), service.ImportOptions{
+			Strategy: service.ConflictInteractive,
+			Decide:   func(_, _ *domain.Alias) (bool, error) { return false, abort },
+		})
+		assert.ErrorIs(t, err, abort)
+		mockRepo.AssertNotCalled(t, "Batch", mock.Anything, mock.Anything)
+
+		_, err = svc.ImportAliases(ctx, imported(), service.ImportOptions{Strategy: service.ConflictInteractive})
+		assert.ErrorContains(t, err, "needs a way to decide conflicts")
+	})
+
+	t.Run("source import maintains its own aliases", func(t *testing.T) {
+		const source = "make:/src/app/Makefile"
+		cleanupMock(t, mockRepo)
+		mockRepo.On("List", ctx).Return([]*domain.Alias{
+			{Name: "app/build", Command: "make build", Source: source, CreatedAt: created, Revision: 2},
+			{Name: "app/old", Command: "make old", Source: source, CreatedAt: created, Revision: 5},
+			{Name: "app/test", Command: "go test ./...", CreatedAt: created},
+			{Name: "web/build", Command: "npm run build --", Source: "npm:/src/web/package.json", CreatedAt: created},
+		}, nil)
+		mockRepo.On("Batch", ctx, mock.MatchedBy(func(ops []repository.Op) bool {
+			return assert.ObjectsAreEqual([]string{
+				"update app/build@2=make -j4 build",
+				"create app/lint=make lint",
+				"delete app/old@5",
+			}, describeOps(ops)) && ops[0].Alias.CreatedAt.Equal(created) && ops[1].Alias.Source == source
+		})).Return(nil)
+
+		result, err := svc.ImportAliases(ctx, []*domain.Alias{
+			{Name: "app/build", Command: "make -j4 build"},
+			{Name: "app/test", Command: "make test"},
+			{Name: "app/lint", Command: "make lint"},
+		}, service.ImportOptions{Source: source, Strategy: service.ConflictOverwrite})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"app/lint"}, result.Created)
+		assert.Equal(t, []string{"app/build"}, result.Updated)
+		assert.Equal(t, []string{"app/test"}, result.Skipped)
+		assert.Equal(t, []string{"app/old"}, result.Removed)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("aliases created meanwhile conflict", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+		mockRepo.On("List", ctx).Return(stored(), nil)
+		mockRepo.On("Batch", ctx, mock.Anything).Return(&repository.BatchError{
+			Index: 0, Op: repository.CreateOp(&domain.Alias{Name: "ll"}), Err: domain.ErrAliasExists,
+		})
+
+		_, err := svc.ImportAliases(ctx, imported(), service.ImportOptions{})
+		assert.ErrorIs(t, err, domain.ErrConflict)
+		assert.ErrorIs(t, err, domain.ErrAliasExists)
+	})
+
+	t.Run("unknown strategy", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+
+		_, err := svc.ImportAliases(ctx, imported(), service.ImportOptions{Strategy: "merge"})
+		assert.ErrorContains(t, err, `invalid conflict strategy "merge"`)
+	})
+}
+
+// describeOps renders ops as "kind name[@expected][=command]", for
+// comparison.
+func describeOps(ops []repository.Op) []string {
+	result := make([]string, len(ops))
+	for i, op := range ops {
+		d := string(op.Kind) + " " + op.Target()
+		if op.Expected != repository.AnyRevision {
+			d += fmt.Sprintf("@%d", op.Expected)
+		}
+		if op.Alias != nil {
+			d += "=" + op.Alias.Command
+		}
+		result[i] = d
+	}
+	return result
+}
+
+func TestValidateAlias(t *testing.T) {
+	svc := service.NewAliasService(new(MockAliasRepository))
+
+	assert.NoError(t, svc.ValidateAlias(&domain.Alias{Name: "k8s/logs", Command: "kubectl logs"}))
+	assert.ErrorIs(t, svc.ValidateAlias(&domain.Alias{Name: "k8s.logs", Command: "kubectl logs"}), domain.ErrInvalidAliasName)
+	assert.ErrorIs(t, svc.ValidateAlias(&domain.Alias{Name: "k", Command: ""}), domain.ErrEmptyAliasCommand)
+}
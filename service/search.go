@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/msaglietto/mantrid/domain"
+)
+
+// Searchable alias fields.
+const (
+	FieldName        = "name"
+	FieldCommand     = "command"
+	FieldDescription = "description"
+)
+
+// SearchFields lists the alias fields searched by default, most significant
+// first.
+var SearchFields = []string{FieldName, FieldDescription, FieldCommand}
+
+// fieldWeights makes a match in the name count more than one in the
+// description, and both more than one in the command.
+var fieldWeights = map[string]int{
+	FieldName:        3,
+	FieldDescription: 2,
+	FieldCommand:     1,
+}
+
+// SearchOptions modifies how SearchAliases matches the query.
+type SearchOptions struct {
+	// Regex treats the query as a regular expression instead of a fuzzy
+	// pattern.
+	Regex bool
+	// Fields restricts the search to the given fields. Empty means all of
+	// SearchFields.
+	Fields []string
+}
+
+// Span is a half-open byte range [Start, End) of a matched field value.
+type Span struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// SearchResult is an alias matching a search, with its relevance score and
+// the matched ranges of each matching field.
+type SearchResult struct {
+	Alias   *domain.Alias
+	Score   int
+	Matches map[string][]Span
+}
+
+// SearchAliases returns the aliases matching query, most relevant first.
+// Results with the same score are ordered by recency, most recently updated
+// first, and then by name.
+func (s *aliasService) SearchAliases(ctx context.Context, query string, opts SearchOptions) ([]SearchResult, error) {
+	if query == "" {
+		return nil, fmt.Errorf("search query cannot be empty")
+	}
+
+	fields := opts.Fields
+	if len(fields) == 0 {
+		fields = SearchFields
+	}
+	for _, f := range fields {
+		if _, ok := fieldWeights[f]; !ok {
+			return nil, fmt.Errorf("invalid search field %q: must be one of %s", f, strings.Join(SearchFields, ", "))
+		}
+	}
+
+	match := fuzzyMatch
+	if opts.Regex {
+		re, err := regexp.Compile(query)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression: %w", err)
+		}
+		match = func(text, _ string) (int, []Span) {
+			return regexMatch(re, text)
+		}
+	}
+
+	aliases, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []SearchResult
+	for _, alias := range aliases {
+		result := SearchResult{Alias: alias, Matches: map[string][]Span{}}
+		for _, field := range fields {
+			score, spans := match(FieldValue(alias, field), query)
+			if spans == nil {
+				continue
+			}
+			result.Matches[field] = spans
+			if weighted := score * fieldWeights[field]; weighted > result.Score {
+				result.Score = weighted
+			}
+		}
+		if len(result.Matches) > 0 {
+			results = append(results, result)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if !a.Alias.UpdatedAt.Equal(b.Alias.UpdatedAt) {
+			return a.Alias.UpdatedAt.After(b.Alias.UpdatedAt)
+		}
+		return a.Alias.Name < b.Alias.Name
+	})
+
+	return results, nil
+}
+
+// FieldValue returns the text of the named search field of alias.
+func FieldValue(alias *domain.Alias, field string) string {
+	switch field {
+	case FieldName:
+		return alias.Name
+	case FieldCommand:
+		return alias.Command
+	case FieldDescription:
+		return alias.Description
+	}
+	return ""
+}
+
+// fuzzyMatch matches query against text case-insensitively. A contiguous
+// match scores highest, especially at the start of text or of a word;
+// otherwise the query characters must appear in order, and matches with
+// fewer and shorter gaps score higher. It returns nil spans when query does
+// not match.
+func fuzzyMatch(text, query string) (int, []Span) {
+	lowerText, lowerQuery := strings.ToLower(text), strings.ToLower(query)
+	if len(lowerText) != len(text) {
+		// Lowercasing changed byte offsets; match on the original text
+		lowerText, lowerQuery = text, query
+	}
+
+	if i := strings.Index(lowerText, lowerQuery); i >= 0 {
+		score := 100
+		if i == 0 {
+			score += 50
+		} else if isBoundary(text, i) {
+			score += 25
+		}
+		if len(query) == len(text) {
+			score += 50
+		}
+		return score, []Span{{Start: i, End: i + len(query)}}
+	}
+
+	var spans []Span
+	score := 0
+	pos := 0
+	for _, qr := range lowerQuery {
+		i := strings.IndexRune(lowerText[pos:], qr)
+		if i < 0 {
+			return 0, nil
+		}
+		start := pos + i
+		end := start + utf8.RuneLen(qr)
+
+		score += 10
+		if isBoundary(text, start) {
+			score += 5
+		}
+		if n := len(spans); n > 0 && spans[n-1].End == start {
+			spans[n-1].End = end
+			score += 10
+		} else {
+			if n > 0 {
+				score -= min(start-spans[n-1].End, 10)
+			}
+			spans = append(spans, Span{Start: start, End: end})
+		}
+		pos = end
+	}
+
+	return max(score, 1), spans
+}
+
+// regexMatch matches re against text. Earlier first matches score higher.
+func regexMatch(re *regexp.Regexp, text string) (int, []Span) {
+	locs := re.FindAllStringIndex(text, -1)
+	if locs == nil {
+		return 0, nil
+	}
+
+	spans := make([]Span, 0, len(locs))
+	for _, loc := range locs {
+		if loc[0] == loc[1] {
+			continue
+		}
+		spans = append(spans, Span{Start: loc[0], End: loc[1]})
+	}
+	if len(spans) == 0 {
+		// Only empty matches, e.g. "^": the field matches with nothing to highlight
+		return 100, []Span{}
+	}
+
+	score := 100
+	if spans[0].Start == 0 {
+		score += 50
+	} else if isBoundary(text, spans[0].Start) {
+		score += 25
+	}
+	return score, spans
+}
+
+// isBoundary reports whether byte offset i of text starts a word.
+func isBoundary(text string, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev, _ := utf8.DecodeLastRuneInString(text[:i])
+	return !unicode.IsLetter(prev) && !unicode.IsDigit(prev)
+}
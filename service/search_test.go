@@ -0,0 +1,101 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchAliases(t *testing.T) {
+	mockRepo := new(MockAliasRepository)
+	svc := service.NewAliasService(mockRepo)
+	ctx := context.Background()
+
+	now := time.Now()
+	stored := []*domain.Alias{
+		{Name: "kgp", Command: "kubectl get pods", UpdatedAt: now},
+		{Name: "deploy", Command: "kubectl apply -f $1", Description: "Deploy a manifest", UpdatedAt: now},
+		{Name: "gs", Command: "git status", UpdatedAt: now},
+		{Name: "dps", Command: "docker ps", UpdatedAt: now.Add(-time.Hour)},
+		{Name: "dlogs", Command: "docker logs -f", UpdatedAt: now},
+	}
+
+	names := func(results []service.SearchResult) []string {
+		out := make([]string, len(results))
+		for i, r := range results {
+			out[i] = r.Alias.Name
+		}
+		return out
+	}
+
+	t.Run("name match ranks above command match", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+		mockRepo.On("List", ctx).Return(stored, nil)
+
+		results, err := svc.SearchAliases(ctx, "deploy", service.SearchOptions{})
+		require.NoError(t, err)
+		require.NotEmpty(t, results)
+		assert.Equal(t, "deploy", results[0].Alias.Name)
+		assert.Equal(t, []service.Span{{Start: 0, End: 6}}, results[0].Matches[service.FieldName])
+		assert.Contains(t, results[0].Matches, service.FieldDescription)
+	})
+
+	t.Run("fuzzy subsequence match", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+		mockRepo.On("List", ctx).Return(stored, nil)
+
+		results, err := svc.SearchAliases(ctx, "kgpods", service.SearchOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"kgp"}, names(results))
+		assert.Equal(t, []service.Span{{Start: 0, End: 1}, {Start: 8, End: 9}, {Start: 12, End: 16}},
+			results[0].Matches[service.FieldCommand])
+	})
+
+	t.Run("ties broken by recency", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+		mockRepo.On("List", ctx).Return(stored, nil)
+
+		results, err := svc.SearchAliases(ctx, "docker", service.SearchOptions{Fields: []string{service.FieldCommand}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"dlogs", "dps"}, names(results))
+	})
+
+	t.Run("restricted to field", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+		mockRepo.On("List", ctx).Return(stored, nil)
+
+		results, err := svc.SearchAliases(ctx, "kubectl", service.SearchOptions{Fields: []string{service.FieldName}})
+		require.NoError(t, err)
+		assert.Empty(t, results)
+	})
+
+	t.Run("regex", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+		mockRepo.On("List", ctx).Return(stored, nil)
+
+		results, err := svc.SearchAliases(ctx, `kubectl (get|apply)`, service.SearchOptions{Regex: true})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"kgp", "deploy"}, names(results))
+	})
+
+	t.Run("invalid regex", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+
+		_, err := svc.SearchAliases(ctx, `(`, service.SearchOptions{Regex: true})
+		assert.Error(t, err)
+		mockRepo.AssertNotCalled(t, "List")
+	})
+
+	t.Run("invalid field", func(t *testing.T) {
+		cleanupMock(t, mockRepo)
+
+		_, err := svc.SearchAliases(ctx, "x", service.SearchOptions{Fields: []string{"created_at"}})
+		assert.Error(t, err)
+		mockRepo.AssertNotCalled(t, "List")
+	})
+}
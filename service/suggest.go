@@ -0,0 +1,81 @@
+package service
+
+import (
+	"sort"
+	"strings"
+)
+
+// maxSuggestions caps how many "did you mean" candidates are returned.
+const maxSuggestions = 3
+
+// suggestNames returns the candidates closest to name, best match first.
+// A candidate qualifies when name is a prefix of it or when its edit
+// distance from name is small relative to the length of name.
+func suggestNames(name string, candidates []string) []string {
+	type scored struct {
+		name     string
+		distance int
+	}
+
+	threshold := len(name) / 3
+	if threshold < 2 {
+		threshold = 2
+	}
+
+	lower := strings.ToLower(name)
+	var matches []scored
+	for _, candidate := range candidates {
+		if candidate == name {
+			continue
+		}
+		candidateLower := strings.ToLower(candidate)
+		if strings.HasPrefix(candidateLower, lower) {
+			matches = append(matches, scored{name: candidate, distance: 0})
+			continue
+		}
+		if d := levenshtein(lower, candidateLower); d <= threshold {
+			matches = append(matches, scored{name: candidate, distance: d})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	if len(matches) > maxSuggestions {
+		matches = matches[:maxSuggestions]
+	}
+
+	result := make([]string, len(matches))
+	for i, m := range matches {
+		result[i] = m.name
+	}
+	return result
+}
+
+// levenshtein computes the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/msaglietto/mantrid/domain"
+	"github.com/msaglietto/mantrid/repository"
+)
+
+// ValidationErrors reports the aliases of a batch that failed validation,
+// keyed by their index in the batch.
+type ValidationErrors map[int]error
+
+func (e ValidationErrors) Error() string {
+	indexes := make([]int, 0, len(e))
+	for i := range e {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	msgs := make([]string, len(indexes))
+	for n, i := range indexes {
+		msgs[n] = fmt.Sprintf("alias #%d: %v", i+1, e[i])
+	}
+	return fmt.Sprintf("%d invalid aliases: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// ValidateAliases validates every alias of a batch that is meant to be
+// stored together, including that no two share a name. Invalid aliases are
+// reported as ValidationErrors.
+func (s *aliasService) ValidateAliases(aliases []*domain.Alias) error {
+	errs := ValidationErrors{}
+	seen := make(map[string]int, len(aliases))
+	for i, alias := range aliases {
+		if err := s.ValidateAlias(alias); err != nil {
+			errs[i] = err
+			continue
+		}
+		if first, ok := seen[alias.Name]; ok {
+			errs[i] = fmt.Errorf("%w: %q is also alias #%d", domain.ErrAliasExists, alias.Name, first+1)
+			continue
+		}
+		seen[alias.Name] = i
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateOps validates the aliases created and updated by the operations
+// of a batch. Deleted aliases only need a name, so that aliases stored with
+// a name that is no longer valid can still be removed.
+func (s *aliasService) validateOps(ops []repository.Op) error {
+	errs := ValidationErrors{}
+	for i, op := range ops {
+		switch op.Kind {
+		case repository.OpCreate, repository.OpUpdate:
+			if op.Alias == nil {
+				errs[i] = fmt.Errorf("no alias to %s", op.Kind)
+			} else if err := s.ValidateAlias(op.Alias); err != nil {
+				errs[i] = err
+			}
+		case repository.OpDelete:
+			if op.Name == "" {
+				errs[i] = domain.ErrEmptyAliasName
+			}
+		default:
+			errs[i] = fmt.Errorf("unknown operation %q", op.Kind)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}